@@ -0,0 +1,62 @@
+// Command friendctl manages users and relationships of the database of DATABASE_URL or CONFIG_FILE without the API
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/friendctl"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+)
+
+func main() {
+	format := flag.String("format", friendctl.FormatTable, "format of results: table or json")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), friendctl.Usage)
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || flag.Arg(0) == "help" {
+		flag.Usage()
+		return
+	}
+	if *format != friendctl.FormatTable && *format != friendctl.FormatJSON {
+		exit(fmt.Errorf("unknown format %q, it must be table or json", *format))
+	}
+
+	// The database and the normalization of emails are configured the same way as the server
+	cfg, _, err := config.Load(nil)
+	if err != nil {
+		exit(err)
+	}
+
+	driver, err := cfg.Database.Driver()
+	if err != nil {
+		exit(err)
+	}
+	db, err := config.NewDatabase(cfg.Database)
+	if err != nil {
+		exit(err)
+	}
+	defer config.CloseDatabase(db)
+
+	repo := repository.NewDriverRepo(db, driver)
+	commands := friendctl.NewCommands(repo, repo, repository.NewEmailNormalizer(cfg.EmailLowercaseLocalPart))
+	result, err := commands.Run(context.Background(), flag.Args())
+	if err != nil {
+		config.CloseDatabase(db)
+		exit(err)
+	}
+	if err := result.Write(os.Stdout, *format); err != nil {
+		config.CloseDatabase(db)
+		exit(err)
+	}
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, "friendctl:", friendctl.ErrorMessage(err))
+	os.Exit(1)
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Load(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`
+listen_addr: ":9000"
+log_level: debug
+database:
+  url: sqlite://friendmanagement.db
+  max_open_conns: 20
+  conn_max_lifetime: 1h
+request_timeout: 3s
+cors:
+  allowed_origins: ["https://app.example.com"]
+`), 0600))
+
+	missingFile := filepath.Join(t.TempDir(), "missing.yaml")
+
+	tcs := map[string]struct {
+		env       map[string]string
+		args      []string
+		expConfig func(cfg *Config)
+		expArgs   []string
+		expError  error
+	}{
+		"success with defaults": {
+			env: map[string]string{"DATABASE_URL": "postgres://127.0.0.1/friendmanagement"},
+			expConfig: func(cfg *Config) {
+				cfg.Database.URL = "postgres://127.0.0.1/friendmanagement"
+			},
+		},
+		"success with a YAML file which is overridden by env vars and flags": {
+			env: map[string]string{
+				"CONFIG_FILE":                file,
+				"LOG_LEVEL":                  "warn",
+				"DB_MAX_IDLE_CONNS":          "8",
+				"EMAIL_LOWERCASE_LOCAL_PART": "false",
+			},
+			args: []string{"--listen-addr", ":9100", "--request-timeout=10s", "migrate", "up"},
+			expConfig: func(cfg *Config) {
+				cfg.ListenAddr = ":9100"
+				cfg.LogLevel = "warn"
+				cfg.Database.URL = "sqlite://friendmanagement.db"
+				cfg.Database.MaxOpenConns = 20
+				cfg.Database.MaxIdleConns = 8
+				cfg.Database.ConnMaxLifetime = time.Hour
+				cfg.RequestTimeout = 10 * time.Second
+				cfg.EmailLowercaseLocalPart = false
+				cfg.CORS.AllowedOrigins = []string{"https://app.example.com"}
+			},
+			expArgs: []string{"migrate", "up"},
+		},
+		"success with the memory storage and a list of origins": {
+			env:  map[string]string{"CORS_ALLOWED_ORIGINS": "https://a.example.com, https://b.example.com,"},
+			args: []string{"--storage=memory", "--shutdown-delay=5s"},
+			expConfig: func(cfg *Config) {
+				cfg.Storage = StorageMemory
+				cfg.Server.ShutdownDelay = 5 * time.Second
+				cfg.CORS.AllowedOrigins = []string{"https://a.example.com", "https://b.example.com"}
+			},
+		},
+		"success with the file exporter of spans": {
+			env:  map[string]string{"TRACING_EXPORTER": "file", "TRACING_FILE": "spans.json", "TRACING_SAMPLE_RATIO": "0.25"},
+			args: []string{"--storage=memory", "--tracing-sql-statements"},
+			expConfig: func(cfg *Config) {
+				cfg.Storage = StorageMemory
+				cfg.Tracing.Exporter = TracingFile
+				cfg.Tracing.File = "spans.json"
+				cfg.Tracing.SampleRatio = 0.25
+				cfg.Tracing.SQLStatements = true
+			},
+		},
+		"success with a JWKS file and a required issuer": {
+			env:  map[string]string{"AUTH_JWKS_FILE": "jwks.json", "AUTH_JWT_ISSUER": "https://auth.example.com"},
+			args: []string{"--storage=memory", "--auth-jwt-audience=friend-management"},
+			expConfig: func(cfg *Config) {
+				cfg.Storage = StorageMemory
+				cfg.Auth.JWKSFile = "jwks.json"
+				cfg.Auth.JWTIssuer = "https://auth.example.com"
+				cfg.Auth.JWTAudience = "friend-management"
+			},
+		},
+		"success without authentication": {
+			env:  map[string]string{"AUTH_ENABLED": "false"},
+			args: []string{"--storage=memory"},
+			expConfig: func(cfg *Config) {
+				cfg.Storage = StorageMemory
+				cfg.Auth.Enabled = false
+			},
+		},
+		"failed with a JWT key file and a JWKS file": {
+			env:      map[string]string{"AUTH_JWT_KEY_FILE": "jwt.pem"},
+			args:     []string{"--storage=memory", "--auth-jwks-file=jwks.json"},
+			expError: errors.New("invalid configuration: only one of the JWT key file and the JWKS file must be set"),
+		},
+		"failed with invalid tracing values": {
+			args:     []string{"--storage=memory", "--tracing-exporter=file", "--tracing-sample-ratio=2"},
+			expError: errors.New("invalid configuration: tracing file must not be empty for the file exporter, tracing sample ratio must be between 0 and 1"),
+		},
+		"failed with invalid env vars": {
+			env:      map[string]string{"DB_MAX_OPEN_CONNS": "ten", "REQUEST_TIMEOUT": "5"},
+			expError: errors.New("DB_MAX_OPEN_CONNS must be a number: \"ten\", REQUEST_TIMEOUT must be a duration (ex: 5s): \"5\""),
+		},
+		"failed with invalid values": {
+			env:      map[string]string{"LOG_LEVEL": "verbose", "DB_MAX_IDLE_CONNS": "20", "SCHEMA_CHECK": "skip"},
+			expError: errors.New("invalid configuration: log level must be trace, debug, info, warn or error: \"verbose\", DATABASE_URL not found, maximum number of idle connections must be between 0 and the maximum number of open connections, schema check must be fail or warn: \"skip\""),
+		},
+		"failed with a write timeout which is shorter than the friend path timeout": {
+			env:      map[string]string{"DATABASE_URL": "postgres://127.0.0.1/friendmanagement", "WRITE_TIMEOUT": "10s"},
+			expError: errors.New("invalid configuration: write timeout must be longer than the request and friend path timeouts"),
+		},
+		"failed with a missing YAML file": {
+			args:     []string{"--config", missingFile},
+			expError: errors.New("open " + missingFile + ": no such file or directory"),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			// Empty env vars are not set
+			for _, key := range []string{"CONFIG_FILE", "DATABASE_URL", "LOG_LEVEL", "DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS",
+				"REQUEST_TIMEOUT", "WRITE_TIMEOUT", "SCHEMA_CHECK", "EMAIL_LOWERCASE_LOCAL_PART", "CORS_ALLOWED_ORIGINS",
+				"TRACING_EXPORTER", "TRACING_FILE", "TRACING_SAMPLE_RATIO", "AUTH_ENABLED", "AUTH_JWT_KEY_FILE", "AUTH_JWKS_FILE",
+				"AUTH_JWT_ISSUER"} {
+				t.Setenv(key, "")
+			}
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, args, err := Load(tc.args)
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+				return
+			}
+			require.NoError(t, err)
+			expConfig := Default()
+			tc.expConfig(&expConfig)
+			require.Equal(t, expConfig, cfg)
+			if tc.expArgs == nil {
+				require.Empty(t, args)
+			} else {
+				require.Equal(t, tc.expArgs, args)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_ParseDatabaseURL(t *testing.T) {
+	tcs := map[string]struct {
+		dbUrl     string
+		expDriver string
+		expDsn    string
+		expError  error
+	}{
+		"success with a postgres url": {
+			dbUrl:     "postgres://friendmanagement:@127.0.0.1:5432/friendmanagement?sslmode=disable",
+			expDriver: DriverPostgres,
+			expDsn:    "postgres://friendmanagement:@127.0.0.1:5432/friendmanagement?sslmode=disable",
+		},
+		"success with a sqlite url": {
+			dbUrl:     "sqlite://data/friendmanagement.db",
+			expDriver: DriverSQLite,
+			expDsn:    "file:data/friendmanagement.db?_busy_timeout=5000&_foreign_keys=on&_txlock=immediate",
+		},
+		"success with a sqlite url of a temporary database and a busy timeout": {
+			dbUrl:     "sqlite://:memory:?_busy_timeout=100",
+			expDriver: DriverSQLite,
+			expDsn:    "file::memory:?_busy_timeout=100&_foreign_keys=on&_txlock=immediate",
+		},
+		"failed with a sqlite url without path": {
+			dbUrl:    "sqlite://",
+			expError: errors.New("DATABASE_URL has no path of the SQLite database"),
+		},
+		"failed with an unsupported scheme": {
+			dbUrl:    "mysql://root@127.0.0.1/friendmanagement",
+			expError: errors.New("DATABASE_URL has an unsupported scheme \"mysql\", it must be postgres or sqlite"),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			driver, dsn, err := ParseDatabaseURL(tc.dbUrl)
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expDriver, driver)
+			require.Equal(t, tc.expDsn, dsn)
+		})
+	}
+}
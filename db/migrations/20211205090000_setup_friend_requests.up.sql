@@ -0,0 +1,19 @@
+-- Setup friend requests which have to be accepted before a friendship is created.
+
+BEGIN;
+
+CREATE TABLE friend_requests (
+    id SERIAL PRIMARY KEY,
+    requestor_id INTEGER REFERENCES users NOT NULL,
+    target_id INTEGER REFERENCES users NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    CONSTRAINT constraint_friend_requests_status CHECK (status IN ('pending', 'accepted', 'rejected', 'cancelled'))
+);
+
+-- Only one pending request is allowed for the same requestor and target
+CREATE UNIQUE INDEX pending_on_friend_requests ON friend_requests(requestor_id, target_id) WHERE status = 'pending';
+CREATE INDEX target_id_on_friend_requests ON friend_requests(target_id);
+
+COMMIT;
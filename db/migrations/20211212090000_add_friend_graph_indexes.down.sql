@@ -0,0 +1,8 @@
+-- Reverses the corresponding up script
+
+BEGIN;
+
+DROP INDEX friend_id_on_friends;
+DROP INDEX target_id_on_user_blocks;
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Indexes for looking up relationships from the second column, used by friend suggestions.
+
+BEGIN;
+
+CREATE INDEX friend_id_on_friends ON friends(friend_id);
+CREATE INDEX target_id_on_user_blocks ON user_blocks(target_id);
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Reverses the corresponding up script, normalized emails are kept
+
+BEGIN;
+
+DROP INDEX lower_email_on_users;
+CREATE INDEX email_on_users ON users(email);
+
+COMMIT;
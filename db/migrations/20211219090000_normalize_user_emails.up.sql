@@ -0,0 +1,31 @@
+-- Case-insensitive email identity: trim and lowercase the domain of existing emails,
+-- then enforce uniqueness on the lowercased email. Aborts when existing emails collide.
+
+BEGIN;
+
+DO $$
+DECLARE
+    collisions TEXT;
+BEGIN
+    SELECT string_agg(normalized_email || ' (' || emails || ')', ', ') INTO collisions
+    FROM (
+        SELECT lower(btrim(email)) AS normalized_email, string_agg(email, ', ' ORDER BY id) AS emails
+        FROM users
+        GROUP BY lower(btrim(email))
+        HAVING COUNT(*) > 1
+    ) AS duplicated;
+
+    IF collisions IS NOT NULL THEN
+        RAISE EXCEPTION 'users have colliding emails after normalization, merge them before migrating: %', collisions;
+    END IF;
+END $$;
+
+UPDATE users
+SET email = substring(btrim(email) FROM '^(.*)@') || '@' || lower(substring(btrim(email) FROM '@([^@]*)$'))
+WHERE email LIKE '%@%'
+    AND email <> substring(btrim(email) FROM '^(.*)@') || '@' || lower(substring(btrim(email) FROM '@([^@]*)$'));
+
+DROP INDEX email_on_users;
+CREATE UNIQUE INDEX lower_email_on_users ON users(lower(email));
+
+COMMIT;
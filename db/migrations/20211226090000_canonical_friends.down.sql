@@ -0,0 +1,8 @@
+-- Reverses the corresponding up script, merged friendships are kept in the canonical order
+
+BEGIN;
+
+ALTER TABLE friends DROP CONSTRAINT constraint_friends_ordered;
+ALTER TABLE friends DROP CONSTRAINT constraint_friends_not_self;
+
+COMMIT;
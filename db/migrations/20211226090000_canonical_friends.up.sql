@@ -0,0 +1,17 @@
+-- Store every friendship once in the canonical order user_id < friend_id.
+-- Self friendships are removed and reversed duplicates are merged into the oldest row.
+
+BEGIN;
+
+DELETE FROM friends WHERE user_id = friend_id;
+
+DELETE FROM friends f
+USING friends d
+WHERE f.user_id = d.friend_id AND f.friend_id = d.user_id AND f.id > d.id;
+
+UPDATE friends SET user_id = friend_id, friend_id = user_id WHERE user_id > friend_id;
+
+ALTER TABLE friends ADD CONSTRAINT constraint_friends_not_self CHECK (user_id <> friend_id);
+ALTER TABLE friends ADD CONSTRAINT constraint_friends_ordered CHECK (user_id < friend_id);
+
+COMMIT;
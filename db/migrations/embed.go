@@ -0,0 +1,25 @@
+// Package migrations embeds the SQL migrations of the databases into the binary,
+// files are named {version}_{name}.up.sql and {version}_{name}.down.sql as golang-migrate expects
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed *.sql
+var postgres embed.FS
+
+//go:embed sqlite/*.sql
+var sqlite embed.FS
+
+// Postgres gets the migrations of Postgres databases
+func Postgres() fs.FS {
+	return postgres
+}
+
+// SQLite gets the migrations of SQLite databases
+func SQLite() fs.FS {
+	sub, _ := fs.Sub(sqlite, "sqlite")
+	return sub
+}
@@ -0,0 +1,11 @@
+-- Reverses the corresponding up script
+
+BEGIN;
+
+DROP TABLE friend_requests;
+DROP TABLE user_blocks;
+DROP TABLE subscriptions;
+DROP TABLE friends;
+DROP TABLE users;
+
+COMMIT;
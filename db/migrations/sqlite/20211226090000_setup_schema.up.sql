@@ -0,0 +1,67 @@
+-- Setup the SQLite database for friends managment with the same schema as the Postgres migrations
+-- up to this version and dummy data. Later changes of the schema are added to both sets of migrations.
+
+BEGIN;
+
+-- Setup users table, emails are unique case-insensitively
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    email VARCHAR(100) UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX lower_email_on_users ON users(lower(email));
+
+-- Setup friends table, every friendship is stored once in the canonical order user_id < friend_id
+CREATE TABLE friends (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users,
+    friend_id INTEGER NOT NULL REFERENCES users,
+    CONSTRAINT constraint_friends_pkey UNIQUE (user_id, friend_id),
+    CONSTRAINT constraint_friends_not_self CHECK (user_id <> friend_id),
+    CONSTRAINT constraint_friends_ordered CHECK (user_id < friend_id)
+);
+CREATE INDEX friend_id_on_friends ON friends(friend_id);
+
+-- Setup subscriptions table
+CREATE TABLE subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_requestor_id INTEGER NOT NULL REFERENCES users,
+    subscription_target_id INTEGER NOT NULL REFERENCES users,
+    CONSTRAINT constraint_subscriptions_pkey UNIQUE (subscription_requestor_id, subscription_target_id)
+);
+
+-- Setup user_blocks table
+CREATE TABLE user_blocks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    requestor_id INTEGER NOT NULL REFERENCES users,
+    target_id INTEGER NOT NULL REFERENCES users,
+    CONSTRAINT constraint_user_blocks_pkey UNIQUE (requestor_id, target_id)
+);
+CREATE INDEX target_id_on_user_blocks ON user_blocks(target_id);
+
+-- Setup friend requests which have to be accepted before a friendship is created
+CREATE TABLE friend_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    requestor_id INTEGER NOT NULL REFERENCES users,
+    target_id INTEGER NOT NULL REFERENCES users,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    CONSTRAINT constraint_friend_requests_status CHECK (status IN ('pending', 'accepted', 'rejected', 'cancelled'))
+);
+
+-- Only one pending request is allowed for the same requestor and target
+CREATE UNIQUE INDEX pending_on_friend_requests ON friend_requests(requestor_id, target_id) WHERE status = 'pending';
+CREATE INDEX target_id_on_friend_requests ON friend_requests(target_id);
+
+-- dummy data for testing
+INSERT INTO users(name, email, created_at, updated_at) VALUES
+('john','john@example.com', strftime('%Y-%m-%d %H:%M:%f', 'now'), strftime('%Y-%m-%d %H:%M:%f', 'now')),
+('andy','andy@example.com', strftime('%Y-%m-%d %H:%M:%f', 'now'), strftime('%Y-%m-%d %H:%M:%f', 'now')),
+('common','common@example.com', strftime('%Y-%m-%d %H:%M:%f', 'now'), strftime('%Y-%m-%d %H:%M:%f', 'now')),
+('lisa','lisa@example.com', strftime('%Y-%m-%d %H:%M:%f', 'now'), strftime('%Y-%m-%d %H:%M:%f', 'now')),
+('kate','kate@example.com', strftime('%Y-%m-%d %H:%M:%f', 'now'), strftime('%Y-%m-%d %H:%M:%f', 'now'));
+
+COMMIT;
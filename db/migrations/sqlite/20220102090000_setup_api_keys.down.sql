@@ -0,0 +1,7 @@
+-- Reverses the corresponding up script
+
+BEGIN;
+
+DROP TABLE api_keys;
+
+COMMIT;
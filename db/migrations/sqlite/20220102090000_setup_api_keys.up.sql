@@ -0,0 +1,14 @@
+-- Setup API keys of clients of the API, only the SHA-256 hash of a key is stored so keys cannot be read back.
+-- A revoked key is kept with revoked_at so its name is not reused
+
+BEGIN;
+
+CREATE TABLE api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    key_hash CHAR(64) UNIQUE NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+
+COMMIT;
@@ -0,0 +1,75 @@
+// Package apperrors maps domain errors to HTTP status codes and stable machine-readable codes
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code of errors which are not mapped to a domain error
+const CodeInternal = "internal_error"
+
+// Error is a domain error with the HTTP status code and the code responded to clients
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (_self Error) Error() string {
+	return _self.Message
+}
+
+// Create a new domain error
+func New(status int, code string, format string, args ...interface{}) Error {
+	return Error{Status: status, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Create an error for a malformed request
+func BadRequest(code string, format string, args ...interface{}) Error {
+	return New(http.StatusBadRequest, code, format, args...)
+}
+
+// Create an error for a request without valid credentials
+func Unauthorized(code string, format string, args ...interface{}) Error {
+	return New(http.StatusUnauthorized, code, format, args...)
+}
+
+// Create an error for a resource which does not exist
+func NotFound(code string, format string, args ...interface{}) Error {
+	return New(http.StatusNotFound, code, format, args...)
+}
+
+// Create an error for a resource which has been existed
+func Conflict(code string, format string, args ...interface{}) Error {
+	return New(http.StatusConflict, code, format, args...)
+}
+
+// Create an error for a well-formed request with invalid fields
+func Unprocessable(code string, format string, args ...interface{}) Error {
+	return New(http.StatusUnprocessableEntity, code, format, args...)
+}
+
+// Create an error for a failure of server side
+func Internal(code string, format string, args ...interface{}) Error {
+	return New(http.StatusInternalServerError, code, format, args...)
+}
+
+// Get HTTP status code of an error, errors which are not domain errors are internal server errors
+func StatusOf(err error) int {
+	var appErr Error
+	if errors.As(err, &appErr) {
+		return appErr.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// Get code of an error, errors which are not domain errors have CodeInternal
+func CodeOf(err error) string {
+	var appErr Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return CodeInternal
+}
@@ -0,0 +1,49 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApperrors_StatusOfAndCodeOf(t *testing.T) {
+	tcs := map[string]struct {
+		err       error
+		expStatus int
+		expCode   string
+	}{
+		"success with a domain error": {
+			err:       NotFound("user_not_found", "%s is not exists", "test@example.com"),
+			expStatus: http.StatusNotFound,
+			expCode:   "user_not_found",
+		},
+		"success with a wrapped domain error": {
+			err:       fmt.Errorf("create friend: %w", Conflict("friendship_exists", "The friend relationship has been existed")),
+			expStatus: http.StatusConflict,
+			expCode:   "friendship_exists",
+		},
+		"success with an unknown error": {
+			err:       errors.New("pq: connection refused"),
+			expStatus: http.StatusInternalServerError,
+			expCode:   CodeInternal,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			require.Equal(t, tc.expStatus, StatusOf(tc.err))
+			require.Equal(t, tc.expCode, CodeOf(tc.err))
+		})
+	}
+}
+
+func TestApperrors_Is(t *testing.T) {
+	errNotExistedUser := NotFound("user_not_found", "The user does not exist")
+
+	require.True(t, errors.Is(fmt.Errorf("get user: %w", errNotExistedUser), errNotExistedUser))
+	require.False(t, errors.Is(NotFound("user_not_found", "test@example.com is not exists"), errNotExistedUser))
+	require.Equal(t, "The user does not exist", errNotExistedUser.Error())
+}
@@ -0,0 +1,153 @@
+// Package auth authenticates clients of the API by API keys and JWT bearer tokens
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/apperrors"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APIKeyHeader is the header of API keys, JWTs are sent in the Authorization header as bearer tokens
+const APIKeyHeader = "X-API-Key"
+
+// Methods of authentication of a principal
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Prefix of generated API keys, so leaked keys are easy to find in logs and repositories
+const apiKeyPrefix = "fm_"
+
+var (
+	ErrMissingCredentials = apperrors.Unauthorized("unauthenticated", "An API key or a bearer token is required")
+	ErrInvalidCredentials = apperrors.Unauthorized("invalid_credentials", "The API key or the bearer token is invalid")
+)
+
+// Principal is the authenticated client of a request, Subject is the name of an API key or the sub claim of a JWT
+type Principal struct {
+	Subject string `json:"subject"`
+	Method  string `json:"method"`
+}
+
+type principalKey struct{}
+
+// Get a context which carries the principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// Get the principal of ctx, false is returned when the request has not been authenticated
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// Authenticator verifies API keys against the api_keys table and JWTs against the keys of the configuration
+type Authenticator struct {
+	apiKeys repository.APIKeyStore
+	// JWTs are rejected without keys
+	keys   *keySet
+	parser *jwt.Parser
+}
+
+// NewAuthenticator loads the JWT key file or the JWKS file of cfg
+func NewAuthenticator(apiKeys repository.APIKeyStore, cfg config.AuthConfig) (*Authenticator, error) {
+	var keys *keySet
+	var err error
+	switch {
+	case cfg.JWTKeyFile != "":
+		keys, err = loadKeyFile(cfg.JWTKeyFile)
+	case cfg.JWKSFile != "":
+		keys, err = loadJWKSFile(cfg.JWKSFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.JWTIssuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		options = append(options, jwt.WithAudience(cfg.JWTAudience))
+	}
+	return &Authenticator{apiKeys: apiKeys, keys: keys, parser: jwt.NewParser(options...)}, nil
+}
+
+// AcceptsJWT reports whether JWTs can be verified, only API keys are accepted without a key file or a JWKS file
+func (_self *Authenticator) AcceptsJWT() bool {
+	return _self.keys != nil
+}
+
+// Authenticate the API key or the bearer token of a request, the API key is used when both are sent
+func (_self *Authenticator) Authenticate(r *http.Request) (Principal, error) {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return _self.authenticateAPIKey(r.Context(), key)
+	}
+	authorization := r.Header.Get("Authorization")
+	if authorization == "" {
+		return Principal{}, ErrMissingCredentials
+	}
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return Principal{}, ErrInvalidCredentials
+	}
+	return _self.authenticateJWT(strings.TrimSpace(token))
+}
+
+// Look up the hash of the key, revoked keys are not found
+func (_self *Authenticator) authenticateAPIKey(ctx context.Context, key string) (Principal, error) {
+	apiKey, err := _self.apiKeys.GetAPIKeyByHash(ctx, HashAPIKey(key))
+	if errors.Is(err, repository.ErrNotExistedAPIKey) {
+		return Principal{}, ErrInvalidCredentials
+	}
+	if err != nil {
+		return Principal{}, err
+	}
+	return Principal{Subject: apiKey.Name, Method: MethodAPIKey}, nil
+}
+
+// Verify the signature, the algorithm and the exp, iss and aud claims of a token, the token must have a subject
+func (_self *Authenticator) authenticateJWT(tokenString string) (Principal, error) {
+	if _self.keys == nil {
+		return Principal{}, ErrInvalidCredentials
+	}
+	token, err := _self.parser.Parse(tokenString, _self.keys.keyfunc)
+	if err != nil || !token.Valid {
+		return Principal{}, ErrInvalidCredentials
+	}
+	subject, err := token.Claims.GetSubject()
+	if err != nil || subject == "" {
+		return Principal{}, ErrInvalidCredentials
+	}
+	return Principal{Subject: subject, Method: MethodJWT}, nil
+}
+
+// Get the hex SHA-256 hash of a key which is stored in the api_keys table,
+// keys are random so a fast hash is enough
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Generate a random API key with 256 bits of entropy
+func GenerateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
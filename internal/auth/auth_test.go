@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+var testRSAKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+
+// writeFile will be writing b to a file of a temporary directory, its path is returned
+func writeFile(t *testing.T, name string, b []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, ioutil.WriteFile(path, b, 0600))
+	return path
+}
+
+// signToken will be signing claims with the key by the algorithm of method, kid is set when it is not empty
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestAuth_Authenticate(t *testing.T) {
+	publicKey, err := x509.MarshalPKIXPublicKey(&testRSAKey.PublicKey)
+	require.NoError(t, err)
+	pemFile := writeFile(t, "jwt.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}))
+	secretFile := writeFile(t, "jwt.key", []byte(testSecret+"\n"))
+	jwks, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{
+			"kty": "RSA", "kid": "rsa-1", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(testRSAKey.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(testRSAKey.E)).Bytes()),
+		},
+		{"kty": "oct", "kid": "hmac-1", "k": base64.RawURLEncoding.EncodeToString([]byte(testSecret))},
+	}})
+	require.NoError(t, err)
+	jwksFile := writeFile(t, "jwks.json", jwks)
+
+	repo := repository.NewMemoryRepo()
+	_, err = repo.CreateAPIKey(context.Background(), "ci", HashAPIKey("fm_valid"))
+	require.NoError(t, err)
+	_, err = repo.CreateAPIKey(context.Background(), "old", HashAPIKey("fm_revoked"))
+	require.NoError(t, err)
+	require.NoError(t, repo.RevokeAPIKey(context.Background(), "old"))
+
+	exp := time.Now().Add(time.Hour).Unix()
+	claims := jwt.MapClaims{"sub": "john@example.com", "exp": exp}
+
+	tcs := map[string]struct {
+		cfg           config.AuthConfig
+		apiKey        string
+		authorization string
+		expPrincipal  Principal
+		expError      error
+	}{
+		"success with an API key": {
+			apiKey:       "fm_valid",
+			expPrincipal: Principal{Subject: "ci", Method: MethodAPIKey},
+		},
+		"success with an HS256 token of a key file": {
+			cfg:           config.AuthConfig{JWTKeyFile: secretFile},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims),
+			expPrincipal:  Principal{Subject: "john@example.com", Method: MethodJWT},
+		},
+		"success with an RS256 token of a PEM key file and the required issuer and audience": {
+			cfg:           config.AuthConfig{JWTKeyFile: pemFile, JWTIssuer: "https://auth.example.com", JWTAudience: "friend-management"},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodRS256, testRSAKey, "", jwt.MapClaims{"sub": "andy@example.com", "exp": exp, "iss": "https://auth.example.com", "aud": "friend-management"}),
+			expPrincipal:  Principal{Subject: "andy@example.com", Method: MethodJWT},
+		},
+		"success with an RS256 token of a JWKS file": {
+			cfg:           config.AuthConfig{JWKSFile: jwksFile},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodRS256, testRSAKey, "rsa-1", claims),
+			expPrincipal:  Principal{Subject: "john@example.com", Method: MethodJWT},
+		},
+		"success with an HS256 token of a JWKS file": {
+			cfg:           config.AuthConfig{JWKSFile: jwksFile},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "hmac-1", claims),
+			expPrincipal:  Principal{Subject: "john@example.com", Method: MethodJWT},
+		},
+		"failed without credentials": {
+			expError: ErrMissingCredentials,
+		},
+		"failed with an unknown API key": {
+			apiKey:   "fm_unknown",
+			expError: ErrInvalidCredentials,
+		},
+		"failed with a revoked API key": {
+			apiKey:   "fm_revoked",
+			expError: ErrInvalidCredentials,
+		},
+		"failed with a token without a key file": {
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims),
+			expError:      ErrInvalidCredentials,
+		},
+		"failed with an expired token": {
+			cfg:           config.AuthConfig{JWTKeyFile: secretFile},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{"sub": "john@example.com", "exp": time.Now().Add(-time.Minute).Unix()}),
+			expError:      ErrInvalidCredentials,
+		},
+		"failed with a token without exp": {
+			cfg:           config.AuthConfig{JWTKeyFile: secretFile},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{"sub": "john@example.com"}),
+			expError:      ErrInvalidCredentials,
+		},
+		"failed with a token without subject": {
+			cfg:           config.AuthConfig{JWTKeyFile: secretFile},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{"exp": exp}),
+			expError:      ErrInvalidCredentials,
+		},
+		"failed with a token of another secret": {
+			cfg:           config.AuthConfig{JWTKeyFile: secretFile},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte("another secret of at least 32 bytes"), "", claims),
+			expError:      ErrInvalidCredentials,
+		},
+		"failed with an HS256 token which is signed by the RSA public key": {
+			cfg:           config.AuthConfig{JWTKeyFile: pemFile},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}), "", claims),
+			expError:      ErrInvalidCredentials,
+		},
+		"failed with an unsigned token": {
+			cfg:           config.AuthConfig{JWTKeyFile: secretFile},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", claims),
+			expError:      ErrInvalidCredentials,
+		},
+		"failed with a token of another issuer": {
+			cfg:           config.AuthConfig{JWTKeyFile: secretFile, JWTIssuer: "https://auth.example.com"},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte(testSecret), "", jwt.MapClaims{"sub": "john@example.com", "exp": exp, "iss": "https://evil.example.com"}),
+			expError:      ErrInvalidCredentials,
+		},
+		"failed with a token of an unknown kid": {
+			cfg:           config.AuthConfig{JWKSFile: jwksFile},
+			authorization: "Bearer " + signToken(t, jwt.SigningMethodRS256, testRSAKey, "rsa-2", claims),
+			expError:      ErrInvalidCredentials,
+		},
+		"failed with another authorization scheme": {
+			cfg:           config.AuthConfig{JWTKeyFile: secretFile},
+			authorization: "Basic am9objpzZWNyZXQ=",
+			expError:      ErrInvalidCredentials,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			authenticator, err := NewAuthenticator(repo, tc.cfg)
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("GET", "/v1/users", nil)
+			require.NoError(t, err)
+			if tc.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tc.apiKey)
+			}
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+
+			principal, err := authenticator.Authenticate(req)
+			if tc.expError != nil {
+				require.Equal(t, tc.expError, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expPrincipal, principal)
+		})
+	}
+}
+
+func TestAuth_NewAuthenticator(t *testing.T) {
+	tcs := map[string]struct {
+		cfg      func(t *testing.T) config.AuthConfig
+		expError string
+	}{
+		"failed with a short HMAC secret": {
+			cfg: func(t *testing.T) config.AuthConfig {
+				return config.AuthConfig{JWTKeyFile: writeFile(t, "jwt.key", []byte("secret"))}
+			},
+			expError: "the HMAC secret must be at least 32 bytes",
+		},
+		"failed with an invalid PEM key": {
+			cfg: func(t *testing.T) config.AuthConfig {
+				return config.AuthConfig{JWTKeyFile: writeFile(t, "jwt.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("invalid")}))}
+			},
+			expError: "invalid JWT key file",
+		},
+		"failed with a JWKS file without signing keys": {
+			cfg: func(t *testing.T) config.AuthConfig {
+				return config.AuthConfig{JWKSFile: writeFile(t, "jwks.json", []byte(`{"keys":[{"kty":"RSA","kid":"enc","use":"enc"}]}`))}
+			},
+			expError: "no signing keys",
+		},
+		"failed with an unsupported key type": {
+			cfg: func(t *testing.T) config.AuthConfig {
+				return config.AuthConfig{JWKSFile: writeFile(t, "jwks.json", []byte(`{"keys":[{"kty":"EC","kid":"ec-1"}]}`))}
+			},
+			expError: `key "ec-1": unsupported key type: "EC"`,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			_, err := NewAuthenticator(repository.NewMemoryRepo(), tc.cfg(t))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expError)
+		})
+	}
+}
+
+func TestAuth_GenerateAPIKey(t *testing.T) {
+	key, err := GenerateAPIKey()
+	require.NoError(t, err)
+	require.Regexp(t, `^fm_[0-9a-f]{64}$`, key)
+
+	other, err := GenerateAPIKey()
+	require.NoError(t, err)
+	require.NotEqual(t, key, other)
+	require.Len(t, HashAPIKey(key), 64)
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keySet is the verification keys of JWTs, keys of a JWKS file are selected by the kid header of tokens
+type keySet struct {
+	// The key of a key file, it verifies tokens with any kid
+	key interface{}
+	// Keys of a JWKS file by their kid
+	keysByID map[string]interface{}
+}
+
+// Get the key of a token, its type has to match the algorithm so an RSA public key is never used as an HMAC secret
+func (_self *keySet) keyfunc(token *jwt.Token) (interface{}, error) {
+	key := _self.key
+	if _self.keysByID != nil {
+		kid, _ := token.Header["kid"].(string)
+		var ok bool
+		if key, ok = _self.keysByID[kid]; !ok {
+			return nil, fmt.Errorf("unknown kid: %q", kid)
+		}
+	}
+
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if _, ok := key.([]byte); ok {
+			return key, nil
+		}
+	case *jwt.SigningMethodRSA:
+		if _, ok := key.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("the key does not match the algorithm %s", token.Method.Alg())
+}
+
+// Load a PEM file of an RSA public key (RS256) or a file of an HMAC secret (HS256), whitespaces around the secret are trimmed
+func loadKeyFile(path string) (*keySet, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(b); block != nil {
+		key, err := jwt.ParseRSAPublicKeyFromPEM(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT key file %s: %w", path, err)
+		}
+		return &keySet{key: key}, nil
+	}
+
+	secret := bytes.TrimSpace(b)
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("invalid JWT key file %s: the HMAC secret must be at least 32 bytes", path)
+	}
+	return &keySet{key: secret}, nil
+}
+
+// jwk is a JSON Web Key of a JWKS file, only RSA and oct keys are supported
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// Modulus and exponent of RSA keys
+	N string `json:"n"`
+	E string `json:"e"`
+	// Secret of oct keys
+	K string `json:"k"`
+}
+
+// Load a JSON Web Key Set file, keys for encryption are skipped
+func loadJWKSFile(path string) (*keySet, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &jwks); err != nil {
+		return nil, fmt.Errorf("invalid JWKS file %s: %w", path, err)
+	}
+
+	keys := &keySet{keysByID: make(map[string]interface{}, len(jwks.Keys))}
+	for _, key := range jwks.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		parsed, err := key.parse()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS file %s: key %q: %w", path, key.Kid, err)
+		}
+		keys.keysByID[key.Kid] = parsed
+	}
+	if len(keys.keysByID) == 0 {
+		return nil, fmt.Errorf("invalid JWKS file %s: no signing keys", path)
+	}
+	return keys, nil
+}
+
+// Get the RSA public key or the HMAC secret of a JSON Web Key
+func (_self jwk) parse() (interface{}, error) {
+	switch _self.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(_self.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(_self.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 2 || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid RSA public key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(_self.K)
+		if err != nil || len(secret) == 0 {
+			return nil, errors.New("invalid secret")
+		}
+		return secret, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %q", _self.Kty)
+	}
+}
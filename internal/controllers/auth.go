@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/apperrors"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/auth"
+	"github.com/go-chi/httplog"
+)
+
+// Authenticate is a middleware which rejects requests without a valid API key or bearer token,
+// the principal of an authenticated request is in its context (auth.PrincipalFrom) and in its request log
+func Authenticate(authenticator *auth.Authenticator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := authenticator.Authenticate(r)
+			if err != nil {
+				if apperrors.StatusOf(err) == http.StatusUnauthorized {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="friend-management"`)
+				}
+				RespondError(w, r, err)
+				return
+			}
+
+			httplog.LogEntrySetField(r.Context(), "principal", principal.Method+":"+principal.Subject)
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
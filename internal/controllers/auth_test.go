@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/auth"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllers_Authenticate(t *testing.T) {
+	tcs := map[string]struct {
+		apiKey           string
+		mockAPIKey       *models.APIKey
+		mockErr          error
+		expStatus        int
+		expResult        string
+		expPrincipal     auth.Principal
+		expAuthenticated bool
+	}{
+		"success with an API key": {
+			apiKey:           "fm_valid",
+			mockAPIKey:       &models.APIKey{ID: 1, Name: "ci"},
+			expStatus:        http.StatusOK,
+			expPrincipal:     auth.Principal{Subject: "ci", Method: auth.MethodAPIKey},
+			expAuthenticated: true,
+		},
+		"failed without credentials": {
+			expStatus: http.StatusUnauthorized,
+			expResult: `{"code":"unauthenticated","message":"An API key or a bearer token is required","request_id":"test-id","success":false}`,
+		},
+		"failed with an unknown API key": {
+			apiKey:    "fm_unknown",
+			mockErr:   repository.ErrNotExistedAPIKey,
+			expStatus: http.StatusUnauthorized,
+			expResult: `{"code":"invalid_credentials","message":"The API key or the bearer token is invalid","request_id":"test-id","success":false}`,
+		},
+		"failed with an error of the repository": {
+			apiKey:    "fm_valid",
+			mockErr:   errors.New("connection refused"),
+			expStatus: http.StatusInternalServerError,
+			expResult: `{"code":"internal_error","message":"An internal error has occurred, report the request_id to support","request_id":"test-id","success":false}`,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			var mockAPIKeys APIKeyStore
+			mockAPIKeys.ExpectedCalls = []*mock.Call{
+				mockAPIKeys.On("GetAPIKeyByHash", mock.Anything, auth.HashAPIKey(tc.apiKey)).Return(tc.mockAPIKey, tc.mockErr),
+			}
+			authenticator, err := auth.NewAuthenticator(&mockAPIKeys, config.AuthConfig{})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("GET", "/v1/users", nil)
+			require.NoError(t, err)
+			req.Header.Set(RequestIDHeader, "test-id")
+			if tc.apiKey != "" {
+				req.Header.Set(auth.APIKeyHeader, tc.apiKey)
+			}
+			rr := httptest.NewRecorder()
+
+			var principal auth.Principal
+			var authenticated bool
+			handler := Authenticate(authenticator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				principal, authenticated = auth.PrincipalFrom(r.Context())
+			}))
+			RequestID(handler).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expStatus, rr.Code)
+			require.Equal(t, tc.expAuthenticated, authenticated)
+			require.Equal(t, tc.expPrincipal, principal)
+			if tc.expStatus == http.StatusUnauthorized {
+				require.Equal(t, `Bearer realm="friend-management"`, rr.Header().Get("WWW-Authenticate"))
+			}
+			if tc.expResult != "" {
+				require.JSONEq(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
@@ -1,18 +1,54 @@
 package controllers
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/apperrors"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+)
 
 var (
-	ErrBodyRequestInvalid    = errors.New("Body request invalid format")
-	ErrExistedFriendship     = errors.New("The friend relationship has been existed")
-	ErrExistedBlockedUser    = errors.New("The users have blocked each other")
-	ErrExistedSubscription   = errors.New("The users have subscribed each other")
-	ErrCreatedFriendship     = errors.New("Users cannot be created a new friendship")
-	ErrBodyRequestEmpty      = errors.New("Request body is empty")
-	ErrNumberOfEmail         = errors.New("Number of email addresses must be 2")
-	ErrDifferentEmail        = errors.New("Two email addresses must be different")
-	ErrRequestorFieldInvalid = errors.New("Requestor field invalid format")
-	ErrTargetFieldInvalid    = errors.New("Target field invalid format")
-	ErrSenderFieldInvalid    = errors.New("Sender field invalid format")
-	ErrTextFieldInvalid      = errors.New("Text field invalid format")
+	ErrBodyRequestInvalid        = apperrors.BadRequest("invalid_body", "Body request invalid format")
+	ErrExistedFriendship         = repository.ErrExistedFriendship
+	ErrExistedBlockedUser        = repository.ErrExistedBlockedUser
+	ErrExistedSubscription       = repository.ErrExistedSubscription
+	ErrCreatedFriendship         = apperrors.Internal("friendship_not_created", "Users cannot be created a new friendship")
+	ErrBodyRequestEmpty          = apperrors.Unprocessable("empty_body", "Request body is empty")
+	ErrNumberOfEmail             = apperrors.Unprocessable("invalid_number_of_emails", "Number of email addresses must be 2")
+	ErrDifferentEmail            = apperrors.Unprocessable("same_emails", "Two email addresses must be different")
+	ErrRequestorFieldInvalid     = apperrors.Unprocessable("invalid_requestor", "Requestor field invalid format")
+	ErrTargetFieldInvalid        = apperrors.Unprocessable("invalid_target", "Target field invalid format")
+	ErrSenderFieldInvalid        = apperrors.Unprocessable("invalid_sender", "Sender field invalid format")
+	ErrTextFieldInvalid          = apperrors.Unprocessable("invalid_text", "Text field invalid format")
+	ErrExistedFriendRequest      = repository.ErrExistedFriendRequest
+	ErrNotFriendRequestTarget    = apperrors.New(http.StatusForbidden, "not_friend_request_target", "Only the target of the friend request can reject it, its requestor can cancel it")
+	ErrNotFriendRequestRequestor = apperrors.New(http.StatusForbidden, "not_friend_request_requestor", "Only the requestor of the friend request can cancel it, its target can reject it")
+	ErrMaxDepthInvalid           = apperrors.Unprocessable("invalid_max_depth", "Max depth must be between 1 and %d, or 0 for the default depth of %d", MaxPathDepth, DefaultPathDepth)
+	ErrNotExistedFriendPath      = apperrors.NotFound("friend_path_not_found", "The users are not connected within the max depth")
+	ErrNameFieldInvalid          = apperrors.Unprocessable("invalid_name", "Name field must be between 1 and %d characters", MaxNameLength)
+	ErrEmailFieldInvalid         = apperrors.Unprocessable("invalid_email", "Email field must be between 1 and %d characters", MaxEmailLength)
+	ErrLimitInvalid              = apperrors.Unprocessable("invalid_limit", "Limit must be between 1 and %d", MaxPageLimit)
+	ErrCursorInvalid             = apperrors.Unprocessable("invalid_cursor", "Cursor is invalid")
+	ErrRequestTimeout            = apperrors.New(http.StatusGatewayTimeout, "request_timeout", "The request has not been completed in time")
+	ErrRequestCanceled           = apperrors.New(http.StatusServiceUnavailable, "request_canceled", "The request has been canceled")
+	ErrInternal                  = apperrors.Internal(apperrors.CodeInternal, "An internal error has occurred, report the request_id to support")
 )
+
+// Error of an email which is not matched with EmailRegex
+func errInvalidEmail(email string) error {
+	return apperrors.Unprocessable("invalid_email", "%s invalid format (ex: \"andy@example.com\")", email)
+}
+
+// Error of a user who has been existed
+func errExistedUser(email string) error {
+	return apperrors.Conflict("user_exists", "%s has been existed", email)
+}
+
+// Translate error of getting a user by email, other errors than a not existing user are kept
+func errNotExistedUser(email string, err error) error {
+	if errors.Is(err, repository.ErrNotExistedUser) {
+		return apperrors.NotFound(repository.ErrNotExistedUser.Code, "%s is not exists", email)
+	}
+	return err
+}
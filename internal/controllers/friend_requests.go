@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+)
+
+// Send a friend request from requestor to target
+func (_self FriendController) CreateFriendRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	requestorReq := RequestorRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&requestorReq); err != nil {
+		RespondError(w, r, ErrBodyRequestInvalid)
+		return
+	}
+	requestorReq.Requestor = _self.Emails.Normalize(requestorReq.Requestor)
+	requestorReq.Target = _self.Emails.Normalize(requestorReq.Target)
+
+	//Validate request
+	if err := requestorReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// Get requestor id and user target id from repository
+	requestorId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Requestor)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(requestorReq.Requestor, err))
+		return
+	}
+	targetId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Target)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(requestorReq.Target, err))
+		return
+	}
+
+	if err := _self.sendFriendRequest(ctx, requestorId, targetId); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	Respond(w, http.StatusOK, MsgOK())
+}
+
+// Get emails of users who have sent a pending friend request to user
+func (_self FriendController) GetIncomingFriendRequests(w http.ResponseWriter, r *http.Request) {
+	_self.getFriendRequests(w, r, _self.Repo.GetIncomingFriendRequests)
+}
+
+// Get emails of users who have received a pending friend request from user
+func (_self FriendController) GetOutgoingFriendRequests(w http.ResponseWriter, r *http.Request) {
+	_self.getFriendRequests(w, r, _self.Repo.GetOutgoingFriendRequests)
+}
+
+// Accept a pending friend request, the friendship is created by this action
+func (_self FriendController) AcceptFriendRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	requestorReq := RequestorRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&requestorReq); err != nil {
+		RespondError(w, r, ErrBodyRequestInvalid)
+		return
+	}
+	requestorReq.Requestor = _self.Emails.Normalize(requestorReq.Requestor)
+	requestorReq.Target = _self.Emails.Normalize(requestorReq.Target)
+
+	//Validate request
+	if err := requestorReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// Get requestor id and user target id from repository
+	requestorId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Requestor)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(requestorReq.Requestor, err))
+		return
+	}
+	targetId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Target)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(requestorReq.Target, err))
+		return
+	}
+
+	// Check and accept friend request in a transaction while both users are locked
+	err = _self.Repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+		if err := repo.LockUsers(ctx, requestorId, targetId); err != nil {
+			return err
+		}
+
+		friendRequest, err := repo.GetPendingFriendRequest(ctx, requestorId, targetId)
+		if err != nil {
+			return err
+		}
+
+		// check blocking which has been created after the request was sent
+		isBlocked, err := repo.IsBlockedUser(ctx, requestorId, targetId)
+		if err != nil {
+			return err
+		}
+		if isBlocked {
+			return ErrExistedBlockedUser
+		}
+
+		//Call services
+		return repo.AcceptFriendRequest(ctx, friendRequest.ID)
+	})
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	Respond(w, http.StatusOK, MsgOK())
+}
+
+// Reject a pending friend request by its target, target is the acting user and requestor is the sender of the request
+func (_self FriendController) RejectFriendRequest(w http.ResponseWriter, r *http.Request) {
+	_self.closeFriendRequest(w, r, repository.FriendRequestRejected, ErrNotFriendRequestTarget)
+}
+
+// Cancel a pending friend request by its requestor, requestor is the acting user and target is the receiver of the request
+func (_self FriendController) CancelFriendRequest(w http.ResponseWriter, r *http.Request) {
+	_self.closeFriendRequest(w, r, repository.FriendRequestCancelled, ErrNotFriendRequestRequestor)
+}
+
+// Get pending friend requests of a user by the given repository method and respond the emails of other side
+func (_self FriendController) getFriendRequests(w http.ResponseWriter, r *http.Request, getRequests func(context.Context, int) (models.FriendRequestSlice, error)) {
+	ctx := r.Context()
+	userReq, err := _self.getUserRequest(r)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// Validation request body
+	if err := userReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// Get user id from an email
+	userId, err := _self.Repo.GetUserIDByEmail(ctx, userReq.Email)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(userReq.Email, err))
+		return
+	}
+
+	friendRequests, err := getRequests(ctx, userId)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+	userIds := make([]int, 0)
+	for _, friendRequest := range friendRequests {
+		if friendRequest.RequestorID == userId {
+			userIds = append(userIds, friendRequest.TargetID)
+		}
+		if friendRequest.TargetID == userId {
+			userIds = append(userIds, friendRequest.RequestorID)
+		}
+	}
+
+	emails, err := _self.Repo.GetEmailsByUserIDs(ctx, userIds)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	Respond(w, http.StatusOK, MsgGetFriendRequestsOk(emails, len(emails)))
+}
+
+// Close a pending friend request from requestor to target with the given status,
+// errWrongActor is responded when the pending request has been sent from target to requestor
+func (_self FriendController) closeFriendRequest(w http.ResponseWriter, r *http.Request, status string, errWrongActor error) {
+	ctx := r.Context()
+	requestorReq := RequestorRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&requestorReq); err != nil {
+		RespondError(w, r, ErrBodyRequestInvalid)
+		return
+	}
+	requestorReq.Requestor = _self.Emails.Normalize(requestorReq.Requestor)
+	requestorReq.Target = _self.Emails.Normalize(requestorReq.Target)
+
+	//Validate request
+	if err := requestorReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// Get requestor id and user target id from repository
+	requestorId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Requestor)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(requestorReq.Requestor, err))
+		return
+	}
+	targetId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Target)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(requestorReq.Target, err))
+		return
+	}
+
+	friendRequest, err := _self.Repo.GetPendingFriendRequest(ctx, requestorId, targetId)
+	if errors.Is(err, repository.ErrNotExistedFriendRequest) {
+		// the acting user is on the other side of a request which has been sent the other way
+		if _, reverseErr := _self.Repo.GetPendingFriendRequest(ctx, targetId, requestorId); reverseErr == nil {
+			err = errWrongActor
+		}
+	}
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	//Call services
+	if err := _self.Repo.UpdateFriendRequestStatus(ctx, friendRequest.ID, status); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	Respond(w, http.StatusOK, MsgOK())
+}
+
+// Check and create a friend request in a transaction while both users are locked,
+// users who are friends, have blocked each other or have a pending request cannot send a request
+func (_self FriendController) sendFriendRequest(ctx context.Context, requestorId int, targetId int) error {
+	return _self.Repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+		if err := repo.LockUsers(ctx, requestorId, targetId); err != nil {
+			return err
+		}
+
+		// Check friend relationship is exists
+		isExisted, err := repo.IsExistedFriend(ctx, requestorId, targetId)
+		if err != nil {
+			return err
+		}
+		if isExisted {
+			return ErrExistedFriendship
+		}
+
+		// check blocking between 2 user
+		isBlocked, err := repo.IsBlockedUser(ctx, requestorId, targetId)
+		if err != nil {
+			return err
+		}
+		if isBlocked {
+			return ErrExistedBlockedUser
+		}
+
+		// Check a pending friend request between 2 users
+		isPending, err := repo.IsPendingFriendRequest(ctx, requestorId, targetId)
+		if err != nil {
+			return err
+		}
+		if isPending {
+			return ErrExistedFriendRequest
+		}
+
+		//Call services
+		return repo.CreateFriendRequest(ctx, requestorId, targetId)
+	})
+}
@@ -0,0 +1,299 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllers_CreateFriendRequest(t *testing.T) {
+	tcs := map[string]struct {
+		input             string
+		expResult         string
+		expError          error
+		mockRequestorUser models.User
+		mockTargetUser    models.User
+		mockIsBlocked     bool
+		mockIsPending     bool
+	}{
+		"success with an input": {
+			input:             `{"requestor": "andy@example.com","target": "lisa@example.com"}`,
+			mockRequestorUser: models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
+			mockTargetUser:    models.User{ID: 103, Name: "Lisa", Email: "lisa@example.com"},
+			expResult:         `{"success":true}`,
+		},
+		"failed with a blocking relationship": {
+			input:             `{"requestor": "andy@example.com","target": "lisa@example.com"}`,
+			mockRequestorUser: models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
+			mockTargetUser:    models.User{ID: 103, Name: "Lisa", Email: "lisa@example.com"},
+			mockIsBlocked:     true,
+			expError:          errors.New(`{"code":"blocking_exists","message":"The users have blocked each other","success":false}`),
+		},
+		"failed with an existing pending request": {
+			input:             `{"requestor": "andy@example.com","target": "lisa@example.com"}`,
+			mockRequestorUser: models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
+			mockTargetUser:    models.User{ID: 103, Name: "Lisa", Email: "lisa@example.com"},
+			mockIsPending:     true,
+			expError:          errors.New(`{"code":"friend_request_exists","message":"The friend request has been existed","success":false}`),
+		},
+		"failed with an unknow format input": {
+			input:    `{}`,
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/v1/friendRequests", bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("LockUsers", mock.Anything, mock.Anything).Return(nil),
+				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockRequestorUser.ID, nil),
+				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockTargetUser.ID, nil),
+				mockRepo.On("IsExistedFriend", mock.Anything, mock.Anything, mock.Anything).Return(false, nil),
+				mockRepo.On("IsBlockedUser", mock.Anything, mock.Anything, mock.Anything).Return(tc.mockIsBlocked, nil),
+				mockRepo.On("IsPendingFriendRequest", mock.Anything, mock.Anything, mock.Anything).Return(tc.mockIsPending, nil),
+				mockRepo.On("CreateFriendRequest", mock.Anything, mock.Anything, mock.Anything).Return(nil),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			handler := http.HandlerFunc(friendController.CreateFriendRequest)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestControllers_GetFriendRequests(t *testing.T) {
+	tcs := map[string]struct {
+		url                string
+		input              string
+		expResult          string
+		expError           error
+		mockMethod         string
+		mockFriendRequests models.FriendRequestSlice
+	}{
+		"success with an input": {
+			url:        "/v1/friendRequests/incoming",
+			input:      `{"email":"kate@example.com"}`,
+			mockMethod: "GetIncomingFriendRequests",
+			mockFriendRequests: models.FriendRequestSlice{
+				&models.FriendRequest{ID: 200, RequestorID: 103, TargetID: 104, Status: repository.FriendRequestPending},
+			},
+			expResult: `{"count":1,"requests":["lisa@example.com"],"success":true}`,
+		},
+		"success with an email in query": {
+			url:        "/v1/friendRequests/incoming?email=Kate@Example.com",
+			mockMethod: "GetIncomingFriendRequests",
+			mockFriendRequests: models.FriendRequestSlice{
+				&models.FriendRequest{ID: 200, RequestorID: 103, TargetID: 104, Status: repository.FriendRequestPending},
+			},
+			expResult: `{"count":1,"requests":["lisa@example.com"],"success":true}`,
+		},
+		"success with an email in path": {
+			url:        "/v1/users/kate@example.com/friendRequests/outgoing",
+			mockMethod: "GetOutgoingFriendRequests",
+			mockFriendRequests: models.FriendRequestSlice{
+				&models.FriendRequest{ID: 201, RequestorID: 104, TargetID: 103, Status: repository.FriendRequestPending},
+			},
+			expResult: `{"count":1,"requests":["lisa@example.com"],"success":true}`,
+		},
+		"failed without an email in query or body": {
+			url:      "/v1/friendRequests/outgoing",
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+		"failed with an invalid email in path": {
+			url:      "/v1/users/kate/friendRequests/incoming",
+			expError: errors.New(`{"code":"invalid_email","message":"kate invalid format (ex: \"andy@example.com\")","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tc.url, bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("GetUserIDByEmail", "kate@example.com").Return(104, nil),
+				mockRepo.On(tc.mockMethod, mock.Anything, 104).Return(tc.mockFriendRequests, nil),
+				mockRepo.On("GetEmailsByUserIDs", []int{103}).Return([]string{"lisa@example.com"}, nil),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			router := chi.NewRouter()
+			router.Get("/v1/friendRequests/incoming", friendController.GetIncomingFriendRequests)
+			router.Get("/v1/friendRequests/outgoing", friendController.GetOutgoingFriendRequests)
+			router.Get("/v1/users/{email}/friendRequests/incoming", friendController.GetIncomingFriendRequests)
+			router.Get("/v1/users/{email}/friendRequests/outgoing", friendController.GetOutgoingFriendRequests)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestControllers_AcceptFriendRequest(t *testing.T) {
+	tcs := map[string]struct {
+		input             string
+		expResult         string
+		expError          error
+		mockRequestorUser models.User
+		mockTargetUser    models.User
+		mockFriendRequest *models.FriendRequest
+		mockGetErr        error
+		mockIsBlocked     bool
+	}{
+		"success with an input": {
+			input:             `{"requestor": "lisa@example.com","target": "kate@example.com"}`,
+			mockRequestorUser: models.User{ID: 103, Name: "Lisa", Email: "lisa@example.com"},
+			mockTargetUser:    models.User{ID: 104, Name: "Kate", Email: "kate@example.com"},
+			mockFriendRequest: &models.FriendRequest{ID: 200, RequestorID: 103, TargetID: 104, Status: repository.FriendRequestPending},
+			expResult:         `{"success":true}`,
+		},
+		"failed with a non-existing pending request": {
+			input:             `{"requestor": "lisa@example.com","target": "kate@example.com"}`,
+			mockRequestorUser: models.User{ID: 103, Name: "Lisa", Email: "lisa@example.com"},
+			mockTargetUser:    models.User{ID: 104, Name: "Kate", Email: "kate@example.com"},
+			mockGetErr:        repository.ErrNotExistedFriendRequest,
+			expError:          errors.New(`{"code":"friend_request_not_found","message":"The pending friend request does not exist","success":false}`),
+		},
+		"failed with a blocking relationship": {
+			input:             `{"requestor": "lisa@example.com","target": "kate@example.com"}`,
+			mockRequestorUser: models.User{ID: 103, Name: "Lisa", Email: "lisa@example.com"},
+			mockTargetUser:    models.User{ID: 104, Name: "Kate", Email: "kate@example.com"},
+			mockFriendRequest: &models.FriendRequest{ID: 200, RequestorID: 103, TargetID: 104, Status: repository.FriendRequestPending},
+			mockIsBlocked:     true,
+			expError:          errors.New(`{"code":"blocking_exists","message":"The users have blocked each other","success":false}`),
+		},
+		"failed with an unknow format input": {
+			input:    `{}`,
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/v1/friendRequests/accept", bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("LockUsers", mock.Anything, mock.Anything).Return(nil),
+				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockRequestorUser.ID, nil),
+				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockTargetUser.ID, nil),
+				mockRepo.On("GetPendingFriendRequest", mock.Anything, mock.Anything, mock.Anything).Return(tc.mockFriendRequest, tc.mockGetErr),
+				mockRepo.On("IsBlockedUser", mock.Anything, mock.Anything, mock.Anything).Return(tc.mockIsBlocked, nil),
+				mockRepo.On("AcceptFriendRequest", mock.Anything, mock.Anything).Return(nil),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			handler := http.HandlerFunc(friendController.AcceptFriendRequest)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestControllers_RejectAndCancelFriendRequest(t *testing.T) {
+	pendingRequest := &models.FriendRequest{ID: 200, RequestorID: 103, TargetID: 104, Status: repository.FriendRequestPending}
+	tcs := map[string]struct {
+		url       string
+		input     string
+		expStatus string
+		expResult string
+		expError  error
+	}{
+		"success with rejecting by the target": {
+			url:       "/v1/friendRequests/reject",
+			input:     `{"target": "kate@example.com","requestor": "lisa@example.com"}`,
+			expStatus: repository.FriendRequestRejected,
+			expResult: `{"success":true}`,
+		},
+		"success with cancelling by the requestor": {
+			url:       "/v1/friendRequests/cancel",
+			input:     `{"requestor": "lisa@example.com","target": "kate@example.com"}`,
+			expStatus: repository.FriendRequestCancelled,
+			expResult: `{"success":true}`,
+		},
+		"failed with rejecting by the requestor": {
+			url:      "/v1/friendRequests/reject",
+			input:    `{"target": "lisa@example.com","requestor": "kate@example.com"}`,
+			expError: errors.New(`{"code":"not_friend_request_target","message":"Only the target of the friend request can reject it, its requestor can cancel it","success":false}`),
+		},
+		"failed with cancelling by the target": {
+			url:      "/v1/friendRequests/cancel",
+			input:    `{"requestor": "kate@example.com","target": "lisa@example.com"}`,
+			expError: errors.New(`{"code":"not_friend_request_requestor","message":"Only the requestor of the friend request can cancel it, its target can reject it","success":false}`),
+		},
+		"failed with a non-existing pending request": {
+			url:      "/v1/friendRequests/reject",
+			input:    `{"target": "kate@example.com","requestor": "john@example.com"}`,
+			expError: errors.New(`{"code":"friend_request_not_found","message":"The pending friend request does not exist","success":false}`),
+		},
+		"failed with an unknow format input": {
+			url:      "/v1/friendRequests/reject",
+			input:    `{}`,
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("POST", tc.url, bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+
+			// only the request from lisa to kate is pending
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("GetUserIDByEmail", "john@example.com").Return(100, nil),
+				mockRepo.On("GetUserIDByEmail", "lisa@example.com").Return(103, nil),
+				mockRepo.On("GetUserIDByEmail", "kate@example.com").Return(104, nil),
+				mockRepo.On("GetPendingFriendRequest", mock.Anything, 103, 104).Return(pendingRequest, nil),
+				mockRepo.On("GetPendingFriendRequest", mock.Anything, mock.Anything, mock.Anything).Return((*models.FriendRequest)(nil), repository.ErrNotExistedFriendRequest),
+				mockRepo.On("UpdateFriendRequestStatus", mock.Anything, 200, tc.expStatus).Return(nil),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			router := chi.NewRouter()
+			router.Post("/v1/friendRequests/reject", friendController.RejectFriendRequest)
+			router.Post("/v1/friendRequests/cancel", friendController.CancelFriendRequest)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+				mockRepo.AssertNotCalled(t, "UpdateFriendRequestStatus", mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
@@ -8,53 +8,106 @@ import (
 	"testing"
 
 	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/go-chi/chi"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
 func TestControllers_GetFriends(t *testing.T) {
 	tcs := map[string]struct {
-		input              string
-		expResult          string
-		expError           error
-		mockUser           models.User
-		mockFriendSlice    models.FriendSlice
-		mockUserBlockSlice models.UserBlockSlice
+		input          string
+		url            string
+		expResult      string
+		expError       error
+		mockUser       models.User
+		mockAfterId    int
+		mockLimit      int
+		mockFriendPage repository.FriendPage
 	}{
 		"success with an input": {
+			input:          `{"Email":"andy@example.com"}`,
+			mockUser:       models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockLimit:      DefaultPageLimit,
+			mockFriendPage: repository.FriendPage{Emails: []string{"andy@example.com"}, Total: 1},
+			expResult:      `{"count":1,"friends":["andy@example.com"],"next_cursor":null,"success":true}`,
+		},
+		"success with a next page": {
+			input:          `{"Email":"andy@example.com"}`,
+			url:            "/v1/friends?limit=1",
+			mockUser:       models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockLimit:      1,
+			mockFriendPage: repository.FriendPage{Emails: []string{"andy@example.com"}, Total: 2, NextAfterID: 101},
+			expResult:      `{"count":2,"friends":["andy@example.com"],"next_cursor":"MTAx","success":true}`,
+		},
+		"success with the last page": {
+			url:            "/v1/friends?email=andy@example.com&limit=1&after=MTAx",
+			mockUser:       models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockAfterId:    101,
+			mockLimit:      1,
+			mockFriendPage: repository.FriendPage{Emails: []string{"common@example.com"}, Total: 2},
+			expResult:      `{"count":2,"friends":["common@example.com"],"next_cursor":null,"success":true}`,
+		},
+		"failed with an invalid limit": {
 			input:    `{"Email":"andy@example.com"}`,
-			mockUser: models.User{ID: 100, Name: "John", Email: "john@example.com"},
-			mockFriendSlice: models.FriendSlice{
-				&models.Friend{UserID: 100, FriendID: 101},
-				&models.Friend{UserID: 100, FriendID: 102},
-			},
-			mockUserBlockSlice: models.UserBlockSlice{
-				&models.UserBlock{RequestorID: 100, TargetID: 102},
-			},
-			expResult: `{"count":1,"friends":["andy@example.com"],"success":true}`,
+			url:      "/v1/friends?limit=0",
+			expError: errors.New(`{"code":"invalid_limit","message":"Limit must be between 1 and 500","success":false}`),
+		},
+		"success with an email in path": {
+			url:            "/v1/users/Andy@Example.com/friends",
+			mockUser:       models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockLimit:      DefaultPageLimit,
+			mockFriendPage: repository.FriendPage{Emails: []string{"andy@example.com"}, Total: 1},
+			expResult:      `{"count":1,"friends":["andy@example.com"],"next_cursor":null,"success":true}`,
+		},
+		"success with an email in query": {
+			url:            "/v1/friends?email=andy@example.com",
+			mockUser:       models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockLimit:      DefaultPageLimit,
+			mockFriendPage: repository.FriendPage{Emails: []string{"andy@example.com"}, Total: 1},
+			expResult:      `{"count":1,"friends":["andy@example.com"],"next_cursor":null,"success":true}`,
+		},
+		"success without friends": {
+			url:            "/v1/friends?email=andy@example.com",
+			mockUser:       models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockLimit:      DefaultPageLimit,
+			mockFriendPage: repository.FriendPage{Emails: []string{}},
+			expResult:      `{"count":0,"friends":[],"next_cursor":null,"success":true}`,
 		},
 		"failed with an unknow format input": {
 			input:    `{}`,
-			expError: errors.New(`{"message":"Request body is empty","success":false}`),
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+		"failed without an email in query or body": {
+			url:      "/v1/friends",
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+		"failed with an invalid email in path": {
+			url:      "/v1/users/andy/friends",
+			expError: errors.New(`{"code":"invalid_email","message":"andy invalid format (ex: \"andy@example.com\")","success":false}`),
 		},
 	}
 
 	for desc, tc := range tcs {
 		t.Run(desc, func(t *testing.T) {
-			req, err := http.NewRequest("GET", "/v1/friends", bytes.NewBuffer([]byte(tc.input)))
+			url := tc.url
+			if url == "" {
+				url = "/v1/friends"
+			}
+			req, err := http.NewRequest("GET", url, bytes.NewBuffer([]byte(tc.input)))
 			require.NoError(t, err)
 
 			var mockRepo SpecRepo
 			mockRepo.ExpectedCalls = []*mock.Call{
-				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockUser.ID, nil),
-				mockRepo.On("GetFriendsByID", mock.Anything, mock.Anything).Return(tc.mockFriendSlice, nil),
-				mockRepo.On("GetUserBlocksByID", mock.Anything, mock.Anything).Return(tc.mockUserBlockSlice, nil),
-				mockRepo.On("GetEmailsByUserIDs", mock.Anything, mock.Anything).Return([]string{"andy@example.com"}, nil),
+				mockRepo.On("GetUserIDByEmail", "andy@example.com").Return(tc.mockUser.ID, nil),
+				mockRepo.On("GetUnblockedFriendEmails", tc.mockUser.ID, tc.mockAfterId, tc.mockLimit).Return(tc.mockFriendPage, nil),
 			}
-			friendController := NewFriendController(mockRepo)
-			handler := http.HandlerFunc(friendController.GetFriends)
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			router := chi.NewRouter()
+			router.Get("/v1/friends", friendController.GetFriends)
+			router.Get("/v1/users/{email}/friends", friendController.GetFriends)
 			rr := httptest.NewRecorder()
-			handler.ServeHTTP(rr, req)
+			router.ServeHTTP(rr, req)
 
 			if tc.expError != nil {
 				require.EqualError(t, tc.expError, rr.Body.String())
@@ -73,6 +126,80 @@ func TestControllers_CreateFriends(t *testing.T) {
 		expError       error
 		mockFirstUser  models.User
 		mockSecondUser models.User
+		mockIsExisted  bool
+		mockIsPending  bool
+		mockCreateErr  error
+	}{
+		"success with sending a friend request": {
+			input:          `{ "friends": ["andy@example.com","john@example.com"]}`,
+			mockFirstUser:  models.User{ID: 100, Name: "Andy", Email: "andy@example.com"},
+			mockSecondUser: models.User{ID: 101, Name: "John", Email: "john@example.com"},
+			expResult:      `{"success":true}`,
+		},
+		"failed with users who are already friends": {
+			input:          `{ "friends": ["andy@example.com","john@example.com"]}`,
+			mockFirstUser:  models.User{ID: 100, Name: "Andy", Email: "andy@example.com"},
+			mockSecondUser: models.User{ID: 101, Name: "John", Email: "john@example.com"},
+			mockIsExisted:  true,
+			expError:       errors.New(`{"code":"friendship_exists","message":"The friend relationship has been existed","success":false}`),
+		},
+		"failed with a pending friend request": {
+			input:          `{ "friends": ["andy@example.com","john@example.com"]}`,
+			mockFirstUser:  models.User{ID: 100, Name: "Andy", Email: "andy@example.com"},
+			mockSecondUser: models.User{ID: 101, Name: "John", Email: "john@example.com"},
+			mockIsPending:  true,
+			expError:       errors.New(`{"code":"friend_request_exists","message":"The friend request has been existed","success":false}`),
+		},
+		"failed with the same email in different cases": {
+			input:    `{ "friends": ["andy@example.com"," Andy@Example.COM"]}`,
+			expError: errors.New(`{"code":"same_emails","message":"Two email addresses must be different","success":false}`),
+		},
+		"failed with an unknow format input": {
+			input:    `{}`,
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/v1/friends", bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("LockUsers", mock.Anything, mock.Anything).Return(nil),
+				mockRepo.On("GetUserIDByEmail", "andy@example.com").Return(tc.mockFirstUser.ID, nil),
+				mockRepo.On("GetUserIDByEmail", "john@example.com").Return(tc.mockSecondUser.ID, nil),
+				mockRepo.On("IsExistedFriend", mock.Anything, mock.Anything, mock.Anything).Return(tc.mockIsExisted, nil),
+				mockRepo.On("IsBlockedUser", mock.Anything, mock.Anything, mock.Anything).Return(false, nil),
+				mockRepo.On("IsPendingFriendRequest", mock.Anything, mock.Anything, mock.Anything).Return(tc.mockIsPending, nil),
+				mockRepo.On("CreateFriendRequest", mock.Anything, tc.mockFirstUser.ID, tc.mockSecondUser.ID).Return(tc.mockCreateErr),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			handler := http.HandlerFunc(friendController.CreateFriend)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			// the friendship is only created when the request is accepted
+			mockRepo.AssertNotCalled(t, "CreateFriend", mock.Anything, mock.Anything, mock.Anything)
+
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestControllers_DeleteFriend(t *testing.T) {
+	tcs := map[string]struct {
+		input          string
+		expResult      string
+		expError       error
+		mockFirstUser  models.User
+		mockSecondUser models.User
+		mockDeleteErr  error
 	}{
 		"success with an input": {
 			input:          `{ "friends": ["andy@example.com","john@example.com"]}`,
@@ -80,27 +207,32 @@ func TestControllers_CreateFriends(t *testing.T) {
 			mockSecondUser: models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
 			expResult:      `{"success":true}`,
 		},
+		"failed with a non-existing friendship": {
+			input:          `{ "friends": ["andy@example.com","john@example.com"]}`,
+			mockFirstUser:  models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockSecondUser: models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
+			mockDeleteErr:  repository.ErrNotExistedFriendship,
+			expError:       errors.New(`{"code":"friendship_not_found","message":"The friend relationship does not exist","success":false}`),
+		},
 		"failed with an unknow format input": {
 			input:    `{}`,
-			expError: errors.New(`{"message":"Request body is empty","success":false}`),
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
 		},
 	}
 
 	for desc, tc := range tcs {
 		t.Run(desc, func(t *testing.T) {
-			req, err := http.NewRequest("POST", "/v1/friends", bytes.NewBuffer([]byte(tc.input)))
+			req, err := http.NewRequest("DELETE", "/v1/friends", bytes.NewBuffer([]byte(tc.input)))
 			require.NoError(t, err)
 
 			var mockRepo SpecRepo
 			mockRepo.ExpectedCalls = []*mock.Call{
 				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockFirstUser.ID, nil),
 				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockSecondUser.ID, nil),
-				mockRepo.On("IsExistedFriend", mock.Anything, mock.Anything, mock.Anything).Return(false, nil),
-				mockRepo.On("IsBlockedUser", mock.Anything, mock.Anything, mock.Anything).Return(false, nil),
-				mockRepo.On("CreateFriend", mock.Anything, mock.Anything, mock.Anything).Return(nil),
+				mockRepo.On("DeleteFriend", mock.Anything, mock.Anything, mock.Anything).Return(tc.mockDeleteErr),
 			}
-			friendController := NewFriendController(mockRepo)
-			handler := http.HandlerFunc(friendController.CreateFriend)
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			handler := http.HandlerFunc(friendController.DeleteFriend)
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
 
@@ -116,62 +248,229 @@ func TestControllers_CreateFriends(t *testing.T) {
 
 func TestControllers_GetCommonFriends(t *testing.T) {
 	tcs := map[string]struct {
-		input                    string
-		expResult                string
-		expError                 error
-		mockFirstUser            models.User
-		mockFirstFriendSlice     models.FriendSlice
-		mockFirstUserBlockSlice  models.UserBlockSlice
-		mockSecondUser           models.User
-		mockSecondFriendSlice    models.FriendSlice
-		mockSecondUserBlockSlice models.UserBlockSlice
+		input          string
+		url            string
+		expResult      string
+		expError       error
+		mockFirstUser  models.User
+		mockSecondUser models.User
+		mockAfterId    int
+		mockLimit      int
+		mockFriendPage repository.FriendPage
 	}{
 		"success with an input": {
-			input:         `{ "friends": ["andy@example.com","john@example.com"]}`,
-			mockFirstUser: models.User{ID: 100, Name: "John", Email: "john@example.com"},
-			mockFirstFriendSlice: models.FriendSlice{
-				&models.Friend{UserID: 100, FriendID: 101},
-				&models.Friend{UserID: 100, FriendID: 102},
-			},
-			mockFirstUserBlockSlice: models.UserBlockSlice{
-				&models.UserBlock{RequestorID: 100, TargetID: 101},
-			},
-			mockSecondUser: models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
-			mockSecondFriendSlice: models.FriendSlice{
-				&models.Friend{UserID: 101, FriendID: 103},
-				&models.Friend{UserID: 101, FriendID: 102},
-			},
-			mockSecondUserBlockSlice: models.UserBlockSlice{
-				&models.UserBlock{RequestorID: 100, TargetID: 103},
-			},
+			input:          `{ "friends": ["andy@example.com","john@example.com"]}`,
+			mockFirstUser:  models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
+			mockSecondUser: models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockLimit:      DefaultPageLimit,
+			mockFriendPage: repository.FriendPage{Emails: []string{"common@example.com"}, Total: 1},
+			expResult:      `{"count":1,"friends":["common@example.com"],"next_cursor":null,"success":true}`,
+		},
+		"success with emails in query": {
+			url:            "/v1/friends/common?email=andy@example.com&email=john@example.com",
+			mockFirstUser:  models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
+			mockSecondUser: models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockLimit:      DefaultPageLimit,
+			mockFriendPage: repository.FriendPage{Emails: []string{"common@example.com"}, Total: 1},
+			expResult:      `{"count":1,"friends":["common@example.com"],"next_cursor":null,"success":true}`,
+		},
+		"success with a next page": {
+			url:            "/v1/friends/common?email=andy@example.com&email=john@example.com&limit=1",
+			mockFirstUser:  models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
+			mockSecondUser: models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockLimit:      1,
+			mockFriendPage: repository.FriendPage{Emails: []string{"common@example.com"}, Total: 2, NextAfterID: 102},
+			expResult:      `{"count":2,"friends":["common@example.com"],"next_cursor":"MTAy","success":true}`,
+		},
+		"failed with an unknow format input": {
+			input:    `{}`,
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+		"failed with one email in query": {
+			url:      "/v1/friends/common?email=andy@example.com",
+			expError: errors.New(`{"code":"invalid_number_of_emails","message":"Number of email addresses must be 2","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			url := tc.url
+			if url == "" {
+				url = "/v1/commonFriends"
+			}
+			req, err := http.NewRequest("GET", url, bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("GetUserIDByEmail", "andy@example.com").Return(tc.mockFirstUser.ID, nil),
+				mockRepo.On("GetUserIDByEmail", "john@example.com").Return(tc.mockSecondUser.ID, nil),
+				mockRepo.On("GetCommonFriendEmails", tc.mockFirstUser.ID, tc.mockSecondUser.ID, tc.mockAfterId, tc.mockLimit).Return(tc.mockFriendPage, nil),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			handler := http.HandlerFunc(friendController.GetCommonFriends)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
 
-			expResult: `{"count":1,"friends":["common@example.com"],"success":true}`,
+func TestControllers_GetFriendSuggestions(t *testing.T) {
+	tcs := map[string]struct {
+		url             string
+		input           string
+		expResult       string
+		expError        error
+		mockUser        models.User
+		mockSuggestions []repository.FriendSuggestion
+	}{
+		"success with an input": {
+			input:    `{"email":"john@example.com"}`,
+			mockUser: models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockSuggestions: []repository.FriendSuggestion{
+				{Email: "andy@example.com", MutualFriends: 2},
+				{Email: "lisa@example.com", MutualFriends: 1},
+			},
+			expResult: `{"count":2,"success":true,"suggestions":[{"email":"andy@example.com","mutual_friends":2},{"email":"lisa@example.com","mutual_friends":1}]}`,
+		},
+		"success with an email in query": {
+			url:             "/v1/suggestions?email=John@Example.com",
+			mockUser:        models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockSuggestions: []repository.FriendSuggestion{},
+			expResult:       `{"count":0,"success":true,"suggestions":[]}`,
+		},
+		"success with an email in path": {
+			url:             "/v1/users/john@example.com/suggestions",
+			mockUser:        models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockSuggestions: []repository.FriendSuggestion{{Email: "andy@example.com", MutualFriends: 1}},
+			expResult:       `{"count":1,"success":true,"suggestions":[{"email":"andy@example.com","mutual_friends":1}]}`,
+		},
+		"failed without an email in query or body": {
+			url:      "/v1/suggestions",
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
 		},
 		"failed with an unknow format input": {
 			input:    `{}`,
-			expError: errors.New(`{"message":"Request body is empty","success":false}`),
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
 		},
 	}
 
 	for desc, tc := range tcs {
 		t.Run(desc, func(t *testing.T) {
-			req, err := http.NewRequest("GET", "/v1/commonFriends", bytes.NewBuffer([]byte(tc.input)))
+			url := tc.url
+			if url == "" {
+				url = "/v1/suggestions"
+			}
+			req, err := http.NewRequest("GET", url, bytes.NewBuffer([]byte(tc.input)))
 			require.NoError(t, err)
 
 			var mockRepo SpecRepo
 			mockRepo.ExpectedCalls = []*mock.Call{
-				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockFirstUser.ID, nil),
-				mockRepo.On("GetFriendsByID", mock.Anything, mock.Anything).Return(tc.mockFirstFriendSlice, nil),
-				mockRepo.On("GetUserBlocksByID", mock.Anything, mock.Anything).Return(tc.mockFirstUserBlockSlice, nil),
-				mockRepo.On("GetEmailsByUserIDs", mock.Anything, mock.Anything).Return([]string{"common@example.com"}, nil),
+				mockRepo.On("GetUserIDByEmail", "john@example.com").Return(tc.mockUser.ID, nil),
+				mockRepo.On("GetFriendSuggestions", mock.Anything, tc.mockUser.ID).Return(tc.mockSuggestions, nil),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			router := chi.NewRouter()
+			router.Get("/v1/suggestions", friendController.GetFriendSuggestions)
+			router.Get("/v1/users/{email}/suggestions", friendController.GetFriendSuggestions)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
 
-				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockSecondUser.ID, nil),
-				mockRepo.On("GetFriendsByID", mock.Anything, mock.Anything).Return(tc.mockSecondFriendSlice, nil),
-				mockRepo.On("GetUserBlocksByID", mock.Anything, mock.Anything).Return(tc.mockSecondUserBlockSlice, nil),
-				mockRepo.On("GetEmailsByUserIDs", mock.Anything, mock.Anything).Return([]string{"common@example.com"}, nil),
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
 			}
-			friendController := NewFriendController(mockRepo)
-			handler := http.HandlerFunc(friendController.GetCommonFriends)
+		})
+	}
+}
+
+func TestControllers_GetFriendPath(t *testing.T) {
+	tcs := map[string]struct {
+		url       string
+		input     string
+		expResult string
+		expError  error
+	}{
+		"success with an input": {
+			input:     `{"friends": ["andy@example.com","john@example.com"]}`,
+			expResult: `{"degree":2,"path":["andy@example.com","common@example.com","john@example.com"],"success":true}`,
+		},
+		"failed with a max depth which is shorter than the path": {
+			input:    `{"friends": ["andy@example.com","john@example.com"], "max_depth": 1}`,
+			expError: errors.New(`{"code":"friend_path_not_found","message":"The users are not connected within the max depth","success":false}`),
+		},
+		"success with the default max depth": {
+			input:     `{"friends": ["andy@example.com","john@example.com"], "max_depth": 0}`,
+			expResult: `{"degree":2,"path":["andy@example.com","common@example.com","john@example.com"],"success":true}`,
+		},
+		"failed with a negative max depth": {
+			input:    `{"friends": ["andy@example.com","john@example.com"], "max_depth": -1}`,
+			expError: errors.New(`{"code":"invalid_max_depth","message":"Max depth must be between 1 and 6, or 0 for the default depth of 4","success":false}`),
+		},
+		"failed with an invalid max depth": {
+			input:    `{"friends": ["andy@example.com","john@example.com"], "max_depth": 7}`,
+			expError: errors.New(`{"code":"invalid_max_depth","message":"Max depth must be between 1 and 6, or 0 for the default depth of 4","success":false}`),
+		},
+		"success with emails and a max depth in query": {
+			url:       "/v1/friendPath?email=Andy@Example.com&email=john@example.com&max_depth=2",
+			expResult: `{"degree":2,"path":["andy@example.com","common@example.com","john@example.com"],"success":true}`,
+		},
+		"failed with an invalid max depth in query": {
+			url:      "/v1/friendPath?email=andy@example.com&email=john@example.com&max_depth=two",
+			expError: errors.New(`{"code":"invalid_max_depth","message":"Max depth must be between 1 and 6, or 0 for the default depth of 4","success":false}`),
+		},
+		"failed with an email in query": {
+			url:      "/v1/friendPath?email=andy@example.com",
+			expError: errors.New(`{"code":"invalid_number_of_emails","message":"Number of email addresses must be 2","success":false}`),
+		},
+		"failed without emails in query or body": {
+			url:      "/v1/friendPath",
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+		"failed with an unknow format input": {
+			input:    `{}`,
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			url := tc.url
+			if url == "" {
+				url = "/v1/friendPath"
+			}
+			req, err := http.NewRequest("GET", url, bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("GetUserIDByEmail", "andy@example.com").Return(101, nil),
+				mockRepo.On("GetUserIDByEmail", "john@example.com").Return(100, nil),
+				mockRepo.On("GetUnblockedFriendsByIDs", []int{101}).Return(models.FriendSlice{
+					&models.Friend{UserID: 101, FriendID: 102},
+				}, nil),
+				mockRepo.On("GetUnblockedFriendsByIDs", []int{102}).Return(models.FriendSlice{
+					&models.Friend{UserID: 100, FriendID: 102},
+					&models.Friend{UserID: 101, FriendID: 102},
+					&models.Friend{UserID: 102, FriendID: 103},
+				}, nil),
+				mockRepo.On("GetUsersByIDs", []int{101, 102, 100}).Return(models.UserSlice{
+					&models.User{ID: 100, Email: "john@example.com"},
+					&models.User{ID: 101, Email: "andy@example.com"},
+					&models.User{ID: 102, Email: "common@example.com"},
+				}, nil),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			handler := http.HandlerFunc(friendController.GetFriendPath)
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
 
@@ -201,7 +500,7 @@ func TestControllers_CreateSubcription(t *testing.T) {
 		},
 		"failed with an unknow format input": {
 			input:    `{}`,
-			expError: errors.New(`{"message":"Request body is empty","success":false}`),
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
 		},
 	}
 
@@ -212,13 +511,14 @@ func TestControllers_CreateSubcription(t *testing.T) {
 
 			var mockRepo SpecRepo
 			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("LockUsers", mock.Anything, mock.Anything).Return(nil),
 				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockRequestorUser.ID, nil),
 				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockTargetUser.ID, nil),
 				mockRepo.On("IsSubscribedUser", mock.Anything, mock.Anything, mock.Anything).Return(false, nil),
 				mockRepo.On("IsBlockedUser", mock.Anything, mock.Anything, mock.Anything).Return(false, nil),
 				mockRepo.On("CreateSubscription", mock.Anything, mock.Anything, mock.Anything).Return(nil),
 			}
-			friendController := NewFriendController(mockRepo)
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
 			handler := http.HandlerFunc(friendController.CreateSubcription)
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
@@ -233,6 +533,60 @@ func TestControllers_CreateSubcription(t *testing.T) {
 	}
 }
 
+func TestControllers_DeleteSubscription(t *testing.T) {
+	tcs := map[string]struct {
+		input             string
+		expResult         string
+		expError          error
+		mockRequestorUser models.User
+		mockTargetUser    models.User
+		mockDeleteErr     error
+	}{
+		"success with an input": {
+			input:             `{"requestor": "andy@example.com","target": "lisa@example.com"}`,
+			mockRequestorUser: models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
+			mockTargetUser:    models.User{ID: 103, Name: "Lisa", Email: "lisa@example.com"},
+			expResult:         `{"success":true}`,
+		},
+		"failed with a non-existing relationship": {
+			input:             `{"requestor": "andy@example.com","target": "lisa@example.com"}`,
+			mockRequestorUser: models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
+			mockTargetUser:    models.User{ID: 103, Name: "Lisa", Email: "lisa@example.com"},
+			mockDeleteErr:     repository.ErrNotExistedSubscription,
+			expError:          errors.New(`{"code":"subscription_not_found","message":"The subscription does not exist","success":false}`),
+		},
+		"failed with an unknow format input": {
+			input:    `{}`,
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("DELETE", "/v1/subscription", bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockRequestorUser.ID, nil),
+				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockTargetUser.ID, nil),
+				mockRepo.On("DeleteSubscription", mock.Anything, mock.Anything, mock.Anything).Return(tc.mockDeleteErr),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			handler := http.HandlerFunc(friendController.DeleteSubscription)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestControllers_CreateUserBlocks(t *testing.T) {
 	tcs := map[string]struct {
 		input             string
@@ -249,7 +603,7 @@ func TestControllers_CreateUserBlocks(t *testing.T) {
 		},
 		"failed with an unknow format input": {
 			input:    `{}`,
-			expError: errors.New(`{"message":"Request body is empty","success":false}`),
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
 		},
 	}
 
@@ -260,12 +614,13 @@ func TestControllers_CreateUserBlocks(t *testing.T) {
 
 			var mockRepo SpecRepo
 			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("LockUsers", mock.Anything, mock.Anything).Return(nil),
 				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockRequestorUser.ID, nil),
 				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockTargetUser.ID, nil),
 				mockRepo.On("IsBlockedUser", mock.Anything, mock.Anything, mock.Anything).Return(false, nil),
 				mockRepo.On("CreateUserBlock", mock.Anything, mock.Anything, mock.Anything).Return(nil),
 			}
-			friendController := NewFriendController(mockRepo)
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
 			handler := http.HandlerFunc(friendController.CreateUserBlock)
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
@@ -280,37 +635,133 @@ func TestControllers_CreateUserBlocks(t *testing.T) {
 	}
 }
 
+func TestControllers_DeleteUserBlock(t *testing.T) {
+	tcs := map[string]struct {
+		input             string
+		expResult         string
+		expError          error
+		mockRequestorUser models.User
+		mockTargetUser    models.User
+		mockDeleteErr     error
+	}{
+		"success with an input": {
+			input:             `{"requestor": "andy@example.com","target": "lisa@example.com"}`,
+			mockRequestorUser: models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
+			mockTargetUser:    models.User{ID: 103, Name: "Lisa", Email: "lisa@example.com"},
+			expResult:         `{"success":true}`,
+		},
+		"failed with a non-existing relationship": {
+			input:             `{"requestor": "andy@example.com","target": "lisa@example.com"}`,
+			mockRequestorUser: models.User{ID: 101, Name: "Andy", Email: "andy@example.com"},
+			mockTargetUser:    models.User{ID: 103, Name: "Lisa", Email: "lisa@example.com"},
+			mockDeleteErr:     repository.ErrNotExistedBlockedUser,
+			expError:          errors.New(`{"code":"blocking_not_found","message":"The blocking relationship does not exist","success":false}`),
+		},
+		"failed with an unknow format input": {
+			input:    `{}`,
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("DELETE", "/v1/blocking", bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockRequestorUser.ID, nil),
+				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockTargetUser.ID, nil),
+				mockRepo.On("DeleteUserBlock", mock.Anything, mock.Anything, mock.Anything).Return(tc.mockDeleteErr),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			handler := http.HandlerFunc(friendController.DeleteUserBlock)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
+
 func TestControllers_GetRecipientEmails(t *testing.T) {
 	tcs := map[string]struct {
-		input         string
-		expResult     string
-		expError      error
-		mockUser      models.User
-		mockRecipient models.User
+		input          string
+		url            string
+		expResult      string
+		expError       error
+		mockUser       models.User
+		mockAfter      string
+		mockLimit      int
+		mockRecipients []string
 	}{
 		"success with an input": {
-			input:         `{"sender": "andy@example.com","text": "Hello World! kate@example.com"}`,
-			mockUser:      models.User{ID: 100, Name: "John", Email: "john@example.com"},
-			mockRecipient: models.User{ID: 103, Name: "Lisa", Email: "lisa@example.com"},
-			expResult:     `{"recipients":["lisa@example.com","kate@example.com"],"success":true}`,
+			input:          `{"sender": "andy@example.com","text": "Hello World! kate@example.com"}`,
+			mockUser:       models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockRecipients: []string{"lisa@example.com"},
+			expResult:      `{"next_cursor":null,"recipients":["kate@example.com","lisa@example.com"],"success":true}`,
+		},
+		"success with mentioned emails in different cases": {
+			input:          `{"sender": "andy@example.com","text": "Hello Kate@Example.com, kate@example.com and Lisa@EXAMPLE.com"}`,
+			mockUser:       models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockRecipients: []string{"lisa@example.com"},
+			expResult:      `{"next_cursor":null,"recipients":["kate@example.com","lisa@example.com"],"success":true}`,
+		},
+		"success with sender and text in query": {
+			url:            "/v1/recipients?sender=andy@example.com&text=Hello%20World!%20kate@example.com",
+			mockUser:       models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockRecipients: []string{"lisa@example.com"},
+			expResult:      `{"next_cursor":null,"recipients":["kate@example.com","lisa@example.com"],"success":true}`,
+		},
+		"success with a page of recipients and mentioned emails": {
+			url:            "/v1/recipients?sender=andy@example.com&text=Hi%20kate@example.com%20and%20zoe@example.com&limit=2&after=YW5ueUBleGFtcGxlLmNvbQ",
+			mockUser:       models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockAfter:      "anny@example.com",
+			mockLimit:      3,
+			mockRecipients: []string{"common@example.com", "lisa@example.com", "mike@example.com"},
+			expResult:      `{"next_cursor":"a2F0ZUBleGFtcGxlLmNvbQ","recipients":["common@example.com","kate@example.com"],"success":true}`,
+		},
+		"success with mentioned emails before the page": {
+			url:            "/v1/recipients?sender=andy@example.com&text=Hi%20kate@example.com%20and%20zoe@example.com&limit=2&after=a2F0ZUBleGFtcGxlLmNvbQ",
+			mockUser:       models.User{ID: 100, Name: "John", Email: "john@example.com"},
+			mockAfter:      "kate@example.com",
+			mockLimit:      3,
+			mockRecipients: []string{"lisa@example.com"},
+			expResult:      `{"next_cursor":null,"recipients":["lisa@example.com","zoe@example.com"],"success":true}`,
 		},
 		"failed with an unknow format input": {
 			input:    `{}`,
-			expError: errors.New(`{"message":"Request body is empty","success":false}`),
+			expError: errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+		"failed with an invalid sender in query": {
+			url:      "/v1/recipients?sender=andy&text=Hello",
+			expError: errors.New(`{"code":"invalid_email","message":"andy invalid format (ex: \"andy@example.com\")","success":false}`),
 		},
 	}
 
 	for desc, tc := range tcs {
 		t.Run(desc, func(t *testing.T) {
-			req, err := http.NewRequest("GET", "/v1/recipients", bytes.NewBuffer([]byte(tc.input)))
+			if tc.mockLimit == 0 {
+				tc.mockLimit = DefaultPageLimit + 1
+			}
+			url := tc.url
+			if url == "" {
+				url = "/v1/recipients"
+			}
+			req, err := http.NewRequest("GET", url, bytes.NewBuffer([]byte(tc.input)))
 			require.NoError(t, err)
 
 			var mockRepo SpecRepo
 			mockRepo.ExpectedCalls = []*mock.Call{
 				mockRepo.On("GetUserIDByEmail", mock.Anything, mock.Anything).Return(tc.mockUser.ID, nil),
-				mockRepo.On("GetRecipientEmails", mock.Anything, mock.Anything).Return([]models.User{tc.mockRecipient}, nil),
+				mockRepo.On("GetRecipientEmails", tc.mockUser.ID, tc.mockAfter, tc.mockLimit).Return(tc.mockRecipients, nil),
 			}
-			friendController := NewFriendController(mockRepo)
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
 			handler := http.HandlerFunc(friendController.GetRecipientEmails)
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
@@ -328,34 +779,63 @@ func TestControllers_GetRecipientEmails(t *testing.T) {
 func TestControllers_GetUsers(t *testing.T) {
 	tcs := map[string]struct {
 		input         string
+		query         string
 		expResult     string
 		expError      error
 		mockUsers     models.UserSlice
+		mockCount     int64
 		mockRecipient models.User
 	}{
 		"success with an empty input": {
 			mockUsers: models.UserSlice{
-				&models.User{Name: "john", Email: "john@example.com"},
-				&models.User{Name: "andy", Email: "andy@example.com"},
+				&models.User{ID: 100, Name: "john", Email: "john@example.com"},
+				&models.User{ID: 101, Name: "andy", Email: "andy@example.com"},
 			},
-			expResult: `{"count":2,"success":true,"users":["john@example.com","andy@example.com"]}`,
+			mockCount: 2,
+			expResult: `{"count":2,"next_cursor":null,"success":true,"users":["john@example.com","andy@example.com"]}`,
+		},
+		"success with a next page": {
+			query: "?limit=1",
+			mockUsers: models.UserSlice{
+				&models.User{ID: 100, Name: "john", Email: "john@example.com"},
+				&models.User{ID: 101, Name: "andy", Email: "andy@example.com"},
+			},
+			mockCount: 2,
+			expResult: `{"count":2,"next_cursor":"MTAw","success":true,"users":["john@example.com"]}`,
+		},
+		"success with the last page": {
+			query: "?limit=1&after=MTAw",
+			mockUsers: models.UserSlice{
+				&models.User{ID: 101, Name: "andy", Email: "andy@example.com"},
+			},
+			mockCount: 2,
+			expResult: `{"count":2,"next_cursor":null,"success":true,"users":["andy@example.com"]}`,
 		},
 		"failed with an unknow format input": {
 			input:    `aaa`,
-			expError: errors.New(`{"message":"Body request invalid format","success":false}`),
+			expError: errors.New(`{"code":"invalid_body","message":"Body request invalid format","success":false}`),
+		},
+		"failed with an invalid limit": {
+			query:    "?limit=501",
+			expError: errors.New(`{"code":"invalid_limit","message":"Limit must be between 1 and 500","success":false}`),
+		},
+		"failed with an invalid cursor": {
+			query:    "?after=abc",
+			expError: errors.New(`{"code":"invalid_cursor","message":"Cursor is invalid","success":false}`),
 		},
 	}
 
 	for desc, tc := range tcs {
 		t.Run(desc, func(t *testing.T) {
-			req, err := http.NewRequest("GET", "/v1/users", bytes.NewBuffer([]byte(tc.input)))
+			req, err := http.NewRequest("GET", "/v1/users"+tc.query, bytes.NewBuffer([]byte(tc.input)))
 			require.NoError(t, err)
 
 			var mockRepo SpecRepo
 			mockRepo.ExpectedCalls = []*mock.Call{
-				mockRepo.On("GetUsers", mock.Anything).Return(tc.mockUsers, nil),
+				mockRepo.On("GetUsers", mock.Anything, mock.Anything, mock.Anything).Return(tc.mockUsers, nil),
+				mockRepo.On("CountUsers", mock.Anything).Return(tc.mockCount, nil),
 			}
-			friendController := NewFriendController(mockRepo)
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
 			handler := http.HandlerFunc(friendController.GetUsers)
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
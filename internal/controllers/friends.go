@@ -3,14 +3,21 @@ package controllers
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"strconv"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
 )
 
 type FriendRequest struct {
 	Emails []string `json:"friends"`
 }
 
+type FriendPathRequest struct {
+	Emails   []string `json:"friends"`
+	MaxDepth int      `json:"max_depth"`
+}
+
 type UserRequest struct {
 	Email string `json:"email"`
 }
@@ -25,203 +32,367 @@ type RecipientsRequest struct {
 	Text   string `json:"text"`
 }
 
-// Create a new friend relationship
+// Send a friend request from the first email to the second email, the friendship is only created
+// when the request is accepted. It is kept for clients of the former direct creation of friendships
 func (_self FriendController) CreateFriend(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 	friendReq := FriendRequest{}
 	if err := json.NewDecoder(r.Body).Decode(&friendReq); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(ErrBodyRequestInvalid))
+		RespondError(w, r, ErrBodyRequestInvalid)
 		return
 	}
+	friendReq.Emails = _self.Emails.NormalizeAll(friendReq.Emails)
 
 	// Validate request body
 	if err := friendReq.Validate(); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(err))
+		RespondError(w, r, err)
 		return
 	}
 
-	// Get user id and friend id from repository
-	userId, err := _self.Repo.GetUserIDByEmail(ctx, friendReq.Emails[0])
+	// Get requestor id and target id from repository
+	requestorId, err := _self.Repo.GetUserIDByEmail(ctx, friendReq.Emails[0])
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(fmt.Errorf("%s is not exists", friendReq.Emails[0])))
+		RespondError(w, r, errNotExistedUser(friendReq.Emails[0], err))
 		return
 	}
-	friendId, err := _self.Repo.GetUserIDByEmail(ctx, friendReq.Emails[1])
+	targetId, err := _self.Repo.GetUserIDByEmail(ctx, friendReq.Emails[1])
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(fmt.Errorf("%s is not exists", friendReq.Emails[1])))
+		RespondError(w, r, errNotExistedUser(friendReq.Emails[1], err))
 		return
 	}
 
-	// Check friend relationship is exists
-	isExisted, err := _self.Repo.IsExistedFriend(ctx, userId, friendId)
-	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+	if err := _self.sendFriendRequest(ctx, requestorId, targetId); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	Respond(w, http.StatusOK, MsgOK())
+}
+
+// Delete a friend relationship
+func (_self FriendController) DeleteFriend(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	friendReq := FriendRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&friendReq); err != nil {
+		RespondError(w, r, ErrBodyRequestInvalid)
 		return
 	}
-	if isExisted {
-		Respond(w, http.StatusInternalServerError, MsgError(ErrExistedFriendship))
+	friendReq.Emails = _self.Emails.NormalizeAll(friendReq.Emails)
+
+	// Validate request body
+	if err := friendReq.Validate(); err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
-	// check blocking between 2 emails
-	isBlocked, err := _self.Repo.IsBlockedUser(ctx, userId, friendId)
+	// Get user id and friend id from repository
+	userId, err := _self.Repo.GetUserIDByEmail(ctx, friendReq.Emails[0])
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+		RespondError(w, r, errNotExistedUser(friendReq.Emails[0], err))
 		return
 	}
-	if isBlocked {
-		Respond(w, http.StatusInternalServerError, MsgError(ErrExistedBlockedUser))
+	friendId, err := _self.Repo.GetUserIDByEmail(ctx, friendReq.Emails[1])
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(friendReq.Emails[1], err))
 		return
 	}
 
-	//Call services to create friend relationship
-	if err := _self.Repo.CreateFriend(ctx, userId, friendId); err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(ErrCreatedFriendship))
+	//Call services to delete friend relationship
+	if err := _self.Repo.DeleteFriend(ctx, userId, friendId); err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
 	Respond(w, http.StatusOK, MsgOK())
 }
 
-// Get all of friends of a user without blocking relationship
+// Get all of friends of a user without blocking relationship,
+// the user is given by {email} path segment, email query parameter or JSON body
 func (_self FriendController) GetFriends(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	userReq := UserRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&userReq); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(ErrBodyRequestInvalid))
+	ctx := r.Context()
+	userReq, err := _self.getUserRequest(r)
+	if err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
 	// Validation request body
 	if err := userReq.Validate(); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(err))
+		RespondError(w, r, err)
+		return
+	}
+	page, err := getPageRequest(r)
+	if err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
 	// Get user id from an email
 	userId, err := _self.Repo.GetUserIDByEmail(ctx, userReq.Email)
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(fmt.Errorf("%s is not exists", userReq.Email)))
+		RespondError(w, r, errNotExistedUser(userReq.Email, err))
 		return
 	}
 
 	// Get friends available
-	friendEmails, err := _self.getFriendEmailsWithoutBlocking(ctx, userId)
+	afterId, err := page.AfterID()
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+		RespondError(w, r, err)
+		return
+	}
+	friends, err := _self.Repo.GetUnblockedFriendEmails(ctx, userId, afterId, page.Limit)
+	if err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
-	Respond(w, http.StatusOK, MsgGetFriendsOk(friendEmails, len(friendEmails)))
+	Respond(w, http.StatusOK, MsgGetFriendsOk(friends.Emails, friends.Total, idCursor(friends.NextAfterID)))
 }
 
-// Get common friends of 2 users
+// Get common friends of 2 users, the users are given by repeated email query parameters or JSON body
 func (_self FriendController) GetCommonFriends(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	friendReq := FriendRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&friendReq); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(ErrBodyRequestInvalid))
-		return
+	ctx := r.Context()
+	friendReq := FriendRequest{Emails: r.URL.Query()["email"]}
+	if len(friendReq.Emails) == 0 {
+		if err := decodeReadBody(r, &friendReq); err != nil {
+			RespondError(w, r, err)
+			return
+		}
 	}
+	friendReq.Emails = _self.Emails.NormalizeAll(friendReq.Emails)
 
 	// Validate request body
 	if err := friendReq.Validate(); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(err))
+		RespondError(w, r, err)
+		return
+	}
+	page, err := getPageRequest(r)
+	if err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
 	// Get user id and friend id from repository
 	firstUserID, err := _self.Repo.GetUserIDByEmail(ctx, friendReq.Emails[0])
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(fmt.Errorf("%s is not exists", friendReq.Emails[0])))
+		RespondError(w, r, errNotExistedUser(friendReq.Emails[0], err))
 		return
 	}
 	secondUserID, err := _self.Repo.GetUserIDByEmail(ctx, friendReq.Emails[1])
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(fmt.Errorf("%s is not exists", friendReq.Emails[1])))
+		RespondError(w, r, errNotExistedUser(friendReq.Emails[1], err))
 		return
 	}
 
-	// Get friends of first user and second user
-	firstFriendEmails, err := _self.getFriendEmailsWithoutBlocking(ctx, firstUserID)
+	// Get common friends of first user and second user
+	afterId, err := page.AfterID()
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+		RespondError(w, r, err)
 		return
 	}
-	secondFriendEmails, err := _self.getFriendEmailsWithoutBlocking(ctx, secondUserID)
+	commonFriends, err := _self.Repo.GetCommonFriendEmails(ctx, firstUserID, secondUserID, afterId, page.Limit)
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+		RespondError(w, r, err)
 		return
 	}
 
-	//Get common friends
-	commonFriends := make([]string, 0)
-	commonMap := make(map[string]bool)
-	for _, firstEmail := range firstFriendEmails {
-		commonMap[firstEmail] = true
+	Respond(w, http.StatusOK, MsgGetFriendsOk(commonFriends.Emails, commonFriends.Total, idCursor(commonFriends.NextAfterID)))
+}
+
+// Get friends of friends of a user ranked by number of mutual friends
+func (_self FriendController) GetFriendSuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userReq, err := _self.getUserRequest(r)
+	if err != nil {
+		RespondError(w, r, err)
+		return
 	}
 
-	for _, secondEmail := range secondFriendEmails {
-		if _, ok := commonMap[secondEmail]; ok {
-			commonFriends = append(commonFriends, secondEmail)
+	// Validation request body
+	if err := userReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// Get user id from an email
+	userId, err := _self.Repo.GetUserIDByEmail(ctx, userReq.Email)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(userReq.Email, err))
+		return
+	}
+
+	//Call services
+	suggestions, err := _self.Repo.GetFriendSuggestions(ctx, userId)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	Respond(w, http.StatusOK, MsgGetSuggestionsOk(suggestions, len(suggestions)))
+}
+
+// Get the shortest chain of friends which connects 2 users
+func (_self FriendController) GetFriendPath(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	pathReq := FriendPathRequest{Emails: query["email"]}
+	if maxDepth := query.Get("max_depth"); maxDepth != "" {
+		value, err := strconv.Atoi(maxDepth)
+		if err != nil {
+			RespondError(w, r, ErrMaxDepthInvalid)
+			return
+		}
+		pathReq.MaxDepth = value
+	}
+	if len(pathReq.Emails) == 0 {
+		if err := decodeReadBody(r, &pathReq); err != nil {
+			RespondError(w, r, err)
+			return
 		}
 	}
+	pathReq.Emails = _self.Emails.NormalizeAll(pathReq.Emails)
+
+	// Validate request body
+	if err := pathReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+	if pathReq.MaxDepth == 0 {
+		pathReq.MaxDepth = DefaultPathDepth
+	}
 
-	Respond(w, http.StatusOK, MsgGetFriendsOk(commonFriends, len(commonFriends)))
+	// Get user id and friend id from repository
+	sourceId, err := _self.Repo.GetUserIDByEmail(ctx, pathReq.Emails[0])
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(pathReq.Emails[0], err))
+		return
+	}
+	targetId, err := _self.Repo.GetUserIDByEmail(ctx, pathReq.Emails[1])
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(pathReq.Emails[1], err))
+		return
+	}
+
+	pathIds, err := _self.findFriendPath(ctx, sourceId, targetId, pathReq.MaxDepth)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// Map user ids of the path to emails with the same order
+	users, err := _self.Repo.GetUsersByIDs(ctx, pathIds)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+	emailsMap := make(map[int]string)
+	for _, user := range users {
+		emailsMap[user.ID] = user.Email
+	}
+	path := make([]string, len(pathIds))
+	for i, id := range pathIds {
+		path[i] = emailsMap[id]
+	}
+
+	Respond(w, http.StatusOK, MsgGetFriendPathOk(path))
 }
 
 // Create a subscription relationship of users
 func (_self FriendController) CreateSubcription(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 	requestorReq := RequestorRequest{}
 	if err := json.NewDecoder(r.Body).Decode(&requestorReq); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(ErrBodyRequestInvalid))
+		RespondError(w, r, ErrBodyRequestInvalid)
 		return
 	}
+	requestorReq.Requestor = _self.Emails.Normalize(requestorReq.Requestor)
+	requestorReq.Target = _self.Emails.Normalize(requestorReq.Target)
 
 	//Validate request
 	if err := requestorReq.Validate(); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(err))
+		RespondError(w, r, err)
 		return
 	}
 
 	// Get requestor id and user target id from repository
 	requestorId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Requestor)
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(fmt.Errorf("%s is not exists", requestorReq.Requestor)))
+		RespondError(w, r, errNotExistedUser(requestorReq.Requestor, err))
 		return
 	}
 	targetId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Target)
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(fmt.Errorf("%s is not exists", requestorReq.Target)))
+		RespondError(w, r, errNotExistedUser(requestorReq.Target, err))
 		return
 	}
 
-	// Check subscription relationship is exists
-	isSubscribed, err := _self.Repo.IsSubscribedUser(ctx, requestorId, targetId)
+	// Check and create subscription in a transaction while both users are locked
+	err = _self.Repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+		if err := repo.LockUsers(ctx, requestorId, targetId); err != nil {
+			return err
+		}
+
+		// Check subscription relationship is exists
+		isSubscribed, err := repo.IsSubscribedUser(ctx, requestorId, targetId)
+		if err != nil {
+			return err
+		}
+		if isSubscribed {
+			return ErrExistedSubscription
+		}
+
+		// check blocking between 2 user
+		isBlocked, err := repo.IsBlockedUser(ctx, requestorId, targetId)
+		if err != nil {
+			return err
+		}
+		if isBlocked {
+			return ErrExistedBlockedUser
+		}
+
+		//Call services
+		return repo.CreateSubscription(ctx, requestorId, targetId)
+	})
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+		RespondError(w, r, err)
+		return
+	}
+
+	Respond(w, http.StatusOK, MsgOK())
+}
+
+// Delete a subscription of requestor to target
+func (_self FriendController) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	requestorReq := RequestorRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&requestorReq); err != nil {
+		RespondError(w, r, ErrBodyRequestInvalid)
 		return
 	}
-	if isSubscribed {
-		Respond(w, http.StatusInternalServerError, MsgError(ErrExistedSubscription))
+	requestorReq.Requestor = _self.Emails.Normalize(requestorReq.Requestor)
+	requestorReq.Target = _self.Emails.Normalize(requestorReq.Target)
+
+	//Validate request
+	if err := requestorReq.Validate(); err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
-	// check blocking between 2 user
-	isBlocked, err := _self.Repo.IsBlockedUser(ctx, requestorId, targetId)
+	// Get requestor id and user target id from repository
+	requestorId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Requestor)
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+		RespondError(w, r, errNotExistedUser(requestorReq.Requestor, err))
 		return
 	}
-	if isBlocked {
-		Respond(w, http.StatusInternalServerError, MsgError(ErrExistedBlockedUser))
+	targetId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Target)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(requestorReq.Target, err))
 		return
 	}
 
 	//Call services
-	if err := _self.Repo.CreateSubscription(ctx, requestorId, targetId); err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+	if err := _self.Repo.DeleteSubscription(ctx, requestorId, targetId); err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
@@ -230,166 +401,250 @@ func (_self FriendController) CreateSubcription(w http.ResponseWriter, r *http.R
 
 // Create a blocking relationship of users
 func (_self FriendController) CreateUserBlock(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 	requestorReq := RequestorRequest{}
 	if err := json.NewDecoder(r.Body).Decode(&requestorReq); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(ErrBodyRequestInvalid))
+		RespondError(w, r, ErrBodyRequestInvalid)
 		return
 	}
+	requestorReq.Requestor = _self.Emails.Normalize(requestorReq.Requestor)
+	requestorReq.Target = _self.Emails.Normalize(requestorReq.Target)
 
 	//Validate request
 	if err := requestorReq.Validate(); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(err))
+		RespondError(w, r, err)
 		return
 	}
 
 	// Get requestor id and user target id from repository
 	requestorId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Requestor)
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(fmt.Errorf("%s is not exists", requestorReq.Requestor)))
+		RespondError(w, r, errNotExistedUser(requestorReq.Requestor, err))
 		return
 	}
 	targetId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Target)
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(fmt.Errorf("%s is not exists", requestorReq.Target)))
+		RespondError(w, r, errNotExistedUser(requestorReq.Target, err))
 		return
 	}
 
-	// check blocking between 2 user
-	isBlocked, err := _self.Repo.IsBlockedUser(ctx, requestorId, targetId)
+	// Check and create blocking relationship in a transaction while both users are locked
+	err = _self.Repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+		if err := repo.LockUsers(ctx, requestorId, targetId); err != nil {
+			return err
+		}
+
+		// check blocking between 2 user
+		isBlocked, err := repo.IsBlockedUser(ctx, requestorId, targetId)
+		if err != nil {
+			return err
+		}
+		if isBlocked {
+			return ErrExistedBlockedUser
+		}
+
+		//Call services
+		return repo.CreateUserBlock(ctx, requestorId, targetId)
+	})
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+		RespondError(w, r, err)
 		return
 	}
-	if isBlocked {
-		Respond(w, http.StatusInternalServerError, MsgError(ErrExistedBlockedUser))
+
+	Respond(w, http.StatusOK, MsgOK())
+}
+
+// Delete a blocking relationship which was created by requestor
+func (_self FriendController) DeleteUserBlock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	requestorReq := RequestorRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&requestorReq); err != nil {
+		RespondError(w, r, ErrBodyRequestInvalid)
+		return
+	}
+	requestorReq.Requestor = _self.Emails.Normalize(requestorReq.Requestor)
+	requestorReq.Target = _self.Emails.Normalize(requestorReq.Target)
+
+	//Validate request
+	if err := requestorReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// Get requestor id and user target id from repository
+	requestorId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Requestor)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(requestorReq.Requestor, err))
+		return
+	}
+	targetId, err := _self.Repo.GetUserIDByEmail(ctx, requestorReq.Target)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(requestorReq.Target, err))
 		return
 	}
 
 	//Call services
-	if err := _self.Repo.CreateUserBlock(ctx, requestorId, targetId); err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+	if err := _self.Repo.DeleteUserBlock(ctx, requestorId, targetId); err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
 	Respond(w, http.StatusOK, MsgOK())
 }
 
-// Get all of recipients who are friend, subscriber, and mention user without blocking by user
+// Get all of recipients who are friend, subscriber, and mention user without blocking by user,
+// the sender and the text are given by sender and text query parameters or JSON body
 func (_self FriendController) GetRecipientEmails(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	recipient := RecipientsRequest{}
-	if err := json.NewDecoder(r.Body).Decode(&recipient); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(err))
-		return
+	ctx := r.Context()
+	query := r.URL.Query()
+	recipient := RecipientsRequest{Sender: query.Get("sender"), Text: query.Get("text")}
+	if recipient.Sender == "" && recipient.Text == "" {
+		if err := decodeReadBody(r, &recipient); err != nil {
+			RespondError(w, r, err)
+			return
+		}
 	}
+	recipient.Sender = _self.Emails.Normalize(recipient.Sender)
 
 	// Validate request body
 	if err := recipient.Validate(); err != nil {
-		Respond(w, http.StatusBadRequest, MsgError(err))
+		RespondError(w, r, err)
+		return
+	}
+	page, err := getPageRequest(r)
+	if err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
 	// Check existed email and get userID
 	senderID, err := _self.Repo.GetUserIDByEmail(ctx, recipient.Sender)
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(fmt.Errorf("%s is not exists", recipient.Sender)))
+		RespondError(w, r, errNotExistedUser(recipient.Sender, err))
 		return
 	}
 
-	//Call services
-	recipients, err := _self.Repo.GetRecipientEmails(ctx, senderID)
+	//Call services, one more recipient than the limit is got to know whether there is a next page
+	recipients, err := _self.Repo.GetRecipientEmails(ctx, senderID, page.After, page.Limit+1)
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+		RespondError(w, r, err)
 		return
 	}
 
-	result := make([]string, 0)
-	existedEmailsMap := make(map[string]bool)
-
-	for _, user := range recipients {
-		result = append(result, user.Email)
-		existedEmailsMap[user.Email] = true
-	}
-
-	//Add mentioned emails to result
-	mentionedEmails := GetMentionedEmailFromText(recipient.Text)
-	for _, email := range mentionedEmails {
-		if _, ok := existedEmailsMap[email]; !ok {
-			result = append(result, email)
-		}
-	}
-
-	Respond(w, http.StatusOK, MsgGetEmailReceiversOk(result))
+	//Add mentioned emails to the page
+	emails, nextCursor := paginateRecipients(recipients, GetMentionedEmailFromText(recipient.Text, _self.Emails), page)
+	Respond(w, http.StatusOK, MsgGetEmailReceiversOk(emails, nextCursor))
 }
 
 // Get all of users
 func (_self FriendController) GetUsers(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 	if r.ContentLength != 0 {
-		Respond(w, http.StatusBadRequest, MsgError(ErrBodyRequestInvalid))
+		RespondError(w, r, ErrBodyRequestInvalid)
+		return
+	}
+
+	page, err := getPageRequest(r)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+	afterId, err := page.AfterID()
+	if err != nil {
+		RespondError(w, r, err)
 		return
 	}
 
-	users, err := _self.Repo.GetUsers(ctx)
+	// Get one more user than the limit to know whether there is a next page
+	users, err := _self.Repo.GetUsers(ctx, afterId, page.Limit+1)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+	count, err := _self.Repo.CountUsers(ctx)
 	if err != nil {
-		Respond(w, http.StatusInternalServerError, MsgError(err))
+		RespondError(w, r, err)
 		return
 	}
 
+	nextCursor := ""
+	if len(users) > page.Limit {
+		users = users[:page.Limit]
+		nextCursor = idCursor(users[len(users)-1].ID)
+	}
 	emails := []string{}
 	for _, user := range users {
 		emails = append(emails, user.Email)
 	}
 
-	Respond(w, http.StatusOK, MsgGetAllUsersOk(emails, len(users)))
+	Respond(w, http.StatusOK, MsgGetAllUsersOk(emails, int(count), nextCursor))
 }
 
-// Get emails of users who are not being blocked by user
-func (_self FriendController) getFriendEmailsWithoutBlocking(ctx context.Context, userId int) ([]string, error) {
-	// get friends by user id
-	friendSlice, err := _self.Repo.GetFriendsByID(ctx, userId)
-	if err != nil {
-		return nil, err
-	}
-	friendIds := make([]int, 0)
-	for _, friend := range friendSlice {
-		if friend.UserID == userId {
-			friendIds = append(friendIds, friend.FriendID)
+// Find the shortest chain of user ids from source to target by a bidirectional breadth first search,
+// friendships between users who have blocked each other are not used
+func (_self FriendController) findFriendPath(ctx context.Context, sourceId int, targetId int, maxDepth int) ([]int, error) {
+	sourceParents := map[int]int{sourceId: sourceId}
+	targetParents := map[int]int{targetId: targetId}
+	sourceFrontier := []int{sourceId}
+	targetFrontier := []int{targetId}
+
+	for depth := 0; depth < maxDepth && len(sourceFrontier) > 0 && len(targetFrontier) > 0; depth++ {
+		// Expand the smaller side by one level
+		isSourceSide := len(sourceFrontier) <= len(targetFrontier)
+		frontier, parents, otherParents := sourceFrontier, sourceParents, targetParents
+		if !isSourceSide {
+			frontier, parents, otherParents = targetFrontier, targetParents, sourceParents
 		}
-		if friend.FriendID == userId {
-			friendIds = append(friendIds, friend.UserID)
+
+		friendSlice, err := _self.Repo.GetUnblockedFriendsByIDs(ctx, frontier)
+		if err != nil {
+			return nil, err
+		}
+		inFrontier := make(map[int]bool)
+		for _, id := range frontier {
+			inFrontier[id] = true
 		}
-	}
 
-	//Get list users who have blocked user
-	userBlocksSlice, err := _self.Repo.GetUserBlocksByID(ctx, userId)
-	if err != nil {
-		return nil, err
-	}
-	userBlocksID := make([]int, 0)
-	for _, user := range userBlocksSlice {
-		if user.RequestorID == userId {
-			userBlocksID = append(userBlocksID, user.TargetID)
+		nextFrontier := make([]int, 0)
+		for _, friend := range friendSlice {
+			for _, edge := range [][2]int{{friend.UserID, friend.FriendID}, {friend.FriendID, friend.UserID}} {
+				from, to := edge[0], edge[1]
+				if !inFrontier[from] {
+					continue
+				}
+				if _, isVisited := parents[to]; isVisited {
+					continue
+				}
+				parents[to] = from
+
+				// Both searches have met, the first meeting point is on a shortest path
+				if _, isMet := otherParents[to]; isMet {
+					return buildFriendPath(to, sourceParents, targetParents), nil
+				}
+				nextFrontier = append(nextFrontier, to)
+			}
 		}
-		if user.TargetID == userId {
-			userBlocksID = append(userBlocksID, user.RequestorID)
+
+		if isSourceSide {
+			sourceFrontier = nextFrontier
+		} else {
+			targetFrontier = nextFrontier
 		}
 	}
-	//Get UserID list with no blocked
-	blockList := make(map[int]bool)
-	for _, id := range userBlocksID {
-		blockList[id] = true
-	}
-	friendIDsNonBlock := make([]int, 0)
-	for _, id := range friendIds {
-		if _, isBlock := blockList[id]; !isBlock {
-			friendIDsNonBlock = append(friendIDsNonBlock, id)
-		}
+
+	return nil, ErrNotExistedFriendPath
+}
+
+// Build the chain of user ids from source to target which goes through the meeting point of both searches
+func buildFriendPath(meetingId int, sourceParents map[int]int, targetParents map[int]int) []int {
+	path := []int{meetingId}
+	for id := meetingId; sourceParents[id] != id; {
+		id = sourceParents[id]
+		path = append([]int{id}, path...)
 	}
-	emails, err := _self.Repo.GetEmailsByUserIDs(ctx, friendIDsNonBlock)
-	if err != nil {
-		return nil, err
+	for id := meetingId; targetParents[id] != id; {
+		id = targetParents[id]
+		path = append(path, id)
 	}
-	return emails, nil
+	return path
 }
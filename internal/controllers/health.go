@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses of the readiness and of its checks
+const (
+	HealthOK           = "ok"
+	HealthUnavailable  = "unavailable"
+	HealthShuttingDown = "shutting_down"
+)
+
+// HealthCheck is a dependency which has to be available to serve requests, ex: the database
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthController responds liveness and readiness of the server
+type HealthController struct {
+	checks       []HealthCheck
+	timeout      time.Duration
+	shuttingDown int32
+}
+
+// NewHealthController creates a controller whose readiness runs checks within 2 seconds
+func NewHealthController(checks ...HealthCheck) *HealthController {
+	return &HealthController{
+		checks:  checks,
+		timeout: 2 * time.Second,
+	}
+}
+
+// Readiness fails from now on, it is called when the server starts shutting down
+func (_self *HealthController) SetShuttingDown() {
+	atomic.StoreInt32(&_self.shuttingDown, 1)
+}
+
+// Liveness responds ok while the process serves requests, dependencies are not checked
+func (_self *HealthController) Liveness(w http.ResponseWriter, r *http.Request) {
+	Respond(w, http.StatusOK, map[string]interface{}{"status": HealthOK})
+}
+
+// Readiness runs all checks concurrently and responds their status and latency,
+// it fails when a check fails or the server is shutting down. Errors of checks are logged with the request id
+func (_self *HealthController) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), _self.timeout)
+	defer cancel()
+
+	results := make(map[string]interface{}, len(_self.checks))
+	status := HealthOK
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range _self.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Check(ctx)
+			result := map[string]interface{}{
+				"status":     HealthOK,
+				"latency_ms": time.Since(start).Milliseconds(),
+			}
+			// Errors of drivers may have hosts of dependencies, they are only logged
+			if err != nil {
+				result["status"] = HealthUnavailable
+				log.Printf("request_id=%s readiness check %s failed: %v", RequestIDFrom(r.Context()), check.Name, errContext(err))
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[check.Name] = result
+			if err != nil {
+				status = HealthUnavailable
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(&_self.shuttingDown) == 1 {
+		status = HealthShuttingDown
+	}
+	statusCode := http.StatusOK
+	if status != HealthOK {
+		statusCode = http.StatusServiceUnavailable
+	}
+	Respond(w, statusCode, map[string]interface{}{"status": status, "checks": results})
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllers_Liveness(t *testing.T) {
+	req, err := http.NewRequest("GET", "/healthz", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	NewHealthController().Liveness(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+func TestControllers_Readiness(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	tcs := map[string]struct {
+		checks       []HealthCheck
+		shuttingDown bool
+		expStatus    int
+		expResult    map[string]interface{}
+		expLog       string
+	}{
+		"success with all checks ok": {
+			checks:    []HealthCheck{{Name: "database", Check: ok}, {Name: "migrations", Check: ok}},
+			expStatus: http.StatusOK,
+			expResult: map[string]interface{}{
+				"status": "ok",
+				"checks": map[string]interface{}{
+					"database":   map[string]interface{}{"status": "ok"},
+					"migrations": map[string]interface{}{"status": "ok"},
+				},
+			},
+		},
+		"success without checks": {
+			expStatus: http.StatusOK,
+			expResult: map[string]interface{}{"status": "ok", "checks": map[string]interface{}{}},
+		},
+		"failed with a failing check": {
+			checks: []HealthCheck{
+				{Name: "database", Check: func(ctx context.Context) error { return errors.New("connection refused") }},
+				{Name: "migrations", Check: ok},
+			},
+			expStatus: http.StatusServiceUnavailable,
+			expResult: map[string]interface{}{
+				"status": "unavailable",
+				"checks": map[string]interface{}{
+					"database":   map[string]interface{}{"status": "unavailable"},
+					"migrations": map[string]interface{}{"status": "ok"},
+				},
+			},
+			expLog: "request_id=req-1 readiness check database failed: connection refused",
+		},
+		"failed with a check which is longer than the timeout": {
+			checks: []HealthCheck{{Name: "database", Check: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}}},
+			expStatus: http.StatusServiceUnavailable,
+			expResult: map[string]interface{}{
+				"status": "unavailable",
+				"checks": map[string]interface{}{
+					"database": map[string]interface{}{"status": "unavailable"},
+				},
+			},
+			expLog: "request_id=req-1 readiness check database failed: The request has not been completed in time",
+		},
+		"failed while shutting down": {
+			checks:       []HealthCheck{{Name: "database", Check: ok}},
+			shuttingDown: true,
+			expStatus:    http.StatusServiceUnavailable,
+			expResult: map[string]interface{}{
+				"status": "shutting_down",
+				"checks": map[string]interface{}{
+					"database": map[string]interface{}{"status": "ok"},
+				},
+			},
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			req, err := http.NewRequest("GET", "/readyz", nil)
+			require.NoError(t, err)
+			req.Header.Set(RequestIDHeader, "req-1")
+			rr := httptest.NewRecorder()
+
+			controller := NewHealthController(tc.checks...)
+			controller.timeout = 10 * time.Millisecond
+			if tc.shuttingDown {
+				controller.SetShuttingDown()
+			}
+			RequestID(http.HandlerFunc(controller.Readiness)).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expStatus, rr.Code)
+			var result map[string]interface{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+			// Latencies vary between runs, only their presence is checked
+			for name, check := range result["checks"].(map[string]interface{}) {
+				require.Contains(t, check, "latency_ms", name)
+				delete(check.(map[string]interface{}), "latency_ms")
+			}
+			require.Equal(t, tc.expResult, result)
+			if tc.expLog == "" {
+				require.Empty(t, logs.String())
+			} else {
+				require.Contains(t, logs.String(), tc.expLog)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -11,7 +12,30 @@ type SpecRepo struct {
 	mock.Mock
 }
 
-func (m SpecRepo) CreateFriend(ctx context.Context, userId int, friendId int) error {
+// Run the unit of work with the same mock repository
+func (m *SpecRepo) WithTx(ctx context.Context, fn func(repo repository.SpecRepo) error) error {
+	return fn(m)
+}
+
+func (m *SpecRepo) LockUsers(ctx context.Context, userIds ...int) error {
+	args := m.Called(ctx, userIds)
+	var r error
+	if args.Get(0) != nil {
+		r = args.Get(0).(error)
+	}
+	return r
+}
+
+func (m *SpecRepo) CreateFriend(ctx context.Context, userId int, friendId int) error {
+	args := m.Called(ctx, userId, friendId)
+	var r error
+	if args.Get(0) != nil {
+		r = args.Get(0).(error)
+	}
+	return r
+}
+
+func (m *SpecRepo) DeleteFriend(ctx context.Context, userId int, friendId int) error {
 	args := m.Called(ctx, userId, friendId)
 	var r error
 	if args.Get(0) != nil {
@@ -20,13 +44,36 @@ func (m SpecRepo) CreateFriend(ctx context.Context, userId int, friendId int) er
 	return r
 }
 
-func (m SpecRepo) GetFriendsByID(ctx context.Context, userId int) (models.FriendSlice, error) {
+func (m *SpecRepo) GetFriendsByID(ctx context.Context, userId int) (models.FriendSlice, error) {
 	args := m.Called(userId)
 	t := args.Get(0).(models.FriendSlice)
 	return t, args.Error(1)
 }
 
-func (m SpecRepo) GetUserBlocksByID(ctx context.Context, userId int) (models.UserBlockSlice, error) {
+func (m *SpecRepo) GetUnblockedFriendsByIDs(ctx context.Context, userIds []int) (models.FriendSlice, error) {
+	args := m.Called(userIds)
+	r1 := args.Get(0).(models.FriendSlice)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *SpecRepo) GetUnblockedFriendEmails(ctx context.Context, userId int, afterId int, limit int) (repository.FriendPage, error) {
+	args := m.Called(userId, afterId, limit)
+	r1 := args.Get(0).(repository.FriendPage)
+	return r1, args.Error(1)
+}
+
+func (m *SpecRepo) GetCommonFriendEmails(ctx context.Context, userId int, otherId int, afterId int, limit int) (repository.FriendPage, error) {
+	args := m.Called(userId, otherId, afterId, limit)
+	r1 := args.Get(0).(repository.FriendPage)
+	return r1, args.Error(1)
+}
+
+func (m *SpecRepo) GetUserBlocksByID(ctx context.Context, userId int) (models.UserBlockSlice, error) {
 	args := m.Called(userId)
 	r1 := args.Get(0).(models.UserBlockSlice)
 
@@ -37,7 +84,7 @@ func (m SpecRepo) GetUserBlocksByID(ctx context.Context, userId int) (models.Use
 	return r1, r2
 }
 
-func (m SpecRepo) CreateSubscription(ctx context.Context, requestorId int, targetId int) error {
+func (m *SpecRepo) CreateSubscription(ctx context.Context, requestorId int, targetId int) error {
 	args := m.Called(ctx, requestorId, targetId)
 	var r error
 	if args.Get(0) != nil {
@@ -46,9 +93,18 @@ func (m SpecRepo) CreateSubscription(ctx context.Context, requestorId int, targe
 	return r
 }
 
-func (m SpecRepo) GetRecipientEmails(ctx context.Context, senderId int) ([]models.User, error) {
-	args := m.Called(ctx, senderId)
-	r1 := args.Get(0).([]models.User)
+func (m *SpecRepo) DeleteSubscription(ctx context.Context, requestorId int, targetId int) error {
+	args := m.Called(ctx, requestorId, targetId)
+	var r error
+	if args.Get(0) != nil {
+		r = args.Get(0).(error)
+	}
+	return r
+}
+
+func (m *SpecRepo) GetRecipientEmails(ctx context.Context, senderId int, afterEmail string, limit int) ([]string, error) {
+	args := m.Called(senderId, afterEmail, limit)
+	r1 := args.Get(0).([]string)
 
 	var r2 error
 	if args.Get(1) != nil {
@@ -57,7 +113,16 @@ func (m SpecRepo) GetRecipientEmails(ctx context.Context, senderId int) ([]model
 	return r1, r2
 }
 
-func (m SpecRepo) CreateUserBlock(ctx context.Context, requestorId int, targetId int) error {
+func (m *SpecRepo) CreateUserBlock(ctx context.Context, requestorId int, targetId int) error {
+	args := m.Called(ctx, requestorId, targetId)
+	var r error
+	if args.Get(0) != nil {
+		r = args.Get(0).(error)
+	}
+	return r
+}
+
+func (m *SpecRepo) DeleteUserBlock(ctx context.Context, requestorId int, targetId int) error {
 	args := m.Called(ctx, requestorId, targetId)
 	var r error
 	if args.Get(0) != nil {
@@ -66,7 +131,7 @@ func (m SpecRepo) CreateUserBlock(ctx context.Context, requestorId int, targetId
 	return r
 }
 
-func (m SpecRepo) IsExistedFriend(ctx context.Context, userId int, friendId int) (bool, error) {
+func (m *SpecRepo) IsExistedFriend(ctx context.Context, userId int, friendId int) (bool, error) {
 	args := m.Called(ctx, userId, friendId)
 	r1 := args.Get(0).(bool)
 
@@ -77,7 +142,7 @@ func (m SpecRepo) IsExistedFriend(ctx context.Context, userId int, friendId int)
 	return r1, r2
 }
 
-func (m SpecRepo) IsBlockedUser(ctx context.Context, userId int, friendId int) (bool, error) {
+func (m *SpecRepo) IsBlockedUser(ctx context.Context, userId int, friendId int) (bool, error) {
 	args := m.Called(ctx, userId, friendId)
 	r1 := args.Get(0).(bool)
 
@@ -88,7 +153,7 @@ func (m SpecRepo) IsBlockedUser(ctx context.Context, userId int, friendId int) (
 	return r1, r2
 }
 
-func (m SpecRepo) IsSubscribedUser(ctx context.Context, requestorId int, targetId int) (bool, error) {
+func (m *SpecRepo) IsSubscribedUser(ctx context.Context, requestorId int, targetId int) (bool, error) {
 	args := m.Called(ctx, requestorId, targetId)
 	r1 := args.Get(0).(bool)
 
@@ -99,14 +164,14 @@ func (m SpecRepo) IsSubscribedUser(ctx context.Context, requestorId int, targetI
 	return r1, r2
 }
 
-func (m SpecRepo) GetUserIDByEmail(ctx context.Context, email string) (int, error) {
+func (m *SpecRepo) GetUserIDByEmail(ctx context.Context, email string) (int, error) {
 	args := m.Called(email)
 
 	t := args.Get(0).(int)
 	return t, args.Error(1)
 }
 
-func (m SpecRepo) GetEmailsByUserIDs(ctx context.Context, userIDs []int) ([]string, error) {
+func (m *SpecRepo) GetEmailsByUserIDs(ctx context.Context, userIDs []int) ([]string, error) {
 	args := m.Called(userIDs)
 	r1 := args.Get(0).([]string)
 
@@ -117,8 +182,8 @@ func (m SpecRepo) GetEmailsByUserIDs(ctx context.Context, userIDs []int) ([]stri
 	return r1, r2
 }
 
-func (m SpecRepo) GetUsers(ctx context.Context) (models.UserSlice, error) {
-	args := m.Called(ctx)
+func (m *SpecRepo) GetUsersByIDs(ctx context.Context, userIDs []int) (models.UserSlice, error) {
+	args := m.Called(userIDs)
 	r1 := args.Get(0).(models.UserSlice)
 
 	var r2 error
@@ -127,3 +192,204 @@ func (m SpecRepo) GetUsers(ctx context.Context) (models.UserSlice, error) {
 	}
 	return r1, r2
 }
+
+func (m *SpecRepo) GetUsers(ctx context.Context, afterId int, limit int) (models.UserSlice, error) {
+	args := m.Called(ctx, afterId, limit)
+	r1 := args.Get(0).(models.UserSlice)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *SpecRepo) CountUsers(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	r1 := args.Get(0).(int64)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *SpecRepo) CreateUser(ctx context.Context, name string, email string) (*models.User, error) {
+	args := m.Called(ctx, name, email)
+	r1 := args.Get(0).(*models.User)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *SpecRepo) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	r1 := args.Get(0).(*models.User)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *SpecRepo) IsExistedUser(ctx context.Context, email string) (bool, error) {
+	args := m.Called(ctx, email)
+	r1 := args.Get(0).(bool)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *SpecRepo) UpdateUserName(ctx context.Context, userId int, name string) error {
+	args := m.Called(ctx, userId, name)
+	var r error
+	if args.Get(0) != nil {
+		r = args.Get(0).(error)
+	}
+	return r
+}
+
+func (m *SpecRepo) DeleteUser(ctx context.Context, userId int) error {
+	args := m.Called(ctx, userId)
+	var r error
+	if args.Get(0) != nil {
+		r = args.Get(0).(error)
+	}
+	return r
+}
+
+func (m *SpecRepo) GetFriendSuggestions(ctx context.Context, userId int) ([]repository.FriendSuggestion, error) {
+	args := m.Called(ctx, userId)
+	r1 := args.Get(0).([]repository.FriendSuggestion)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *SpecRepo) CreateFriendRequest(ctx context.Context, requestorId int, targetId int) error {
+	args := m.Called(ctx, requestorId, targetId)
+	var r error
+	if args.Get(0) != nil {
+		r = args.Get(0).(error)
+	}
+	return r
+}
+
+func (m *SpecRepo) IsPendingFriendRequest(ctx context.Context, userId int, friendId int) (bool, error) {
+	args := m.Called(ctx, userId, friendId)
+	r1 := args.Get(0).(bool)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *SpecRepo) GetPendingFriendRequest(ctx context.Context, requestorId int, targetId int) (*models.FriendRequest, error) {
+	args := m.Called(ctx, requestorId, targetId)
+	r1 := args.Get(0).(*models.FriendRequest)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *SpecRepo) GetIncomingFriendRequests(ctx context.Context, userId int) (models.FriendRequestSlice, error) {
+	args := m.Called(ctx, userId)
+	r1 := args.Get(0).(models.FriendRequestSlice)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *SpecRepo) GetOutgoingFriendRequests(ctx context.Context, userId int) (models.FriendRequestSlice, error) {
+	args := m.Called(ctx, userId)
+	r1 := args.Get(0).(models.FriendRequestSlice)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *SpecRepo) AcceptFriendRequest(ctx context.Context, requestId int) error {
+	args := m.Called(ctx, requestId)
+	var r error
+	if args.Get(0) != nil {
+		r = args.Get(0).(error)
+	}
+	return r
+}
+
+func (m *SpecRepo) UpdateFriendRequestStatus(ctx context.Context, requestId int, status string) error {
+	args := m.Called(ctx, requestId, status)
+	var r error
+	if args.Get(0) != nil {
+		r = args.Get(0).(error)
+	}
+	return r
+}
+
+type APIKeyStore struct {
+	mock.Mock
+}
+
+func (m *APIKeyStore) CreateAPIKey(ctx context.Context, name string, keyHash string) (*models.APIKey, error) {
+	args := m.Called(ctx, name, keyHash)
+	r1 := args.Get(0).(*models.APIKey)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *APIKeyStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	r1 := args.Get(0).(*models.APIKey)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *APIKeyStore) GetAPIKeys(ctx context.Context) (models.APIKeySlice, error) {
+	args := m.Called(ctx)
+	r1 := args.Get(0).(models.APIKeySlice)
+
+	var r2 error
+	if args.Get(1) != nil {
+		r2 = args.Get(1).(error)
+	}
+	return r1, r2
+}
+
+func (m *APIKeyStore) RevokeAPIKey(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	var r error
+	if args.Get(0) != nil {
+		r = args.Get(0).(error)
+	}
+	return r
+}
@@ -3,11 +3,13 @@ package controllers
 import "github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
 
 type FriendController struct {
-	Repo repository.SpecRepo
+	Repo   repository.SpecRepo
+	Emails repository.EmailNormalizer
 }
 
-func NewFriendController(repo repository.SpecRepo) FriendController {
+func NewFriendController(repo repository.SpecRepo, emails repository.EmailNormalizer) FriendController {
 	return FriendController{
-		Repo: repo,
+		Repo:   repo,
+		Emails: emails,
 	}
 }
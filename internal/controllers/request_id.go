@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+// RequestIDHeader is the header of the id of a request which is given by the client or generated by the server
+const RequestIDHeader = "X-Request-ID"
+
+// Ids of clients are kept when they are short tokens, other ids are replaced so logs cannot be forged
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// RequestID is a middleware which propagates the X-Request-ID header of a request or generates a new id.
+// The id is responded in the X-Request-ID header and in error bodies, and it is set on the request header
+// so request logs have the same id
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !requestIDRegex.MatchString(id) {
+			id = newRequestID()
+		}
+		r.Header.Set(RequestIDHeader, id)
+		w.Header().Set(RequestIDHeader, id)
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// Get the id of the request of ctx, it is empty without the RequestID middleware
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Generate a random id of 32 hex characters
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllers_RequestID(t *testing.T) {
+	tcs := map[string]struct {
+		header  string
+		expKept bool
+	}{
+		"success with the id of the client": {
+			header:  "5f0c2a7e-7d1b-4c55-9f3e-0c1f6a2b9d10",
+			expKept: true,
+		},
+		"success with a generated id": {},
+		"success with a generated id instead of an id with spaces": {
+			header: "id\n level=error forged",
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v1/users", nil)
+			require.NoError(t, err)
+			req.Header.Set(RequestIDHeader, tc.header)
+			rr := httptest.NewRecorder()
+
+			var ctxID, headerID string
+			RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = RequestIDFrom(r.Context())
+				headerID = r.Header.Get(RequestIDHeader)
+			})).ServeHTTP(rr, req)
+
+			if tc.expKept {
+				require.Equal(t, tc.header, ctxID)
+			} else {
+				require.Regexp(t, `^[0-9a-f]{32}$`, ctxID)
+			}
+			require.Equal(t, ctxID, headerID)
+			require.Equal(t, ctxID, rr.Header().Get(RequestIDHeader))
+		})
+	}
+}
+
+func TestControllers_RespondError(t *testing.T) {
+	tcs := map[string]struct {
+		err       error
+		expStatus int
+		expResult string
+		expLog    string
+	}{
+		"success with a domain error": {
+			err:       ErrBodyRequestEmpty,
+			expStatus: http.StatusUnprocessableEntity,
+			expResult: `{"code":"empty_body","message":"Request body is empty","request_id":"req-1","success":false}`,
+		},
+		"success with a sanitized internal error": {
+			err:       errors.New(`pq: relation "users" does not exist`),
+			expStatus: http.StatusInternalServerError,
+			expResult: `{"code":"internal_error","message":"An internal error has occurred, report the request_id to support","request_id":"req-1","success":false}`,
+			expLog:    `request_id=req-1 GET /v1/users failed: pq: relation "users" does not exist`,
+		},
+		"success with a logged domain server error": {
+			err:       ErrCreatedFriendship,
+			expStatus: http.StatusInternalServerError,
+			expResult: `{"code":"friendship_not_created","message":"Users cannot be created a new friendship","request_id":"req-1","success":false}`,
+			expLog:    `request_id=req-1 GET /v1/users failed: Users cannot be created a new friendship`,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			var logs bytes.Buffer
+			log.SetOutput(&logs)
+			defer log.SetOutput(os.Stderr)
+
+			req, err := http.NewRequest("GET", "/v1/users", nil)
+			require.NoError(t, err)
+			req.Header.Set(RequestIDHeader, "req-1")
+			rr := httptest.NewRecorder()
+
+			RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				RespondError(w, r, tc.err)
+			})).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expStatus, rr.Code)
+			require.Equal(t, tc.expResult, rr.Body.String())
+			if tc.expLog == "" {
+				require.Empty(t, logs.String())
+			} else {
+				require.Contains(t, logs.String(), tc.expLog)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Timeout is a middleware which cancels the request context after timeout,
+// so queries of the request are canceled when the deadline expires or the client disconnects
+func Timeout(timeout time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			r = r.WithContext(ctx)
+			next.ServeHTTP(&timeoutWriter{ResponseWriter: w, r: r}, r)
+		})
+	}
+}
+
+// timeoutWriter replaces a server error responded after the request context is done with the error of the context,
+// drivers report a canceled query by their own errors instead of the context error
+type timeoutWriter struct {
+	http.ResponseWriter
+	r        *http.Request
+	replaced bool
+}
+
+func (_self *timeoutWriter) WriteHeader(statusCode int) {
+	if statusCode >= http.StatusInternalServerError && _self.r.Context().Err() != nil {
+		_self.replaced = true
+		_self.ResponseWriter.Header().Del("Content-Type")
+		RespondError(_self.ResponseWriter, _self.r, _self.r.Context().Err())
+		return
+	}
+	_self.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (_self *timeoutWriter) Write(b []byte) (int, error) {
+	if _self.replaced {
+		return len(b), nil
+	}
+	return _self.ResponseWriter.Write(b)
+}
+
+// Translate errors of a done context, other errors are kept
+func errContext(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrRequestTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrRequestCanceled
+	}
+	return err
+}
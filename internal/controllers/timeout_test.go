@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllers_Timeout(t *testing.T) {
+	tcs := map[string]struct {
+		timeout   time.Duration
+		handler   http.HandlerFunc
+		expStatus int
+		expResult string
+	}{
+		"success before the deadline": {
+			timeout: time.Second,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				Respond(w, http.StatusOK, MsgOK())
+			},
+			expStatus: http.StatusOK,
+			expResult: `{"success":true}`,
+		},
+		"failed with a driver error after the deadline": {
+			timeout: time.Millisecond,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				RespondError(w, r, errors.New("pq: canceling statement due to user request"))
+			},
+			expStatus: http.StatusGatewayTimeout,
+			expResult: `{"code":"request_timeout","message":"The request has not been completed in time","success":false}`,
+		},
+		"failed with the context error after the deadline": {
+			timeout: time.Millisecond,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				RespondError(w, r, r.Context().Err())
+			},
+			expStatus: http.StatusGatewayTimeout,
+			expResult: `{"code":"request_timeout","message":"The request has not been completed in time","success":false}`,
+		},
+		"keep a client error after the deadline": {
+			timeout: time.Millisecond,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				RespondError(w, r, ErrBodyRequestEmpty)
+			},
+			expStatus: http.StatusUnprocessableEntity,
+			expResult: `{"code":"empty_body","message":"Request body is empty","success":false}`,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v1/users", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			Timeout(tc.timeout)(tc.handler).ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expStatus, rr.Code)
+			require.Equal(t, tc.expResult, rr.Body.String())
+			require.Equal(t, []string{"application/json"}, rr.Header().Values("Content-Type"))
+		})
+	}
+}
+
+func TestControllers_TimeoutCanceledClient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, "GET", "/v1/users", nil)
+	require.NoError(t, err)
+	cancel()
+
+	rr := httptest.NewRecorder()
+	Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		RespondError(w, r, errors.New("sql: transaction has already been committed or rolled back"))
+	})).ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	require.Equal(t, `{"code":"request_canceled","message":"The request has been canceled","success":false}`, rr.Body.String())
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/go-chi/chi"
+)
+
+type CreateUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type UpdateUserRequest struct {
+	Name string `json:"name"`
+}
+
+// Create a new user
+func (_self FriendController) CreateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userReq := CreateUserRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&userReq); err != nil {
+		RespondError(w, r, ErrBodyRequestInvalid)
+		return
+	}
+	userReq.Email = _self.Emails.Normalize(userReq.Email)
+	userReq.Name = strings.TrimSpace(userReq.Name)
+
+	// Validate request body
+	if err := userReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	//Call services, an existing email is not inserted again
+	user, err := _self.Repo.CreateUser(ctx, userReq.Name, userReq.Email)
+	if errors.Is(err, repository.ErrExistedUser) {
+		RespondError(w, r, errExistedUser(userReq.Email))
+		return
+	}
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	Respond(w, http.StatusOK, MsgGetUserOk(user))
+}
+
+// Get a user by email in url path
+func (_self FriendController) GetUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userReq := UserRequest{Email: _self.Emails.Normalize(chi.URLParam(r, "email"))}
+
+	// Validate email in url path
+	if err := userReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	user, err := _self.Repo.GetUserByEmail(ctx, userReq.Email)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(userReq.Email, err))
+		return
+	}
+
+	Respond(w, http.StatusOK, MsgGetUserOk(user))
+}
+
+// Update name of a user by email in url path
+func (_self FriendController) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userReq := UserRequest{Email: _self.Emails.Normalize(chi.URLParam(r, "email"))}
+	updateReq := UpdateUserRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
+		RespondError(w, r, ErrBodyRequestInvalid)
+		return
+	}
+	updateReq.Name = strings.TrimSpace(updateReq.Name)
+
+	// Validate email in url path and request body
+	if err := userReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+	if err := updateReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// Get user id from an email
+	userId, err := _self.Repo.GetUserIDByEmail(ctx, userReq.Email)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(userReq.Email, err))
+		return
+	}
+
+	//Call services
+	if err := _self.Repo.UpdateUserName(ctx, userId, updateReq.Name); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	user, err := _self.Repo.GetUserByEmail(ctx, userReq.Email)
+	if err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	Respond(w, http.StatusOK, MsgGetUserOk(user))
+}
+
+// Delete a user by email in url path with all of relationships of the user
+func (_self FriendController) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userReq := UserRequest{Email: _self.Emails.Normalize(chi.URLParam(r, "email"))}
+
+	// Validate email in url path
+	if err := userReq.Validate(); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	// Get user id from an email
+	userId, err := _self.Repo.GetUserIDByEmail(ctx, userReq.Email)
+	if err != nil {
+		RespondError(w, r, errNotExistedUser(userReq.Email, err))
+		return
+	}
+
+	//Call services
+	if err := _self.Repo.DeleteUser(ctx, userId); err != nil {
+		RespondError(w, r, err)
+		return
+	}
+
+	Respond(w, http.StatusOK, MsgOK())
+}
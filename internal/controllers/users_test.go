@@ -0,0 +1,236 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControllers_CreateUser(t *testing.T) {
+	createdAt := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+	tcs := map[string]struct {
+		input         string
+		keepLocalCase bool
+		expStatus     int
+		expResult     string
+		expError      error
+		mockUser      *models.User
+		mockCreateErr error
+	}{
+		"success with an input": {
+			input:     `{"name": " Mike ","email": "mike@example.com"}`,
+			expStatus: http.StatusOK,
+			mockUser:  &models.User{ID: 105, Name: "Mike", Email: "mike@example.com", CreatedAt: createdAt, UpdatedAt: createdAt},
+			expResult: `{"success":true,"user":{"created_at":"2021-12-01T10:00:00Z","email":"mike@example.com","name":"Mike","updated_at":"2021-12-01T10:00:00Z"}}`,
+		},
+		"success with keeping case of the local part": {
+			input:         `{"name": "Mike","email": "Mike@Example.com"}`,
+			keepLocalCase: true,
+			expStatus:     http.StatusOK,
+			mockUser:      &models.User{ID: 105, Name: "Mike", Email: "Mike@example.com", CreatedAt: createdAt, UpdatedAt: createdAt},
+			expResult:     `{"success":true,"user":{"created_at":"2021-12-01T10:00:00Z","email":"Mike@example.com","name":"Mike","updated_at":"2021-12-01T10:00:00Z"}}`,
+		},
+		"failed with an existing email": {
+			input:         `{"name": "Mike","email": "Mike@Example.com"}`,
+			mockCreateErr: repository.ErrExistedUser,
+			expStatus:     http.StatusConflict,
+			expError:      errors.New(`{"code":"user_exists","message":"mike@example.com has been existed","success":false}`),
+		},
+		"failed with an empty name": {
+			input:     `{"name": " ","email": "mike@example.com"}`,
+			expStatus: http.StatusUnprocessableEntity,
+			expError:  errors.New(`{"code":"invalid_name","message":"Name field must be between 1 and 100 characters","success":false}`),
+		},
+		"failed with an unknow format input": {
+			input:     `{}`,
+			expStatus: http.StatusUnprocessableEntity,
+			expError:  errors.New(`{"code":"empty_body","message":"Request body is empty","success":false}`),
+		},
+		"failed with a malformed input": {
+			input:     `{"name": "Mike",`,
+			expStatus: http.StatusBadRequest,
+			expError:  errors.New(`{"code":"invalid_body","message":"Body request invalid format","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/v1/users", bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("CreateUser", mock.Anything, "Mike", "mike@example.com").Return(tc.mockUser, tc.mockCreateErr),
+				mockRepo.On("CreateUser", mock.Anything, "Mike", "Mike@example.com").Return(tc.mockUser, tc.mockCreateErr),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(!tc.keepLocalCase))
+			handler := http.HandlerFunc(friendController.CreateUser)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expStatus, rr.Code)
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestControllers_GetUser(t *testing.T) {
+	createdAt := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+	tcs := map[string]struct {
+		email     string
+		expStatus int
+		expResult string
+		expError  error
+		mockUser  *models.User
+		mockErr   error
+	}{
+		"success with an input": {
+			email:     "john@example.com",
+			expStatus: http.StatusOK,
+			mockUser:  &models.User{ID: 100, Name: "john", Email: "john@example.com", CreatedAt: createdAt, UpdatedAt: createdAt},
+			expResult: `{"success":true,"user":{"created_at":"2021-12-01T10:00:00Z","email":"john@example.com","name":"john","updated_at":"2021-12-01T10:00:00Z"}}`,
+		},
+		"failed with an unknown email": {
+			email:     "test@example.com",
+			mockErr:   repository.ErrNotExistedUser,
+			expStatus: http.StatusNotFound,
+			expError:  errors.New(`{"code":"user_not_found","message":"test@example.com is not exists","success":false}`),
+		},
+		"failed with an invalid email": {
+			email:     "john",
+			expStatus: http.StatusUnprocessableEntity,
+			expError:  errors.New(`{"code":"invalid_email","message":"john invalid format (ex: \"andy@example.com\")","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v1/users/"+tc.email, nil)
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("GetUserByEmail", mock.Anything, tc.email).Return(tc.mockUser, tc.mockErr),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			router := chi.NewRouter()
+			router.Get("/v1/users/{email}", friendController.GetUser)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.expStatus, rr.Code)
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestControllers_UpdateUser(t *testing.T) {
+	createdAt := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2021, 12, 2, 10, 0, 0, 0, time.UTC)
+	tcs := map[string]struct {
+		email     string
+		input     string
+		expResult string
+		expError  error
+	}{
+		"success with an input": {
+			email:     "john@example.com",
+			input:     `{"name": "Johnny"}`,
+			expResult: `{"success":true,"user":{"created_at":"2021-12-01T10:00:00Z","email":"john@example.com","name":"Johnny","updated_at":"2021-12-02T10:00:00Z"}}`,
+		},
+		"failed with an empty name": {
+			email:    "john@example.com",
+			input:    `{}`,
+			expError: errors.New(`{"code":"invalid_name","message":"Name field must be between 1 and 100 characters","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("PATCH", "/v1/users/"+tc.email, bytes.NewBuffer([]byte(tc.input)))
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("GetUserIDByEmail", tc.email).Return(100, nil),
+				mockRepo.On("UpdateUserName", mock.Anything, 100, "Johnny").Return(nil),
+				mockRepo.On("GetUserByEmail", mock.Anything, tc.email).Return(&models.User{
+					ID: 100, Name: "Johnny", Email: "john@example.com", CreatedAt: createdAt, UpdatedAt: updatedAt,
+				}, nil),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			router := chi.NewRouter()
+			router.Patch("/v1/users/{email}", friendController.UpdateUser)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestControllers_DeleteUser(t *testing.T) {
+	tcs := map[string]struct {
+		email     string
+		expResult string
+		expError  error
+	}{
+		"success with an input": {
+			email:     "john@example.com",
+			expResult: `{"success":true}`,
+		},
+		"failed with an invalid email": {
+			email:    "john",
+			expError: errors.New(`{"code":"invalid_email","message":"john invalid format (ex: \"andy@example.com\")","success":false}`),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			req, err := http.NewRequest("DELETE", "/v1/users/"+tc.email, nil)
+			require.NoError(t, err)
+
+			var mockRepo SpecRepo
+			mockRepo.ExpectedCalls = []*mock.Call{
+				mockRepo.On("GetUserIDByEmail", tc.email).Return(100, nil),
+				mockRepo.On("DeleteUser", mock.Anything, 100).Return(nil),
+			}
+			friendController := NewFriendController(&mockRepo, repository.NewEmailNormalizer(true))
+			router := chi.NewRouter()
+			router.Delete("/v1/users/{email}", friendController.DeleteUser)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if tc.expError != nil {
+				require.EqualError(t, tc.expError, rr.Body.String())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, rr.Body.String())
+			}
+		})
+	}
+}
@@ -1,14 +1,49 @@
 package controllers
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"io"
+	"log"
 	"net/http"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/apperrors"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/go-chi/chi"
 )
 
 const EmailRegex = `[_A-Za-z0-9-\+]+(\.[_A-Za-z0-9-]+)*@[A-Za-z0-9-]+(\.[A-Za-z0-9]+)*(\.[A-Za-z]{2,})`
 
+// Max lengths of fields of users table
+const (
+	MaxNameLength  = 100
+	MaxEmailLength = 100
+)
+
+// Default and upper bound of number of friendships in a friend path
+const (
+	DefaultPathDepth = 4
+	MaxPathDepth     = 6
+)
+
+// Default and upper bound of number of items in a page of listings
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 500
+)
+
+// PageRequest is the cursor based pagination of a listing, After is the decoded key of the last item of previous page
+type PageRequest struct {
+	Limit int
+	After string
+}
+
 func isValidEmail(email string) (bool, error) {
 	isValid, err := regexp.MatchString(EmailRegex, email)
 	if err != nil || !isValid {
@@ -25,16 +60,27 @@ func (_self FriendRequest) Validate() error {
 	if len(_self.Emails) != 2 {
 		return ErrNumberOfEmail
 	}
-	if _self.Emails[0] == _self.Emails[1] {
+	if strings.EqualFold(_self.Emails[0], _self.Emails[1]) {
 		return ErrDifferentEmail
 	}
 	isValidUserEmail, err := isValidEmail(_self.Emails[0])
 	if !isValidUserEmail || err != nil {
-		return errors.New(_self.Emails[0] + " invalid format (ex: \"andy@example.com\")")
+		return errInvalidEmail(_self.Emails[0])
 	}
 	isValidFriendEmail, err := isValidEmail(_self.Emails[1])
 	if !isValidFriendEmail || err != nil {
-		return errors.New(_self.Emails[1] + " invalid format (ex: \"andy@example.com\")")
+		return errInvalidEmail(_self.Emails[1])
+	}
+	return nil
+}
+
+// Validate to body of friend path request
+func (_self FriendPathRequest) Validate() error {
+	if err := (FriendRequest{Emails: _self.Emails}).Validate(); err != nil {
+		return err
+	}
+	if _self.MaxDepth < 0 || _self.MaxDepth > MaxPathDepth {
+		return ErrMaxDepthInvalid
 	}
 	return nil
 }
@@ -46,7 +92,33 @@ func (_self UserRequest) Validate() error {
 	}
 	isValidEmail, err := isValidEmail(_self.Email)
 	if !isValidEmail || err != nil {
-		return errors.New(_self.Email + " invalid format (ex: \"andy@example.com\")")
+		return errInvalidEmail(_self.Email)
+	}
+	return nil
+}
+
+// Validate to body of create user request
+func (_self CreateUserRequest) Validate() error {
+	if _self.Name == "" && _self.Email == "" {
+		return ErrBodyRequestEmpty
+	}
+	if err := (UpdateUserRequest{Name: _self.Name}).Validate(); err != nil {
+		return err
+	}
+	if _self.Email == "" || len(_self.Email) > MaxEmailLength {
+		return ErrEmailFieldInvalid
+	}
+	isValidEmail, err := isValidEmail(_self.Email)
+	if !isValidEmail || err != nil {
+		return errInvalidEmail(_self.Email)
+	}
+	return nil
+}
+
+// Validate to body of update user request
+func (_self UpdateUserRequest) Validate() error {
+	if _self.Name == "" || len(_self.Name) > MaxNameLength {
+		return ErrNameFieldInvalid
 	}
 	return nil
 }
@@ -65,18 +137,18 @@ func (_self RequestorRequest) Validate() error {
 		return ErrTargetFieldInvalid
 	}
 
-	if _self.Target == _self.Requestor {
+	if strings.EqualFold(_self.Target, _self.Requestor) {
 		return ErrDifferentEmail
 	}
 
 	isValidRequestEmail, requestErr := isValidEmail(_self.Requestor)
 	if !isValidRequestEmail || requestErr != nil {
-		return errors.New(_self.Requestor + "invalid format (ex: \"andy@example.com\")")
+		return errInvalidEmail(_self.Requestor)
 	}
 
 	isValidTargetEmail, targetErr := isValidEmail(_self.Target)
 	if !isValidTargetEmail || targetErr != nil {
-		return errors.New(_self.Target + " invalid format (ex: \"andy@example.com\")")
+		return errInvalidEmail(_self.Target)
 	}
 	return nil
 }
@@ -96,19 +168,110 @@ func (_self RecipientsRequest) Validate() error {
 	isValidEmail, err := isValidEmail(_self.Sender)
 
 	if !isValidEmail || err != nil {
-		return errors.New(_self.Sender + " invalid format (ex: \"andy@example.com\")")
+		return errInvalidEmail(_self.Sender)
 	}
 	return nil
 }
 
-// Spit emails in a text
-func GetMentionedEmailFromText(text string) []string {
+// Decode JSON body of a read request which does not have its input in path or query parameters,
+// the body form is kept for backward compatibility and a missing body is an empty request
+func decodeReadBody(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return ErrBodyRequestInvalid
+	}
+	return nil
+}
+
+// Get the user of a read request from the email path parameter, the email query parameter or the JSON body in this order
+func (_self FriendController) getUserRequest(r *http.Request) (UserRequest, error) {
+	userReq := UserRequest{Email: chi.URLParam(r, "email")}
+	if userReq.Email == "" {
+		userReq.Email = r.URL.Query().Get("email")
+	}
+	if userReq.Email == "" {
+		if err := decodeReadBody(r, &userReq); err != nil {
+			return userReq, err
+		}
+	}
+	userReq.Email = _self.Emails.Normalize(userReq.Email)
+	return userReq, nil
+}
+
+// Get pagination of a listing from limit and after query parameters, after is next_cursor of the previous page
+func getPageRequest(r *http.Request) (PageRequest, error) {
+	page := PageRequest{Limit: DefaultPageLimit}
+	query := r.URL.Query()
+	if limit := query.Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > MaxPageLimit {
+			return page, ErrLimitInvalid
+		}
+		page.Limit = value
+	}
+	if after := query.Get("after"); after != "" {
+		key, err := base64.RawURLEncoding.DecodeString(after)
+		if err != nil || len(key) == 0 {
+			return page, ErrCursorInvalid
+		}
+		page.After = string(key)
+	}
+	return page, nil
+}
+
+// Encode key of the last item of a page to an opaque cursor
+func encodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// Get id of the last user of previous page, it is 0 for the first page
+func (_self PageRequest) AfterID() (int, error) {
+	if _self.After == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(_self.After)
+	if err != nil || id < 1 {
+		return 0, ErrCursorInvalid
+	}
+	return id, nil
+}
+
+// Get cursor of a page of users ordered by id from id of the last user, the cursor is empty for the last page
+func idCursor(lastId int) string {
+	if lastId == 0 {
+		return ""
+	}
+	return encodeCursor(strconv.Itoa(lastId))
+}
+
+// Get a page of recipients and mentioned emails ordered by email, recipients are the emails after page.After
+// of the repository which has one more email than the limit when there is a next page
+func paginateRecipients(recipients []string, mentionedEmails []string, page PageRequest) ([]string, string) {
+	emails := append([]string{}, recipients...)
+	isAdded := make(map[string]bool)
+	for _, email := range recipients {
+		isAdded[strings.ToLower(email)] = true
+	}
+	for _, email := range mentionedEmails {
+		if email > page.After && !isAdded[strings.ToLower(email)] {
+			emails = append(emails, email)
+			isAdded[strings.ToLower(email)] = true
+		}
+	}
+	sort.Strings(emails)
+	if len(emails) <= page.Limit {
+		return emails, ""
+	}
+	return emails[:page.Limit], encodeCursor(emails[page.Limit-1])
+}
+
+// Spit normalized emails in a text
+func GetMentionedEmailFromText(text string, emails repository.EmailNormalizer) []string {
 	regex := regexp.MustCompile(EmailRegex)
 
 	emailChain := regex.FindAllString(text, -1)
 	email := make([]string, len(emailChain))
 	for index, emailCharacter := range emailChain {
-		email[index] = emailCharacter
+		email[index] = emails.Normalize(emailCharacter)
 	}
 	return email
 }
@@ -118,30 +281,83 @@ func MsgOK() map[string]interface{} {
 }
 
 func MsgError(err error) map[string]interface{} {
-	return map[string]interface{}{"message": err.Error(), "success": false}
+	return map[string]interface{}{"code": apperrors.CodeOf(err), "message": err.Error(), "success": false}
 }
 
 func Message(status bool, msg string) map[string]interface{} {
 	return map[string]interface{}{"message": msg, "success": status}
 }
 
-func MsgGetFriendsOk(friends []string, count int) interface{} {
-	return map[string]interface{}{"count": count, "friends": friends, "success": true}
+// Get next_cursor of a page, it is null for the last page
+func nextCursorValue(nextCursor string) interface{} {
+	if nextCursor == "" {
+		return nil
+	}
+	return nextCursor
+}
+
+func MsgGetFriendsOk(friends []string, count int, nextCursor string) interface{} {
+	return map[string]interface{}{"count": count, "friends": friends, "next_cursor": nextCursorValue(nextCursor), "success": true}
+}
+
+func MsgGetEmailReceiversOk(emails []string, nextCursor string) interface{} {
+	return map[string]interface{}{"next_cursor": nextCursorValue(nextCursor), "recipients": emails, "success": true}
+}
+
+func MsgGetFriendRequestsOk(emails []string, count int) interface{} {
+	return map[string]interface{}{"count": count, "requests": emails, "success": true}
+}
+
+func MsgGetSuggestionsOk(suggestions interface{}, count int) interface{} {
+	return map[string]interface{}{"count": count, "suggestions": suggestions, "success": true}
+}
+
+func MsgGetFriendPathOk(path []string) interface{} {
+	return map[string]interface{}{"degree": len(path) - 1, "path": path, "success": true}
 }
 
-func MsgGetEmailReceiversOk(emails []string) interface{} {
-	return map[string]interface{}{"recipients": emails, "success": true}
+func MsgGetUserOk(user *models.User) interface{} {
+	return map[string]interface{}{
+		"success": true,
+		"user": map[string]interface{}{
+			"name":       user.Name,
+			"email":      user.Email,
+			"created_at": user.CreatedAt,
+			"updated_at": user.UpdatedAt,
+		},
+	}
 }
 
-func MsgGetAllUsersOk(users []string, count int) interface{} {
-	return map[string]interface{}{"count": count, "users": users, "success": true}
+func MsgGetAllUsersOk(users []string, count int, nextCursor string) interface{} {
+	return map[string]interface{}{"count": count, "next_cursor": nextCursorValue(nextCursor), "users": users, "success": true}
+}
+
+// Respond an error with HTTP status code of the error and the id of the request, errors of a done request context
+// are timeouts. Server errors are logged with the request id, errors which are not domain errors are responded
+// with a generic message, so details such as driver errors are not sent to clients
+func RespondError(w http.ResponseWriter, r *http.Request, err error) {
+	if apperrors.CodeOf(err) == apperrors.CodeInternal && r.Context().Err() != nil {
+		err = r.Context().Err()
+	}
+	err = errContext(err)
+
+	id := RequestIDFrom(r.Context())
+	if apperrors.StatusOf(err) == http.StatusInternalServerError {
+		log.Printf("request_id=%s %s %s failed: %v", id, r.Method, r.URL.Path, err)
+	}
+	if apperrors.CodeOf(err) == apperrors.CodeInternal {
+		err = ErrInternal
+	}
+	payload := MsgError(err)
+	if id != "" {
+		payload["request_id"] = id
+	}
+	Respond(w, apperrors.StatusOf(err), payload)
 }
 
 func Respond(w http.ResponseWriter, statusCode int, payload interface{}) {
 	response, _ := json.Marshal(payload)
 	w.Header().Add("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
 	w.WriteHeader(statusCode)
 	w.Write(response)
 }
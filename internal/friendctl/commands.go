@@ -0,0 +1,456 @@
+package friendctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/apperrors"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/auth"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/controllers"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+)
+
+// Number of rows which are read by a query while listing all users or friends
+const pageSize = controllers.MaxPageLimit
+
+// Maximum length of names of API keys, it is the length of the name column
+const maxAPIKeyName = 100
+
+var (
+	ErrUnknownCommand    = apperrors.BadRequest("unknown_command", "Unknown command, run `friendctl help` to list commands")
+	ErrInvalidAPIKeyName = apperrors.Unprocessable("invalid_api_key_name", "The name of an API key must have 1 to %d characters", maxAPIKeyName)
+)
+
+// Usage lists commands with their arguments
+const Usage = `usage: friendctl [-format table|json] <command> [arguments]
+
+commands:
+  users                               list all users
+  create-user <name> <email>          create a user
+  delete-user <email>                 delete a user with all of the relationships
+  friend <email> <email>              create a friendship
+  unfriend <email> <email>            delete a friendship
+  subscribe <requestor> <target>      subscribe requestor to updates of target
+  unsubscribe <requestor> <target>    delete a subscription
+  block <requestor> <target>          block updates of target to requestor
+  unblock <requestor> <target>        delete a blocking relationship
+  friends <email>                     list friends of a user
+  common-friends <email> <email>      list common friends of users
+  recipients <sender> [text]          list recipients of an update of sender, mentioned emails of text are included
+  api-keys                            list API keys of clients of the API
+  create-api-key <name>               create an API key, the key is only printed once
+  revoke-api-key <name>               revoke an API key`
+
+// Result is the output of a command, it is printed as a table or JSON
+type Result struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Result of a command which changes data
+func resultOK() Result {
+	return Result{Header: []string{"result"}, Rows: [][]string{{"ok"}}}
+}
+
+// Result of a list of emails
+func resultEmails(emails []string) Result {
+	rows := make([][]string, len(emails))
+	for i, email := range emails {
+		rows[i] = []string{email}
+	}
+	return Result{Header: []string{"email"}, Rows: rows}
+}
+
+// Commands manages users and relationships through the repository with the same validation and checks as the API
+type Commands struct {
+	Repo    repository.SpecRepo
+	APIKeys repository.APIKeyStore
+	Emails  repository.EmailNormalizer
+}
+
+func NewCommands(repo repository.SpecRepo, apiKeys repository.APIKeyStore, emails repository.EmailNormalizer) Commands {
+	return Commands{
+		Repo:    repo,
+		APIKeys: apiKeys,
+		Emails:  emails,
+	}
+}
+
+// Run a command with its arguments
+func (_self Commands) Run(ctx context.Context, args []string) (Result, error) {
+	if len(args) == 0 {
+		return Result{}, ErrUnknownCommand
+	}
+
+	command, args := args[0], args[1:]
+	switch {
+	case command == "users" && len(args) == 0:
+		return _self.users(ctx)
+	case command == "create-user" && len(args) == 2:
+		return _self.createUser(ctx, args[0], args[1])
+	case command == "delete-user" && len(args) == 1:
+		return _self.deleteUser(ctx, args[0])
+	case command == "friend" && len(args) == 2:
+		return _self.friend(ctx, args[0], args[1])
+	case command == "unfriend" && len(args) == 2:
+		return _self.unfriend(ctx, args[0], args[1])
+	case command == "subscribe" && len(args) == 2:
+		return _self.subscribe(ctx, args[0], args[1])
+	case command == "unsubscribe" && len(args) == 2:
+		return _self.unsubscribe(ctx, args[0], args[1])
+	case command == "block" && len(args) == 2:
+		return _self.block(ctx, args[0], args[1])
+	case command == "unblock" && len(args) == 2:
+		return _self.unblock(ctx, args[0], args[1])
+	case command == "friends" && len(args) == 1:
+		return _self.friends(ctx, args[0])
+	case command == "common-friends" && len(args) == 2:
+		return _self.commonFriends(ctx, args[0], args[1])
+	case command == "recipients" && (len(args) == 1 || len(args) == 2):
+		return _self.recipients(ctx, args[0], strings.Join(args[1:], ""))
+	case command == "api-keys" && len(args) == 0:
+		return _self.apiKeys(ctx)
+	case command == "create-api-key" && len(args) == 1:
+		return _self.createAPIKey(ctx, args[0])
+	case command == "revoke-api-key" && len(args) == 1:
+		return _self.revokeAPIKey(ctx, args[0])
+	}
+	return Result{}, ErrUnknownCommand
+}
+
+// Get user ids of a pair of emails which are validated as the requests of the API
+func (_self Commands) getUserIDs(ctx context.Context, firstEmail string, secondEmail string) (int, int, error) {
+	emails := []string{_self.Emails.Normalize(firstEmail), _self.Emails.Normalize(secondEmail)}
+	if err := (controllers.FriendRequest{Emails: emails}).Validate(); err != nil {
+		return 0, 0, err
+	}
+	firstId, err := _self.getUserID(ctx, emails[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	secondId, err := _self.getUserID(ctx, emails[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return firstId, secondId, nil
+}
+
+// Get a user id by email, the email is kept in the error of a not existing user
+func (_self Commands) getUserID(ctx context.Context, email string) (int, error) {
+	userId, err := _self.Repo.GetUserIDByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotExistedUser) {
+		return 0, apperrors.NotFound(repository.ErrNotExistedUser.Code, "%s is not exists", email)
+	}
+	return userId, err
+}
+
+// List all users page by page
+func (_self Commands) users(ctx context.Context) (Result, error) {
+	result := Result{Header: []string{"id", "name", "email"}, Rows: [][]string{}}
+	for afterId := 0; ; {
+		users, err := _self.Repo.GetUsers(ctx, afterId, pageSize)
+		if err != nil {
+			return Result{}, err
+		}
+		for _, user := range users {
+			result.Rows = append(result.Rows, []string{strconv.Itoa(user.ID), user.Name, user.Email})
+			afterId = user.ID
+		}
+		if len(users) < pageSize {
+			return result, nil
+		}
+	}
+}
+
+func (_self Commands) createUser(ctx context.Context, name string, email string) (Result, error) {
+	userReq := controllers.CreateUserRequest{Name: strings.TrimSpace(name), Email: _self.Emails.Normalize(email)}
+	if err := userReq.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	user, err := _self.Repo.CreateUser(ctx, userReq.Name, userReq.Email)
+	if errors.Is(err, repository.ErrExistedUser) {
+		return Result{}, apperrors.Conflict(repository.ErrExistedUser.Code, "%s has been existed", userReq.Email)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Header: []string{"id", "name", "email"},
+		Rows:   [][]string{{strconv.Itoa(user.ID), user.Name, user.Email}},
+	}, nil
+}
+
+func (_self Commands) deleteUser(ctx context.Context, email string) (Result, error) {
+	userReq := controllers.UserRequest{Email: _self.Emails.Normalize(email)}
+	if err := userReq.Validate(); err != nil {
+		return Result{}, err
+	}
+	userId, err := _self.getUserID(ctx, userReq.Email)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := _self.Repo.DeleteUser(ctx, userId); err != nil {
+		return Result{}, err
+	}
+	return resultOK(), nil
+}
+
+// Create a friendship directly while both users are locked, users who have blocked each other cannot be friends.
+// Unlike the API, no friend request is needed
+func (_self Commands) friend(ctx context.Context, email string, friendEmail string) (Result, error) {
+	userId, friendId, err := _self.getUserIDs(ctx, email, friendEmail)
+	if err != nil {
+		return Result{}, err
+	}
+
+	err = _self.Repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+		if err := repo.LockUsers(ctx, userId, friendId); err != nil {
+			return err
+		}
+		isBlocked, err := repo.IsBlockedUser(ctx, userId, friendId)
+		if err != nil {
+			return err
+		}
+		if isBlocked {
+			return repository.ErrExistedBlockedUser
+		}
+
+		// A pending friend request between the users is accepted, so it is not left pending for a friendship
+		for _, pair := range [][2]int{{userId, friendId}, {friendId, userId}} {
+			friendRequest, err := repo.GetPendingFriendRequest(ctx, pair[0], pair[1])
+			if err == nil {
+				return repo.AcceptFriendRequest(ctx, friendRequest.ID)
+			}
+			if !errors.Is(err, repository.ErrNotExistedFriendRequest) {
+				return err
+			}
+		}
+		return repo.CreateFriend(ctx, userId, friendId)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return resultOK(), nil
+}
+
+func (_self Commands) unfriend(ctx context.Context, email string, friendEmail string) (Result, error) {
+	userId, friendId, err := _self.getUserIDs(ctx, email, friendEmail)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := _self.Repo.DeleteFriend(ctx, userId, friendId); err != nil {
+		return Result{}, err
+	}
+	return resultOK(), nil
+}
+
+// Create a subscription while both users are locked, users who have blocked each other cannot subscribe
+func (_self Commands) subscribe(ctx context.Context, requestor string, target string) (Result, error) {
+	requestorId, targetId, err := _self.getUserIDs(ctx, requestor, target)
+	if err != nil {
+		return Result{}, err
+	}
+
+	err = _self.Repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+		if err := repo.LockUsers(ctx, requestorId, targetId); err != nil {
+			return err
+		}
+		isBlocked, err := repo.IsBlockedUser(ctx, requestorId, targetId)
+		if err != nil {
+			return err
+		}
+		if isBlocked {
+			return repository.ErrExistedBlockedUser
+		}
+		return repo.CreateSubscription(ctx, requestorId, targetId)
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return resultOK(), nil
+}
+
+func (_self Commands) unsubscribe(ctx context.Context, requestor string, target string) (Result, error) {
+	requestorId, targetId, err := _self.getUserIDs(ctx, requestor, target)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := _self.Repo.DeleteSubscription(ctx, requestorId, targetId); err != nil {
+		return Result{}, err
+	}
+	return resultOK(), nil
+}
+
+func (_self Commands) block(ctx context.Context, requestor string, target string) (Result, error) {
+	requestorId, targetId, err := _self.getUserIDs(ctx, requestor, target)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := _self.Repo.CreateUserBlock(ctx, requestorId, targetId); err != nil {
+		return Result{}, err
+	}
+	return resultOK(), nil
+}
+
+func (_self Commands) unblock(ctx context.Context, requestor string, target string) (Result, error) {
+	requestorId, targetId, err := _self.getUserIDs(ctx, requestor, target)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := _self.Repo.DeleteUserBlock(ctx, requestorId, targetId); err != nil {
+		return Result{}, err
+	}
+	return resultOK(), nil
+}
+
+// List all unblocked friends of a user page by page
+func (_self Commands) friends(ctx context.Context, email string) (Result, error) {
+	userReq := controllers.UserRequest{Email: _self.Emails.Normalize(email)}
+	if err := userReq.Validate(); err != nil {
+		return Result{}, err
+	}
+	userId, err := _self.getUserID(ctx, userReq.Email)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return listFriendPages(func(afterId int) (repository.FriendPage, error) {
+		return _self.Repo.GetUnblockedFriendEmails(ctx, userId, afterId, pageSize)
+	})
+}
+
+// List all common friends of users page by page
+func (_self Commands) commonFriends(ctx context.Context, email string, otherEmail string) (Result, error) {
+	userId, otherId, err := _self.getUserIDs(ctx, email, otherEmail)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return listFriendPages(func(afterId int) (repository.FriendPage, error) {
+		return _self.Repo.GetCommonFriendEmails(ctx, userId, otherId, afterId, pageSize)
+	})
+}
+
+// Get emails of all pages of friends
+func listFriendPages(getPage func(afterId int) (repository.FriendPage, error)) (Result, error) {
+	emails := []string{}
+	for afterId := 0; ; {
+		page, err := getPage(afterId)
+		if err != nil {
+			return Result{}, err
+		}
+		emails = append(emails, page.Emails...)
+		if page.NextAfterID == 0 {
+			return resultEmails(emails), nil
+		}
+		afterId = page.NextAfterID
+	}
+}
+
+// List friends and subscribers of sender without blocking relationships with sender, and emails mentioned in text.
+// The text is optional unlike the API
+func (_self Commands) recipients(ctx context.Context, sender string, text string) (Result, error) {
+	senderReq := controllers.UserRequest{Email: _self.Emails.Normalize(sender)}
+	if err := senderReq.Validate(); err != nil {
+		return Result{}, err
+	}
+	senderId, err := _self.getUserID(ctx, senderReq.Email)
+	if err != nil {
+		return Result{}, err
+	}
+
+	isAdded := make(map[string]bool)
+	emails := []string{}
+	for afterEmail := ""; ; {
+		recipients, err := _self.Repo.GetRecipientEmails(ctx, senderId, afterEmail, pageSize)
+		if err != nil {
+			return Result{}, err
+		}
+		for _, email := range recipients {
+			emails = append(emails, email)
+			isAdded[email] = true
+		}
+		if len(recipients) < pageSize {
+			break
+		}
+		afterEmail = recipients[len(recipients)-1]
+	}
+	for _, email := range controllers.GetMentionedEmailFromText(text, _self.Emails) {
+		if !isAdded[email] {
+			emails = append(emails, email)
+			isAdded[email] = true
+		}
+	}
+	sort.Strings(emails)
+	return resultEmails(emails), nil
+}
+
+// List API keys with their status, keys themselves are not stored
+func (_self Commands) apiKeys(ctx context.Context) (Result, error) {
+	apiKeys, err := _self.APIKeys.GetAPIKeys(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Header: []string{"id", "name", "created_at", "revoked_at"}, Rows: [][]string{}}
+	for _, apiKey := range apiKeys {
+		revokedAt := ""
+		if apiKey.RevokedAt.Valid {
+			revokedAt = apiKey.RevokedAt.Time.UTC().Format(time.RFC3339)
+		}
+		result.Rows = append(result.Rows, []string{
+			strconv.Itoa(apiKey.ID), apiKey.Name, apiKey.CreatedAt.UTC().Format(time.RFC3339), revokedAt,
+		})
+	}
+	return result, nil
+}
+
+// Create an API key with a unique name, only the hash of the key is stored so the key is printed once
+func (_self Commands) createAPIKey(ctx context.Context, name string) (Result, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyName {
+		return Result{}, ErrInvalidAPIKeyName
+	}
+	key, err := auth.GenerateAPIKey()
+	if err != nil {
+		return Result{}, err
+	}
+
+	apiKey, err := _self.APIKeys.CreateAPIKey(ctx, name, auth.HashAPIKey(key))
+	if errors.Is(err, repository.ErrExistedAPIKey) {
+		return Result{}, apperrors.Conflict(repository.ErrExistedAPIKey.Code, "API key %s has been existed", name)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		Header: []string{"id", "name", "key"},
+		Rows:   [][]string{{strconv.Itoa(apiKey.ID), apiKey.Name, key}},
+	}, nil
+}
+
+func (_self Commands) revokeAPIKey(ctx context.Context, name string) (Result, error) {
+	if err := _self.APIKeys.RevokeAPIKey(ctx, strings.TrimSpace(name)); err != nil {
+		return Result{}, err
+	}
+	return resultOK(), nil
+}
+
+// Get the error message of a failed command, codes of application errors are kept for scripts
+func ErrorMessage(err error) string {
+	if code := apperrors.CodeOf(err); code != apperrors.CodeInternal {
+		return fmt.Sprintf("%s (%s)", err.Error(), code)
+	}
+	return err.Error()
+}
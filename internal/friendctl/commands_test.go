@@ -0,0 +1,198 @@
+package friendctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/auth"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCommands will be creating commands of an in-memory repository with john, andy, common and lisa,
+// john and andy are friends of common, lisa has blocked john and subscribed to john
+func newTestCommands(t *testing.T) Commands {
+	ctx := context.Background()
+	repo := repository.NewMemoryRepo()
+	commands := NewCommands(repo, repo, repository.NewEmailNormalizer(true))
+	for _, args := range [][]string{
+		{"create-user", "john", "john@example.com"},
+		{"create-user", "andy", "andy@example.com"},
+		{"create-user", "common", "common@example.com"},
+		{"create-user", "lisa", "lisa@example.com"},
+		{"friend", "john@example.com", "common@example.com"},
+		{"friend", "andy@example.com", "common@example.com"},
+		{"subscribe", "lisa@example.com", "john@example.com"},
+		{"block", "lisa@example.com", "john@example.com"},
+	} {
+		_, err := commands.Run(ctx, args)
+		require.NoError(t, err)
+	}
+	return commands
+}
+
+func TestFriendctl_Run(t *testing.T) {
+	tcs := map[string]struct {
+		args      []string
+		expResult Result
+		expError  error
+	}{
+		"success with listing users": {
+			args: []string{"users"},
+			expResult: Result{Header: []string{"id", "name", "email"}, Rows: [][]string{
+				{"1", "john", "john@example.com"},
+				{"2", "andy", "andy@example.com"},
+				{"3", "common", "common@example.com"},
+				{"4", "lisa", "lisa@example.com"},
+			}},
+		},
+		"success with creating a user with a normalized email": {
+			args:      []string{"create-user", " kate ", "kate@EXAMPLE.com"},
+			expResult: Result{Header: []string{"id", "name", "email"}, Rows: [][]string{{"5", "kate", "kate@example.com"}}},
+		},
+		"success with creating a friendship": {
+			args:      []string{"friend", "john@example.com", "andy@example.com"},
+			expResult: resultOK(),
+		},
+		"success with listing friends": {
+			args:      []string{"friends", "common@example.com"},
+			expResult: resultEmails([]string{"john@example.com", "andy@example.com"}),
+		},
+		"success with listing common friends": {
+			args:      []string{"common-friends", "john@example.com", "andy@example.com"},
+			expResult: resultEmails([]string{"common@example.com"}),
+		},
+		"success with listing recipients with mentioned emails": {
+			args:      []string{"recipients", "common@example.com", "hello kate@example.com"},
+			expResult: resultEmails([]string{"andy@example.com", "john@example.com", "kate@example.com"}),
+		},
+		"success with listing recipients without blocked users": {
+			args:      []string{"recipients", "john@example.com"},
+			expResult: resultEmails([]string{"common@example.com"}),
+		},
+		"success with unblocking users": {
+			args:      []string{"unblock", "lisa@example.com", "john@example.com"},
+			expResult: resultOK(),
+		},
+		"success with deleting a user": {
+			args:      []string{"delete-user", "lisa@example.com"},
+			expResult: resultOK(),
+		},
+		"failed with creating a friendship of blocked users": {
+			args:     []string{"friend", "john@example.com", "lisa@example.com"},
+			expError: repository.ErrExistedBlockedUser,
+		},
+		"failed with creating an existing user": {
+			args:     []string{"create-user", "john", "John@example.com"},
+			expError: errors.New("john@example.com has been existed"),
+		},
+		"failed with an unknown user": {
+			args:     []string{"unfriend", "john@example.com", "kate@example.com"},
+			expError: errors.New("kate@example.com is not exists"),
+		},
+		"failed with an invalid email": {
+			args:     []string{"friends", "john"},
+			expError: errors.New("john invalid format (ex: \"andy@example.com\")"),
+		},
+		"failed with the same emails": {
+			args:     []string{"subscribe", "john@example.com", "john@example.com"},
+			expError: errors.New("Two email addresses must be different"),
+		},
+		"failed with a not existing friendship": {
+			args:     []string{"unfriend", "john@example.com", "andy@example.com"},
+			expError: repository.ErrNotExistedFriendship,
+		},
+		"failed with missing arguments": {
+			args:     []string{"friend", "john@example.com"},
+			expError: ErrUnknownCommand,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			commands := newTestCommands(t)
+
+			result, err := commands.Run(context.Background(), tc.args)
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expResult, result)
+		})
+	}
+}
+
+func TestFriendctl_FriendWithPendingFriendRequest(t *testing.T) {
+	ctx := context.Background()
+	commands := newTestCommands(t)
+	andyId, err := commands.Repo.GetUserIDByEmail(ctx, "andy@example.com")
+	require.NoError(t, err)
+	lisaId, err := commands.Repo.GetUserIDByEmail(ctx, "lisa@example.com")
+	require.NoError(t, err)
+	require.NoError(t, commands.Repo.CreateFriendRequest(ctx, lisaId, andyId))
+
+	result, err := commands.Run(ctx, []string{"friend", "andy@example.com", "lisa@example.com"})
+	require.NoError(t, err)
+	require.Equal(t, resultOK(), result)
+
+	// The request has been accepted instead of being left pending
+	isPending, err := commands.Repo.IsPendingFriendRequest(ctx, andyId, lisaId)
+	require.NoError(t, err)
+	require.False(t, isPending)
+	isExisted, err := commands.Repo.IsExistedFriend(ctx, andyId, lisaId)
+	require.NoError(t, err)
+	require.True(t, isExisted)
+}
+
+func TestFriendctl_APIKeys(t *testing.T) {
+	ctx := context.Background()
+	commands := newTestCommands(t)
+
+	result, err := commands.Run(ctx, []string{"create-api-key", "ci"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"id", "name", "key"}, result.Header)
+	require.Len(t, result.Rows, 1)
+	require.Equal(t, []string{"1", "ci"}, result.Rows[0][:2])
+	key := result.Rows[0][2]
+	require.Regexp(t, `^fm_[0-9a-f]{64}$`, key)
+
+	// Only the hash of the key is stored
+	apiKey, err := commands.APIKeys.GetAPIKeyByHash(ctx, auth.HashAPIKey(key))
+	require.NoError(t, err)
+	require.Equal(t, "ci", apiKey.Name)
+
+	_, err = commands.Run(ctx, []string{"create-api-key", "ci"})
+	require.EqualError(t, err, "API key ci has been existed")
+	_, err = commands.Run(ctx, []string{"create-api-key", " "})
+	require.Equal(t, ErrInvalidAPIKeyName, err)
+
+	result, err = commands.Run(ctx, []string{"revoke-api-key", "ci"})
+	require.NoError(t, err)
+	require.Equal(t, resultOK(), result)
+	_, err = commands.Run(ctx, []string{"revoke-api-key", "ci"})
+	require.Equal(t, repository.ErrNotExistedAPIKey, err)
+
+	result, err = commands.Run(ctx, []string{"api-keys"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"id", "name", "created_at", "revoked_at"}, result.Header)
+	require.Len(t, result.Rows, 1)
+	require.Equal(t, "ci", result.Rows[0][1])
+	require.NotEmpty(t, result.Rows[0][3])
+}
+
+func TestFriendctl_Write(t *testing.T) {
+	result := Result{Header: []string{"id", "email"}, Rows: [][]string{{"1", "john@example.com"}, {"20", "andy@example.com"}}}
+
+	var table bytes.Buffer
+	require.NoError(t, result.Write(&table, FormatTable))
+	require.Equal(t, "ID  EMAIL\n1   john@example.com\n20  andy@example.com\n", table.String())
+
+	var jsonOutput bytes.Buffer
+	require.NoError(t, result.Write(&jsonOutput, FormatJSON))
+	require.JSONEq(t, `[{"id":"1","email":"john@example.com"},{"id":"20","email":"andy@example.com"}]`, jsonOutput.String())
+
+	require.EqualError(t, result.Write(&table, "xml"), "unknown format \"xml\", it must be table or json")
+}
@@ -0,0 +1,49 @@
+package friendctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Formats of printed results
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// Write the result in a format, JSON is a list of objects which are keyed by the header
+func (_self Result) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatTable:
+		return _self.writeTable(w)
+	case FormatJSON:
+		return _self.writeJSON(w)
+	}
+	return fmt.Errorf("unknown format %q, it must be table or json", format)
+}
+
+func (_self Result) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(_self.Header, "\t")))
+	for _, row := range _self.Rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func (_self Result) writeJSON(w io.Writer) error {
+	objects := make([]map[string]string, len(_self.Rows))
+	for i, row := range _self.Rows {
+		objects[i] = make(map[string]string, len(_self.Header))
+		for j, column := range _self.Header {
+			objects[i][column] = row[j]
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(objects)
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "friendmanagement"
+
+// Metrics is the Prometheus collectors of the server, they are exposed by Handler
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	repoDuration  *prometheus.HistogramVec
+	repoErrors    *prometheus.CounterVec
+	friendships   prometheus.Counter
+	subscriptions prometheus.Counter
+	blocks        prometheus.Counter
+}
+
+// New creates the collectors in a registry with the Go runtime and process collectors
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by method, chi route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by method, chi route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_call_duration_seconds",
+			Help:      "Latency of calls of the repository by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		repoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "repository_errors_total",
+			Help:      "Number of failed calls of the repository by method and error code.",
+		}, []string{"method", "code"}),
+		friendships: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "friendships_created_total",
+			Help:      "Number of friendships which have been created.",
+		}),
+		subscriptions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "subscriptions_created_total",
+			Help:      "Number of subscriptions which have been created.",
+		}),
+		blocks: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "blocks_created_total",
+			Help:      "Number of blocks which have been created.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.repoDuration,
+		m.repoErrors,
+		m.friendships,
+		m.subscriptions,
+		m.blocks,
+	)
+	return m
+}
+
+// RegisterDB exposes the pool statistics of the database (sql.DBStats) as go_sql_* metrics
+func (_self *Metrics) RegisterDB(db *sql.DB, name string) {
+	_self.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler responds the metrics in the Prometheus text format
+func (_self *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(_self.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts requests and observes their latency by chi route pattern, so paths with
+// parameters such as /v1/users/{email} are one series. Requests which match no route are labeled "unmatched"
+func (_self *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		labels := prometheus.Labels{"method": r.Method, "route": route, "status": strconv.Itoa(status)}
+		_self.httpRequests.With(labels).Inc()
+		_self.httpDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
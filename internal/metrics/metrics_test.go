@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Middleware(t *testing.T) {
+	tcs := map[string]struct {
+		method   string
+		path     string
+		expRoute string
+		expCode  string
+	}{
+		"success with the route pattern of a path parameter": {
+			method:   "GET",
+			path:     "/v1/users/john@example.com",
+			expRoute: "/v1/users/{email}",
+			expCode:  "200",
+		},
+		"success with the status of an error response": {
+			method:   "POST",
+			path:     "/v1/friends",
+			expRoute: "/v1/friends",
+			expCode:  "400",
+		},
+		"success with a path which matches no route": {
+			method:   "GET",
+			path:     "/v2/users",
+			expRoute: "unmatched",
+			expCode:  "404",
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			metrics := New()
+			r := chi.NewRouter()
+			r.Use(metrics.Middleware)
+			r.Route("/v1", func(route chi.Router) {
+				route.Get("/users/{email}", func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte("{}"))
+				})
+				route.Post("/friends", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusBadRequest)
+				})
+			})
+
+			req, err := http.NewRequest(tc.method, tc.path, nil)
+			require.NoError(t, err)
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.Equal(t, float64(1), testutil.ToFloat64(metrics.httpRequests.WithLabelValues(tc.method, tc.expRoute, tc.expCode)))
+			require.Equal(t, 1, testutil.CollectAndCount(metrics.httpDuration))
+		})
+	}
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	metrics := New()
+	metrics.friendships.Inc()
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+
+	metrics.Handler().ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	require.True(t, strings.Contains(body, "friendmanagement_friendships_created_total 1"), body)
+	require.True(t, strings.Contains(body, "go_goroutines"), body)
+}
@@ -0,0 +1,250 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/apperrors"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Repo is a repository which observes latency and errors of each method of the repository it decorates
+// and counts friendships, subscriptions and blocks which have been created
+type Repo struct {
+	repo    repository.SpecRepo
+	metrics *Metrics
+	// Counters of the current unit of work, they are incremented when its transaction is committed
+	pending *[]prometheus.Counter
+}
+
+func NewRepo(repo repository.SpecRepo, metrics *Metrics) Repo {
+	return Repo{
+		repo:    repo,
+		metrics: metrics,
+	}
+}
+
+// Observe the latency of a call of method and its error by code, errors are counted whatever their code
+// so expected failures such as user_not_found are visible too
+func (_self Repo) observe(method string, start time.Time, err *error) {
+	_self.metrics.repoDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if *err != nil {
+		_self.metrics.repoErrors.WithLabelValues(method, apperrors.CodeOf(*err)).Inc()
+	}
+}
+
+// Count a created relationship, in a unit of work it is counted after the transaction is committed
+func (_self Repo) created(counter prometheus.Counter) {
+	if _self.pending != nil {
+		*_self.pending = append(*_self.pending, counter)
+		return
+	}
+	counter.Inc()
+}
+
+// Run a unit of work with a decorated repository of the transaction
+func (_self Repo) WithTx(ctx context.Context, fn func(repo repository.SpecRepo) error) (err error) {
+	defer _self.observe("WithTx", time.Now(), &err)
+	if _self.pending != nil {
+		return _self.repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+			return fn(Repo{repo: repo, metrics: _self.metrics, pending: _self.pending})
+		})
+	}
+
+	pending := []prometheus.Counter{}
+	err = _self.repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+		return fn(Repo{repo: repo, metrics: _self.metrics, pending: &pending})
+	})
+	if err == nil {
+		for _, counter := range pending {
+			counter.Inc()
+		}
+	}
+	return err
+}
+
+func (_self Repo) LockUsers(ctx context.Context, userIds ...int) (err error) {
+	defer _self.observe("LockUsers", time.Now(), &err)
+	return _self.repo.LockUsers(ctx, userIds...)
+}
+
+func (_self Repo) CreateFriend(ctx context.Context, userId int, friendId int) (err error) {
+	defer _self.observe("CreateFriend", time.Now(), &err)
+	if err = _self.repo.CreateFriend(ctx, userId, friendId); err == nil {
+		_self.created(_self.metrics.friendships)
+	}
+	return err
+}
+
+func (_self Repo) DeleteFriend(ctx context.Context, userId int, friendId int) (err error) {
+	defer _self.observe("DeleteFriend", time.Now(), &err)
+	return _self.repo.DeleteFriend(ctx, userId, friendId)
+}
+
+func (_self Repo) GetFriendsByID(ctx context.Context, userId int) (result models.FriendSlice, err error) {
+	defer _self.observe("GetFriendsByID", time.Now(), &err)
+	return _self.repo.GetFriendsByID(ctx, userId)
+}
+
+func (_self Repo) GetUnblockedFriendsByIDs(ctx context.Context, userIds []int) (result models.FriendSlice, err error) {
+	defer _self.observe("GetUnblockedFriendsByIDs", time.Now(), &err)
+	return _self.repo.GetUnblockedFriendsByIDs(ctx, userIds)
+}
+
+func (_self Repo) GetUnblockedFriendEmails(ctx context.Context, userId int, afterId int, limit int) (result repository.FriendPage, err error) {
+	defer _self.observe("GetUnblockedFriendEmails", time.Now(), &err)
+	return _self.repo.GetUnblockedFriendEmails(ctx, userId, afterId, limit)
+}
+
+func (_self Repo) GetCommonFriendEmails(ctx context.Context, userId int, otherId int, afterId int, limit int) (result repository.FriendPage, err error) {
+	defer _self.observe("GetCommonFriendEmails", time.Now(), &err)
+	return _self.repo.GetCommonFriendEmails(ctx, userId, otherId, afterId, limit)
+}
+
+func (_self Repo) GetUserBlocksByID(ctx context.Context, userId int) (result models.UserBlockSlice, err error) {
+	defer _self.observe("GetUserBlocksByID", time.Now(), &err)
+	return _self.repo.GetUserBlocksByID(ctx, userId)
+}
+
+func (_self Repo) CreateSubscription(ctx context.Context, requestorId int, targetId int) (err error) {
+	defer _self.observe("CreateSubscription", time.Now(), &err)
+	if err = _self.repo.CreateSubscription(ctx, requestorId, targetId); err == nil {
+		_self.created(_self.metrics.subscriptions)
+	}
+	return err
+}
+
+func (_self Repo) DeleteSubscription(ctx context.Context, requestorId int, targetId int) (err error) {
+	defer _self.observe("DeleteSubscription", time.Now(), &err)
+	return _self.repo.DeleteSubscription(ctx, requestorId, targetId)
+}
+
+func (_self Repo) GetRecipientEmails(ctx context.Context, senderId int, afterEmail string, limit int) (result []string, err error) {
+	defer _self.observe("GetRecipientEmails", time.Now(), &err)
+	return _self.repo.GetRecipientEmails(ctx, senderId, afterEmail, limit)
+}
+
+func (_self Repo) CreateUserBlock(ctx context.Context, requestorId int, targetId int) (err error) {
+	defer _self.observe("CreateUserBlock", time.Now(), &err)
+	if err = _self.repo.CreateUserBlock(ctx, requestorId, targetId); err == nil {
+		_self.created(_self.metrics.blocks)
+	}
+	return err
+}
+
+func (_self Repo) DeleteUserBlock(ctx context.Context, requestorId int, targetId int) (err error) {
+	defer _self.observe("DeleteUserBlock", time.Now(), &err)
+	return _self.repo.DeleteUserBlock(ctx, requestorId, targetId)
+}
+
+func (_self Repo) IsExistedFriend(ctx context.Context, userId int, friendId int) (result bool, err error) {
+	defer _self.observe("IsExistedFriend", time.Now(), &err)
+	return _self.repo.IsExistedFriend(ctx, userId, friendId)
+}
+
+func (_self Repo) IsBlockedUser(ctx context.Context, userId int, friendId int) (result bool, err error) {
+	defer _self.observe("IsBlockedUser", time.Now(), &err)
+	return _self.repo.IsBlockedUser(ctx, userId, friendId)
+}
+
+func (_self Repo) IsSubscribedUser(ctx context.Context, requestorId int, targetId int) (result bool, err error) {
+	defer _self.observe("IsSubscribedUser", time.Now(), &err)
+	return _self.repo.IsSubscribedUser(ctx, requestorId, targetId)
+}
+
+func (_self Repo) GetUserIDByEmail(ctx context.Context, email string) (result int, err error) {
+	defer _self.observe("GetUserIDByEmail", time.Now(), &err)
+	return _self.repo.GetUserIDByEmail(ctx, email)
+}
+
+func (_self Repo) GetEmailsByUserIDs(ctx context.Context, userIDs []int) (result []string, err error) {
+	defer _self.observe("GetEmailsByUserIDs", time.Now(), &err)
+	return _self.repo.GetEmailsByUserIDs(ctx, userIDs)
+}
+
+func (_self Repo) GetUsersByIDs(ctx context.Context, userIDs []int) (result models.UserSlice, err error) {
+	defer _self.observe("GetUsersByIDs", time.Now(), &err)
+	return _self.repo.GetUsersByIDs(ctx, userIDs)
+}
+
+func (_self Repo) GetUsers(ctx context.Context, afterId int, limit int) (result models.UserSlice, err error) {
+	defer _self.observe("GetUsers", time.Now(), &err)
+	return _self.repo.GetUsers(ctx, afterId, limit)
+}
+
+func (_self Repo) CountUsers(ctx context.Context) (result int64, err error) {
+	defer _self.observe("CountUsers", time.Now(), &err)
+	return _self.repo.CountUsers(ctx)
+}
+
+func (_self Repo) CreateUser(ctx context.Context, name string, email string) (result *models.User, err error) {
+	defer _self.observe("CreateUser", time.Now(), &err)
+	return _self.repo.CreateUser(ctx, name, email)
+}
+
+func (_self Repo) GetUserByEmail(ctx context.Context, email string) (result *models.User, err error) {
+	defer _self.observe("GetUserByEmail", time.Now(), &err)
+	return _self.repo.GetUserByEmail(ctx, email)
+}
+
+func (_self Repo) IsExistedUser(ctx context.Context, email string) (result bool, err error) {
+	defer _self.observe("IsExistedUser", time.Now(), &err)
+	return _self.repo.IsExistedUser(ctx, email)
+}
+
+func (_self Repo) UpdateUserName(ctx context.Context, userId int, name string) (err error) {
+	defer _self.observe("UpdateUserName", time.Now(), &err)
+	return _self.repo.UpdateUserName(ctx, userId, name)
+}
+
+func (_self Repo) DeleteUser(ctx context.Context, userId int) (err error) {
+	defer _self.observe("DeleteUser", time.Now(), &err)
+	return _self.repo.DeleteUser(ctx, userId)
+}
+
+func (_self Repo) GetFriendSuggestions(ctx context.Context, userId int) (result []repository.FriendSuggestion, err error) {
+	defer _self.observe("GetFriendSuggestions", time.Now(), &err)
+	return _self.repo.GetFriendSuggestions(ctx, userId)
+}
+
+func (_self Repo) CreateFriendRequest(ctx context.Context, requestorId int, targetId int) (err error) {
+	defer _self.observe("CreateFriendRequest", time.Now(), &err)
+	return _self.repo.CreateFriendRequest(ctx, requestorId, targetId)
+}
+
+func (_self Repo) IsPendingFriendRequest(ctx context.Context, userId int, friendId int) (result bool, err error) {
+	defer _self.observe("IsPendingFriendRequest", time.Now(), &err)
+	return _self.repo.IsPendingFriendRequest(ctx, userId, friendId)
+}
+
+func (_self Repo) GetPendingFriendRequest(ctx context.Context, requestorId int, targetId int) (result *models.FriendRequest, err error) {
+	defer _self.observe("GetPendingFriendRequest", time.Now(), &err)
+	return _self.repo.GetPendingFriendRequest(ctx, requestorId, targetId)
+}
+
+func (_self Repo) GetIncomingFriendRequests(ctx context.Context, userId int) (result models.FriendRequestSlice, err error) {
+	defer _self.observe("GetIncomingFriendRequests", time.Now(), &err)
+	return _self.repo.GetIncomingFriendRequests(ctx, userId)
+}
+
+func (_self Repo) GetOutgoingFriendRequests(ctx context.Context, userId int) (result models.FriendRequestSlice, err error) {
+	defer _self.observe("GetOutgoingFriendRequests", time.Now(), &err)
+	return _self.repo.GetOutgoingFriendRequests(ctx, userId)
+}
+
+// Count the friendship of an accepted friend request, a request of users who are already friends
+// is not accepted and ErrExistedFriendship is returned, so no friendship is counted for it
+func (_self Repo) AcceptFriendRequest(ctx context.Context, requestId int) (err error) {
+	defer _self.observe("AcceptFriendRequest", time.Now(), &err)
+	if err = _self.repo.AcceptFriendRequest(ctx, requestId); err == nil {
+		_self.created(_self.metrics.friendships)
+	}
+	return err
+}
+
+func (_self Repo) UpdateFriendRequestStatus(ctx context.Context, requestId int, status string) (err error) {
+	defer _self.observe("UpdateFriendRequestStatus", time.Now(), &err)
+	return _self.repo.UpdateFriendRequestStatus(ctx, requestId, status)
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Repo(t *testing.T) {
+	type repoError struct {
+		method string
+		code   string
+	}
+	errRollback := errors.New("rollback")
+	tcs := map[string]struct {
+		run              func(ctx context.Context, repo repository.SpecRepo) error
+		expError         error
+		expFriendships   float64
+		expBlocks        float64
+		expErrors        map[repoError]float64
+		expObservedCalls []string
+	}{
+		"success with counting a created friendship": {
+			run: func(ctx context.Context, repo repository.SpecRepo) error {
+				return repo.CreateFriend(ctx, 1, 2)
+			},
+			expFriendships:   1,
+			expObservedCalls: []string{"CreateFriend"},
+		},
+		"success with counting a block after the unit of work is committed": {
+			run: func(ctx context.Context, repo repository.SpecRepo) error {
+				return repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+					if err := repo.LockUsers(ctx, 1, 2); err != nil {
+						return err
+					}
+					return repo.CreateUserBlock(ctx, 1, 2)
+				})
+			},
+			expBlocks:        1,
+			expObservedCalls: []string{"WithTx", "LockUsers", "CreateUserBlock"},
+		},
+		"failed with an existing friendship": {
+			run: func(ctx context.Context, repo repository.SpecRepo) error {
+				if err := repo.CreateFriend(ctx, 1, 2); err != nil {
+					return err
+				}
+				return repo.CreateFriend(ctx, 2, 1)
+			},
+			expError:         repository.ErrExistedFriendship,
+			expFriendships:   1,
+			expErrors:        map[repoError]float64{{"CreateFriend", "friendship_exists"}: 1},
+			expObservedCalls: []string{"CreateFriend"},
+		},
+		"success with counting a friendship of an accepted friend request": {
+			run: func(ctx context.Context, repo repository.SpecRepo) error {
+				if err := repo.CreateFriendRequest(ctx, 1, 2); err != nil {
+					return err
+				}
+				friendRequest, err := repo.GetPendingFriendRequest(ctx, 1, 2)
+				if err != nil {
+					return err
+				}
+				return repo.AcceptFriendRequest(ctx, friendRequest.ID)
+			},
+			expFriendships:   1,
+			expObservedCalls: []string{"CreateFriendRequest", "GetPendingFriendRequest", "AcceptFriendRequest"},
+		},
+		"failed with accepting a friend request of existing friends": {
+			run: func(ctx context.Context, repo repository.SpecRepo) error {
+				if err := repo.CreateFriendRequest(ctx, 1, 2); err != nil {
+					return err
+				}
+				friendRequest, err := repo.GetPendingFriendRequest(ctx, 1, 2)
+				if err != nil {
+					return err
+				}
+				if err := repo.CreateFriend(ctx, 1, 2); err != nil {
+					return err
+				}
+				return repo.AcceptFriendRequest(ctx, friendRequest.ID)
+			},
+			expError:         repository.ErrExistedFriendship,
+			expFriendships:   1,
+			expErrors:        map[repoError]float64{{"AcceptFriendRequest", "friendship_exists"}: 1},
+			expObservedCalls: []string{"CreateFriendRequest", "GetPendingFriendRequest", "CreateFriend", "AcceptFriendRequest"},
+		},
+		"failed with a unit of work which is rolled back": {
+			run: func(ctx context.Context, repo repository.SpecRepo) error {
+				return repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+					if err := repo.CreateFriend(ctx, 1, 2); err != nil {
+						return err
+					}
+					return errRollback
+				})
+			},
+			expError:         errRollback,
+			expErrors:        map[repoError]float64{{"WithTx", "internal_error"}: 1},
+			expObservedCalls: []string{"WithTx", "CreateFriend"},
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			ctx := context.Background()
+			memoryRepo := repository.NewMemoryRepo()
+			for _, email := range []string{"john@example.com", "andy@example.com"} {
+				_, err := memoryRepo.CreateUser(ctx, "user", email)
+				require.NoError(t, err)
+			}
+			metrics := New()
+
+			err := tc.run(ctx, NewRepo(memoryRepo, metrics))
+			if tc.expError != nil {
+				require.ErrorIs(t, err, tc.expError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tc.expFriendships, testutil.ToFloat64(metrics.friendships))
+			require.Equal(t, tc.expBlocks, testutil.ToFloat64(metrics.blocks))
+			require.Equal(t, float64(0), testutil.ToFloat64(metrics.subscriptions))
+			require.Equal(t, len(tc.expErrors), testutil.CollectAndCount(metrics.repoErrors))
+			for repoErr, count := range tc.expErrors {
+				require.Equal(t, count, testutil.ToFloat64(metrics.repoErrors.WithLabelValues(repoErr.method, repoErr.code)))
+			}
+			require.Equal(t, len(tc.expObservedCalls), testutil.CollectAndCount(metrics.repoDuration))
+		})
+	}
+}
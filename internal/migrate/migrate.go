@@ -0,0 +1,281 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+// Migration is a version of the database schema with the scripts to apply and to reverse it
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is the version of the database schema compared with the migrations of the binary
+type Status struct {
+	// Version of the last applied migration, it is 0 when no migration has been applied
+	Version int64
+	// A migration failed in the middle, the database has to be fixed and the version forced
+	Dirty bool
+	// Version of the last migration of the binary
+	Latest int64
+	// Migrations which have not been applied yet, ordered by version
+	Pending []Migration
+}
+
+// The database has been migrated by a newer binary
+func (_self Status) IsAhead() bool {
+	return _self.Version > _self.Latest
+}
+
+var migrationFileName = regexp.MustCompile(`^([0-9]+)_(.+)\.(up|down)\.sql$`)
+
+// Load migrations from files named {version}_{name}.up.sql and {version}_{name}.down.sql, ordered by version.
+// Other files are skipped
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		b, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names %q and %q", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(b)
+		} else {
+			migration.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator applies migrations to a database, the version is stored in schema_migrations table
+// the same way as golang-migrate, so databases which were migrated by the migrate container keep their version
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+// New creates a migrator of the migrations of fsys for a database of the driver (postgres or sqlite3)
+func New(db *sql.DB, driver string, fsys fs.FS) (Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return Migrator{}, err
+	}
+	return Migrator{
+		db:         db,
+		driver:     driver,
+		migrations: migrations,
+	}, nil
+}
+
+// Get the version of the database schema and the pending migrations, the database is not changed
+// so the status can be read by a read-only role, ex: by readiness checks
+func (_self Migrator) Status(ctx context.Context) (Status, error) {
+	version, dirty, err := _self.version(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+
+	status := Status{
+		Version: version,
+		Dirty:   dirty,
+		Pending: []Migration{},
+	}
+	for _, migration := range _self.migrations {
+		status.Latest = migration.Version
+		if migration.Version > version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// Apply all pending migrations in order of versions, applied migrations are returned.
+// A migration which fails leaves the database dirty at its version
+func (_self Migrator) Up(ctx context.Context) ([]Migration, error) {
+	status, err := _self.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status.Dirty {
+		return nil, errDirty(status.Version)
+	}
+	if len(status.Pending) == 0 {
+		return []Migration{}, nil
+	}
+	if err := _self.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	applied := []Migration{}
+	for _, migration := range status.Pending {
+		if err := _self.run(ctx, migration.Version, migration.Version, migration.Up); err != nil {
+			return applied, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Reverse the last steps applied migrations, reversed migrations are returned.
+// A migration which fails leaves the database dirty at its version
+func (_self Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	status, err := _self.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status.Dirty {
+		return nil, errDirty(status.Version)
+	}
+
+	applied := []Migration{}
+	for i := len(_self.migrations) - 1; i >= 0; i-- {
+		if migration := _self.migrations[i]; migration.Version <= status.Version {
+			applied = append(applied, migration)
+		}
+	}
+
+	reversed := []Migration{}
+	for i, migration := range applied {
+		if len(reversed) == steps {
+			break
+		}
+		if i == 0 && migration.Version != status.Version {
+			return nil, fmt.Errorf("database is at version %d which is not a migration of the binary", status.Version)
+		}
+
+		var previous int64
+		if i+1 < len(applied) {
+			previous = applied[i+1].Version
+		}
+		if err := _self.run(ctx, migration.Version, previous, migration.Down); err != nil {
+			return reversed, fmt.Errorf("failed to reverse migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		reversed = append(reversed, migration)
+	}
+	return reversed, nil
+}
+
+// Set the version of the database schema without running migrations and clear the dirty flag,
+// it is used after a failed migration has been fixed by hand
+func (_self Migrator) Force(ctx context.Context, version int64) error {
+	if err := _self.createTable(ctx); err != nil {
+		return err
+	}
+	return _self.setVersion(ctx, version, false)
+}
+
+// Run a script of a migration, the database is dirty at the version of the migration until the script succeeds
+func (_self Migrator) run(ctx context.Context, version int64, nextVersion int64, script string) error {
+	if err := _self.setVersion(ctx, version, true); err != nil {
+		return err
+	}
+	if _, err := _self.db.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	return _self.setVersion(ctx, nextVersion, false)
+}
+
+// Get the version of the database schema, it is 0 when schema_migrations table does not exist
+func (_self Migrator) version(ctx context.Context) (int64, bool, error) {
+	isExisted, err := _self.isExistedTable(ctx)
+	if err != nil || !isExisted {
+		return 0, false, err
+	}
+
+	migration, err := models.SchemaMigrations().One(ctx, _self.db)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return migration.Version, migration.Dirty, nil
+}
+
+// Verify schema_migrations table exists by the catalog of the driver
+func (_self Migrator) isExistedTable(ctx context.Context) (bool, error) {
+	query := `SELECT to_regclass('schema_migrations') IS NOT NULL`
+	if _self.driver == config.DriverSQLite {
+		query = `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')`
+	}
+
+	var isExisted bool
+	err := _self.db.QueryRowContext(ctx, query).Scan(&isExisted)
+	return isExisted, err
+}
+
+func (_self Migrator) createTable(ctx context.Context) error {
+	_, err := _self.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`)
+	return err
+}
+
+// Replace the version of schema_migrations table, the table is empty at version 0 unless it is dirty
+func (_self Migrator) setVersion(ctx context.Context, version int64, dirty bool) error {
+	tx, err := _self.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := models.SchemaMigrations().DeleteAll(ctx, tx); err != nil {
+		return err
+	}
+	if version > 0 || dirty {
+		migration := models.SchemaMigration{Version: version, Dirty: dirty}
+		if err := migration.Insert(ctx, tx, boil.Infer()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func errDirty(version int64) error {
+	return fmt.Errorf("database is dirty at version %d, fix the failed migration and force the version", version)
+}
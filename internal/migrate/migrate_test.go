@@ -0,0 +1,172 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/db/migrations"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDatabase will be creating an empty SQLite database of the test
+func newTestDatabase(t *testing.T) *sql.DB {
+	driver, dsn, err := config.ParseDatabaseURL("sqlite://" + filepath.Join(t.TempDir(), "friendmanagement.db"))
+	require.NoError(t, err)
+	db, err := sql.Open(driver, dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+var testMigrations = fstest.MapFS{
+	"2_add_friends.up.sql":   {Data: []byte(`CREATE TABLE friends (id INTEGER PRIMARY KEY);`)},
+	"2_add_friends.down.sql": {Data: []byte(`DROP TABLE friends;`)},
+	"1_add_users.up.sql":     {Data: []byte(`CREATE TABLE users (id INTEGER PRIMARY KEY);`)},
+	"1_add_users.down.sql":   {Data: []byte(`DROP TABLE users;`)},
+	"README.md":              {Data: []byte(`not a migration`)},
+}
+
+func TestMigrate_Load(t *testing.T) {
+	tcs := map[string]struct {
+		fsys        fstest.MapFS
+		expNames    []string
+		expVersions []int64
+		expError    error
+	}{
+		"success with ordering migrations by version": {
+			fsys:        testMigrations,
+			expNames:    []string{"add_users", "add_friends"},
+			expVersions: []int64{1, 2},
+		},
+		"failed with a migration without up script": {
+			fsys: fstest.MapFS{
+				"1_add_users.down.sql": {Data: []byte(`DROP TABLE users;`)},
+			},
+			expError: errors.New("migration 1_add_users has no up script"),
+		},
+		"failed with different names of the same version": {
+			fsys: fstest.MapFS{
+				"1_add_users.up.sql":      {Data: []byte(`CREATE TABLE users (id INTEGER PRIMARY KEY);`)},
+				"1_create_users.down.sql": {Data: []byte(`DROP TABLE users;`)},
+			},
+			expError: errors.New("migration 1 has different names \"add_users\" and \"create_users\""),
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			result, err := Load(tc.fsys)
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+				return
+			}
+			require.NoError(t, err)
+			names, versions := []string{}, []int64{}
+			for _, migration := range result {
+				names = append(names, migration.Name)
+				versions = append(versions, migration.Version)
+			}
+			require.Equal(t, tc.expNames, names)
+			require.Equal(t, tc.expVersions, versions)
+		})
+	}
+}
+
+func TestMigrate_UpAndDown(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDatabase(t)
+	migrator, err := New(db, config.DriverSQLite, testMigrations)
+	require.NoError(t, err)
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), status.Version)
+	require.Equal(t, int64(2), status.Latest)
+	require.Len(t, status.Pending, 2)
+
+	// The status is read without creating schema_migrations table, so it works with a read-only role
+	var tables int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE name = 'schema_migrations'`).Scan(&tables))
+	require.Equal(t, 0, tables)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 2)
+	status, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Status{Version: 2, Latest: 2, Pending: []Migration{}}, status)
+
+	// Migrations which have been applied are not applied again
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	require.Empty(t, applied)
+
+	reversed, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reversed, 1)
+	require.Equal(t, int64(2), reversed[0].Version)
+	status, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), status.Version)
+
+	reversed, err = migrator.Down(ctx, 5)
+	require.NoError(t, err)
+	require.Len(t, reversed, 1)
+	status, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), status.Version)
+	require.Len(t, status.Pending, 2)
+}
+
+func TestMigrate_DirtyAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"1_add_users.up.sql":   testMigrations["1_add_users.up.sql"],
+		"2_add_broken.up.sql":  {Data: []byte(`CREATE TABLE broken (;`)},
+		"3_add_friends.up.sql": testMigrations["2_add_friends.up.sql"],
+	}
+	migrator, err := New(newTestDatabase(t), config.DriverSQLite, fsys)
+	require.NoError(t, err)
+
+	applied, err := migrator.Up(ctx)
+	require.Error(t, err)
+	require.Len(t, applied, 1)
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), status.Version)
+	require.True(t, status.Dirty)
+
+	// A dirty database is not migrated until the version is forced
+	_, err = migrator.Up(ctx)
+	require.EqualError(t, err, "database is dirty at version 2, fix the failed migration and force the version")
+
+	require.NoError(t, migrator.Force(ctx, 2))
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	require.Equal(t, int64(3), applied[0].Version)
+}
+
+func TestMigrate_EmbeddedMigrations(t *testing.T) {
+	ctx := context.Background()
+	migrator, err := New(newTestDatabase(t), config.DriverSQLite, migrations.SQLite())
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Empty(t, status.Pending)
+	require.False(t, status.Dirty)
+
+	// Postgres and SQLite migrations end at the same version of the schema
+	postgres, err := Load(migrations.Postgres())
+	require.NoError(t, err)
+	require.Equal(t, postgres[len(postgres)-1].Version, status.Latest)
+}
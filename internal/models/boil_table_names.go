@@ -4,12 +4,16 @@
 package models
 
 var TableNames = struct {
+	APIKeys          string
+	FriendRequests   string
 	Friends          string
 	SchemaMigrations string
 	Subscriptions    string
 	UserBlocks       string
 	Users            string
 }{
+	APIKeys:          "api_keys",
+	FriendRequests:   "friend_requests",
 	Friends:          "friends",
 	SchemaMigrations: "schema_migrations",
 	Subscriptions:    "subscriptions",
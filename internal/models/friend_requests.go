@@ -0,0 +1,1269 @@
+// Code generated by SQLBoiler 4.8.3 (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"github.com/volatiletech/sqlboiler/v4/queries/qmhelper"
+	"github.com/volatiletech/strmangle"
+)
+
+// FriendRequest is an object representing the database table.
+type FriendRequest struct {
+	ID          int       `boil:"id" json:"id" toml:"id" yaml:"id"`
+	RequestorID int       `boil:"requestor_id" json:"requestor_id" toml:"requestor_id" yaml:"requestor_id"`
+	TargetID    int       `boil:"target_id" json:"target_id" toml:"target_id" yaml:"target_id"`
+	Status      string    `boil:"status" json:"status" toml:"status" yaml:"status"`
+	CreatedAt   time.Time `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
+	UpdatedAt   time.Time `boil:"updated_at" json:"updated_at" toml:"updated_at" yaml:"updated_at"`
+
+	R *friendRequestR `boil:"-" json:"-" toml:"-" yaml:"-"`
+	L friendRequestL  `boil:"-" json:"-" toml:"-" yaml:"-"`
+}
+
+var FriendRequestColumns = struct {
+	ID          string
+	RequestorID string
+	TargetID    string
+	Status      string
+	CreatedAt   string
+	UpdatedAt   string
+}{
+	ID:          "id",
+	RequestorID: "requestor_id",
+	TargetID:    "target_id",
+	Status:      "status",
+	CreatedAt:   "created_at",
+	UpdatedAt:   "updated_at",
+}
+
+var FriendRequestTableColumns = struct {
+	ID          string
+	RequestorID string
+	TargetID    string
+	Status      string
+	CreatedAt   string
+	UpdatedAt   string
+}{
+	ID:          "friend_requests.id",
+	RequestorID: "friend_requests.requestor_id",
+	TargetID:    "friend_requests.target_id",
+	Status:      "friend_requests.status",
+	CreatedAt:   "friend_requests.created_at",
+	UpdatedAt:   "friend_requests.updated_at",
+}
+
+// Generated where
+
+var FriendRequestWhere = struct {
+	ID          whereHelperint
+	RequestorID whereHelperint
+	TargetID    whereHelperint
+	Status      whereHelperstring
+	CreatedAt   whereHelpertime_Time
+	UpdatedAt   whereHelpertime_Time
+}{
+	ID:          whereHelperint{field: "\"friend_requests\".\"id\""},
+	RequestorID: whereHelperint{field: "\"friend_requests\".\"requestor_id\""},
+	TargetID:    whereHelperint{field: "\"friend_requests\".\"target_id\""},
+	Status:      whereHelperstring{field: "\"friend_requests\".\"status\""},
+	CreatedAt:   whereHelpertime_Time{field: "\"friend_requests\".\"created_at\""},
+	UpdatedAt:   whereHelpertime_Time{field: "\"friend_requests\".\"updated_at\""},
+}
+
+// FriendRequestRels is where relationship names are stored.
+var FriendRequestRels = struct {
+	Requestor string
+	Target    string
+}{
+	Requestor: "Requestor",
+	Target:    "Target",
+}
+
+// friendRequestR is where relationships are stored.
+type friendRequestR struct {
+	Requestor *User `boil:"Requestor" json:"Requestor" toml:"Requestor" yaml:"Requestor"`
+	Target    *User `boil:"Target" json:"Target" toml:"Target" yaml:"Target"`
+}
+
+// NewStruct creates a new relationship struct
+func (*friendRequestR) NewStruct() *friendRequestR {
+	return &friendRequestR{}
+}
+
+// friendRequestL is where Load methods for each relationship are stored.
+type friendRequestL struct{}
+
+var (
+	friendRequestAllColumns            = []string{"id", "requestor_id", "target_id", "status", "created_at", "updated_at"}
+	friendRequestColumnsWithoutDefault = []string{"requestor_id", "target_id", "created_at", "updated_at"}
+	friendRequestColumnsWithDefault    = []string{"id", "status"}
+	friendRequestPrimaryKeyColumns     = []string{"id"}
+)
+
+type (
+	// FriendRequestSlice is an alias for a slice of pointers to FriendRequest.
+	// This should almost always be used instead of []FriendRequest.
+	FriendRequestSlice []*FriendRequest
+	// FriendRequestHook is the signature for custom FriendRequest hook methods
+	FriendRequestHook func(context.Context, boil.ContextExecutor, *FriendRequest) error
+
+	friendRequestQuery struct {
+		*queries.Query
+	}
+)
+
+// Cache for insert, update and upsert
+var (
+	friendRequestType                 = reflect.TypeOf(&FriendRequest{})
+	friendRequestMapping              = queries.MakeStructMapping(friendRequestType)
+	friendRequestPrimaryKeyMapping, _ = queries.BindMapping(friendRequestType, friendRequestMapping, friendRequestPrimaryKeyColumns)
+	friendRequestInsertCacheMut       sync.RWMutex
+	friendRequestInsertCache          = make(map[string]insertCache)
+	friendRequestUpdateCacheMut       sync.RWMutex
+	friendRequestUpdateCache          = make(map[string]updateCache)
+	friendRequestUpsertCacheMut       sync.RWMutex
+	friendRequestUpsertCache          = make(map[string]insertCache)
+)
+
+var (
+	// Force time package dependency for automated UpdatedAt/CreatedAt.
+	_ = time.Second
+	// Force qmhelper dependency for where clause generation (which doesn't
+	// always happen)
+	_ = qmhelper.Where
+)
+
+var friendRequestBeforeInsertHooks []FriendRequestHook
+var friendRequestBeforeUpdateHooks []FriendRequestHook
+var friendRequestBeforeDeleteHooks []FriendRequestHook
+var friendRequestBeforeUpsertHooks []FriendRequestHook
+
+var friendRequestAfterInsertHooks []FriendRequestHook
+var friendRequestAfterSelectHooks []FriendRequestHook
+var friendRequestAfterUpdateHooks []FriendRequestHook
+var friendRequestAfterDeleteHooks []FriendRequestHook
+var friendRequestAfterUpsertHooks []FriendRequestHook
+
+// doBeforeInsertHooks executes all "before insert" hooks.
+func (o *FriendRequest) doBeforeInsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range friendRequestBeforeInsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpdateHooks executes all "before Update" hooks.
+func (o *FriendRequest) doBeforeUpdateHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range friendRequestBeforeUpdateHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeDeleteHooks executes all "before Delete" hooks.
+func (o *FriendRequest) doBeforeDeleteHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range friendRequestBeforeDeleteHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpsertHooks executes all "before Upsert" hooks.
+func (o *FriendRequest) doBeforeUpsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range friendRequestBeforeUpsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterInsertHooks executes all "after Insert" hooks.
+func (o *FriendRequest) doAfterInsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range friendRequestAfterInsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterSelectHooks executes all "after Select" hooks.
+func (o *FriendRequest) doAfterSelectHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range friendRequestAfterSelectHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpdateHooks executes all "after Update" hooks.
+func (o *FriendRequest) doAfterUpdateHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range friendRequestAfterUpdateHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterDeleteHooks executes all "after Delete" hooks.
+func (o *FriendRequest) doAfterDeleteHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range friendRequestAfterDeleteHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpsertHooks executes all "after Upsert" hooks.
+func (o *FriendRequest) doAfterUpsertHooks(ctx context.Context, exec boil.ContextExecutor) (err error) {
+	if boil.HooksAreSkipped(ctx) {
+		return nil
+	}
+
+	for _, hook := range friendRequestAfterUpsertHooks {
+		if err := hook(ctx, exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddFriendRequestHook registers your hook function for all future operations.
+func AddFriendRequestHook(hookPoint boil.HookPoint, friendRequestHook FriendRequestHook) {
+	switch hookPoint {
+	case boil.BeforeInsertHook:
+		friendRequestBeforeInsertHooks = append(friendRequestBeforeInsertHooks, friendRequestHook)
+	case boil.BeforeUpdateHook:
+		friendRequestBeforeUpdateHooks = append(friendRequestBeforeUpdateHooks, friendRequestHook)
+	case boil.BeforeDeleteHook:
+		friendRequestBeforeDeleteHooks = append(friendRequestBeforeDeleteHooks, friendRequestHook)
+	case boil.BeforeUpsertHook:
+		friendRequestBeforeUpsertHooks = append(friendRequestBeforeUpsertHooks, friendRequestHook)
+	case boil.AfterInsertHook:
+		friendRequestAfterInsertHooks = append(friendRequestAfterInsertHooks, friendRequestHook)
+	case boil.AfterSelectHook:
+		friendRequestAfterSelectHooks = append(friendRequestAfterSelectHooks, friendRequestHook)
+	case boil.AfterUpdateHook:
+		friendRequestAfterUpdateHooks = append(friendRequestAfterUpdateHooks, friendRequestHook)
+	case boil.AfterDeleteHook:
+		friendRequestAfterDeleteHooks = append(friendRequestAfterDeleteHooks, friendRequestHook)
+	case boil.AfterUpsertHook:
+		friendRequestAfterUpsertHooks = append(friendRequestAfterUpsertHooks, friendRequestHook)
+	}
+}
+
+// One returns a single friendRequest record from the query.
+func (q friendRequestQuery) One(ctx context.Context, exec boil.ContextExecutor) (*FriendRequest, error) {
+	o := &FriendRequest{}
+
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Bind(ctx, exec, o)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: failed to execute a one query for friend_requests")
+	}
+
+	if err := o.doAfterSelectHooks(ctx, exec); err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+// All returns all FriendRequest records from the query.
+func (q friendRequestQuery) All(ctx context.Context, exec boil.ContextExecutor) (FriendRequestSlice, error) {
+	var o []*FriendRequest
+
+	err := q.Bind(ctx, exec, &o)
+	if err != nil {
+		return nil, errors.Wrap(err, "models: failed to assign all query results to FriendRequest slice")
+	}
+
+	if len(friendRequestAfterSelectHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterSelectHooks(ctx, exec); err != nil {
+				return o, err
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// Count returns the count of all FriendRequest records in the query.
+func (q friendRequestQuery) Count(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+
+	err := q.Query.QueryRowContext(ctx, exec).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to count friend_requests rows")
+	}
+
+	return count, nil
+}
+
+// Exists checks if the row exists in the table.
+func (q friendRequestQuery) Exists(ctx context.Context, exec boil.ContextExecutor) (bool, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Query.QueryRowContext(ctx, exec).Scan(&count)
+	if err != nil {
+		return false, errors.Wrap(err, "models: failed to check if friend_requests exists")
+	}
+
+	return count > 0, nil
+}
+
+// Requestor pointed to by the foreign key.
+func (o *FriendRequest) Requestor(mods ...qm.QueryMod) userQuery {
+	queryMods := []qm.QueryMod{
+		qm.Where("\"id\" = ?", o.RequestorID),
+	}
+
+	queryMods = append(queryMods, mods...)
+
+	query := Users(queryMods...)
+	queries.SetFrom(query.Query, "\"users\"")
+
+	return query
+}
+
+// Target pointed to by the foreign key.
+func (o *FriendRequest) Target(mods ...qm.QueryMod) userQuery {
+	queryMods := []qm.QueryMod{
+		qm.Where("\"id\" = ?", o.TargetID),
+	}
+
+	queryMods = append(queryMods, mods...)
+
+	query := Users(queryMods...)
+	queries.SetFrom(query.Query, "\"users\"")
+
+	return query
+}
+
+// LoadRequestor allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for an N-1 relationship.
+func (friendRequestL) LoadRequestor(ctx context.Context, e boil.ContextExecutor, singular bool, maybeFriendRequest interface{}, mods queries.Applicator) error {
+	var slice []*FriendRequest
+	var object *FriendRequest
+
+	if singular {
+		object = maybeFriendRequest.(*FriendRequest)
+	} else {
+		slice = *maybeFriendRequest.(*[]*FriendRequest)
+	}
+
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &friendRequestR{}
+		}
+		args = append(args, object.RequestorID)
+
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &friendRequestR{}
+			}
+
+			for _, a := range args {
+				if a == obj.RequestorID {
+					continue Outer
+				}
+			}
+
+			args = append(args, obj.RequestorID)
+
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`users`),
+		qm.WhereIn(`users.id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load User")
+	}
+
+	var resultSlice []*User
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice User")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results of eager load for users")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for users")
+	}
+
+	if len(friendRequestAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(resultSlice) == 0 {
+		return nil
+	}
+
+	if singular {
+		foreign := resultSlice[0]
+		object.R.Requestor = foreign
+		if foreign.R == nil {
+			foreign.R = &userR{}
+		}
+		foreign.R.RequestorFriendRequests = append(foreign.R.RequestorFriendRequests, object)
+		return nil
+	}
+
+	for _, local := range slice {
+		for _, foreign := range resultSlice {
+			if local.RequestorID == foreign.ID {
+				local.R.Requestor = foreign
+				if foreign.R == nil {
+					foreign.R = &userR{}
+				}
+				foreign.R.RequestorFriendRequests = append(foreign.R.RequestorFriendRequests, local)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadTarget allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for an N-1 relationship.
+func (friendRequestL) LoadTarget(ctx context.Context, e boil.ContextExecutor, singular bool, maybeFriendRequest interface{}, mods queries.Applicator) error {
+	var slice []*FriendRequest
+	var object *FriendRequest
+
+	if singular {
+		object = maybeFriendRequest.(*FriendRequest)
+	} else {
+		slice = *maybeFriendRequest.(*[]*FriendRequest)
+	}
+
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &friendRequestR{}
+		}
+		args = append(args, object.TargetID)
+
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &friendRequestR{}
+			}
+
+			for _, a := range args {
+				if a == obj.TargetID {
+					continue Outer
+				}
+			}
+
+			args = append(args, obj.TargetID)
+
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`users`),
+		qm.WhereIn(`users.id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load User")
+	}
+
+	var resultSlice []*User
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice User")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results of eager load for users")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for users")
+	}
+
+	if len(friendRequestAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(resultSlice) == 0 {
+		return nil
+	}
+
+	if singular {
+		foreign := resultSlice[0]
+		object.R.Target = foreign
+		if foreign.R == nil {
+			foreign.R = &userR{}
+		}
+		foreign.R.TargetFriendRequests = append(foreign.R.TargetFriendRequests, object)
+		return nil
+	}
+
+	for _, local := range slice {
+		for _, foreign := range resultSlice {
+			if local.TargetID == foreign.ID {
+				local.R.Target = foreign
+				if foreign.R == nil {
+					foreign.R = &userR{}
+				}
+				foreign.R.TargetFriendRequests = append(foreign.R.TargetFriendRequests, local)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetRequestor of the friendRequest to the related item.
+// Sets o.R.Requestor to related.
+// Adds o to related.R.RequestorFriendRequests.
+func (o *FriendRequest) SetRequestor(ctx context.Context, exec boil.ContextExecutor, insert bool, related *User) error {
+	var err error
+	if insert {
+		if err = related.Insert(ctx, exec, boil.Infer()); err != nil {
+			return errors.Wrap(err, "failed to insert into foreign table")
+		}
+	}
+
+	updateQuery := fmt.Sprintf(
+		"UPDATE \"friend_requests\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, []string{"requestor_id"}),
+		strmangle.WhereClause("\"", "\"", 2, friendRequestPrimaryKeyColumns),
+	)
+	values := []interface{}{related.ID, o.ID}
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, updateQuery)
+		fmt.Fprintln(writer, values)
+	}
+	if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+		return errors.Wrap(err, "failed to update local table")
+	}
+
+	o.RequestorID = related.ID
+	if o.R == nil {
+		o.R = &friendRequestR{
+			Requestor: related,
+		}
+	} else {
+		o.R.Requestor = related
+	}
+
+	if related.R == nil {
+		related.R = &userR{
+			RequestorFriendRequests: FriendRequestSlice{o},
+		}
+	} else {
+		related.R.RequestorFriendRequests = append(related.R.RequestorFriendRequests, o)
+	}
+
+	return nil
+}
+
+// SetTarget of the friendRequest to the related item.
+// Sets o.R.Target to related.
+// Adds o to related.R.TargetFriendRequests.
+func (o *FriendRequest) SetTarget(ctx context.Context, exec boil.ContextExecutor, insert bool, related *User) error {
+	var err error
+	if insert {
+		if err = related.Insert(ctx, exec, boil.Infer()); err != nil {
+			return errors.Wrap(err, "failed to insert into foreign table")
+		}
+	}
+
+	updateQuery := fmt.Sprintf(
+		"UPDATE \"friend_requests\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, []string{"target_id"}),
+		strmangle.WhereClause("\"", "\"", 2, friendRequestPrimaryKeyColumns),
+	)
+	values := []interface{}{related.ID, o.ID}
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, updateQuery)
+		fmt.Fprintln(writer, values)
+	}
+	if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+		return errors.Wrap(err, "failed to update local table")
+	}
+
+	o.TargetID = related.ID
+	if o.R == nil {
+		o.R = &friendRequestR{
+			Target: related,
+		}
+	} else {
+		o.R.Target = related
+	}
+
+	if related.R == nil {
+		related.R = &userR{
+			TargetFriendRequests: FriendRequestSlice{o},
+		}
+	} else {
+		related.R.TargetFriendRequests = append(related.R.TargetFriendRequests, o)
+	}
+
+	return nil
+}
+
+// FriendRequests retrieves all the records using an executor.
+func FriendRequests(mods ...qm.QueryMod) friendRequestQuery {
+	mods = append(mods, qm.From("\"friend_requests\""))
+	return friendRequestQuery{NewQuery(mods...)}
+}
+
+// FindFriendRequest retrieves a single record by ID with an executor.
+// If selectCols is empty Find will return all columns.
+func FindFriendRequest(ctx context.Context, exec boil.ContextExecutor, iD int, selectCols ...string) (*FriendRequest, error) {
+	friendRequestObj := &FriendRequest{}
+
+	sel := "*"
+	if len(selectCols) > 0 {
+		sel = strings.Join(strmangle.IdentQuoteSlice(dialect.LQ, dialect.RQ, selectCols), ",")
+	}
+	query := fmt.Sprintf(
+		"select %s from \"friend_requests\" where \"id\"=$1", sel,
+	)
+
+	q := queries.Raw(query, iD)
+
+	err := q.Bind(ctx, exec, friendRequestObj)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: unable to select from friend_requests")
+	}
+
+	if err = friendRequestObj.doAfterSelectHooks(ctx, exec); err != nil {
+		return friendRequestObj, err
+	}
+
+	return friendRequestObj, nil
+}
+
+// Insert a single record using an executor.
+// See boil.Columns.InsertColumnSet documentation to understand column list inference for inserts.
+func (o *FriendRequest) Insert(ctx context.Context, exec boil.ContextExecutor, columns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no friend_requests provided for insertion")
+	}
+
+	var err error
+	if !boil.TimestampsAreSkipped(ctx) {
+		currTime := time.Now().In(boil.GetLocation())
+
+		if o.CreatedAt.IsZero() {
+			o.CreatedAt = currTime
+		}
+		if o.UpdatedAt.IsZero() {
+			o.UpdatedAt = currTime
+		}
+	}
+
+	if err := o.doBeforeInsertHooks(ctx, exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(friendRequestColumnsWithDefault, o)
+
+	key := makeCacheKey(columns, nzDefaults)
+	friendRequestInsertCacheMut.RLock()
+	cache, cached := friendRequestInsertCache[key]
+	friendRequestInsertCacheMut.RUnlock()
+
+	if !cached {
+		wl, returnColumns := columns.InsertColumnSet(
+			friendRequestAllColumns,
+			friendRequestColumnsWithDefault,
+			friendRequestColumnsWithoutDefault,
+			nzDefaults,
+		)
+
+		cache.valueMapping, err = queries.BindMapping(friendRequestType, friendRequestMapping, wl)
+		if err != nil {
+			return err
+		}
+		cache.retMapping, err = queries.BindMapping(friendRequestType, friendRequestMapping, returnColumns)
+		if err != nil {
+			return err
+		}
+		if len(wl) != 0 {
+			cache.query = fmt.Sprintf("INSERT INTO \"friend_requests\" (\"%s\") %%sVALUES (%s)%%s", strings.Join(wl, "\",\""), strmangle.Placeholders(dialect.UseIndexPlaceholders, len(wl), 1, 1))
+		} else {
+			cache.query = "INSERT INTO \"friend_requests\" %sDEFAULT VALUES%s"
+		}
+
+		var queryOutput, queryReturning string
+
+		if len(cache.retMapping) != 0 {
+			queryReturning = fmt.Sprintf(" RETURNING \"%s\"", strings.Join(returnColumns, "\",\""))
+		}
+
+		cache.query = fmt.Sprintf(cache.query, queryOutput, queryReturning)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, cache.query)
+		fmt.Fprintln(writer, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRowContext(ctx, cache.query, vals...).Scan(queries.PtrsFromMapping(value, cache.retMapping)...)
+	} else {
+		_, err = exec.ExecContext(ctx, cache.query, vals...)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "models: unable to insert into friend_requests")
+	}
+
+	if !cached {
+		friendRequestInsertCacheMut.Lock()
+		friendRequestInsertCache[key] = cache
+		friendRequestInsertCacheMut.Unlock()
+	}
+
+	return o.doAfterInsertHooks(ctx, exec)
+}
+
+// Update uses an executor to update the FriendRequest.
+// See boil.Columns.UpdateColumnSet documentation to understand column list inference for updates.
+// Update does not automatically update the record in case of default values. Use .Reload() to refresh the records.
+func (o *FriendRequest) Update(ctx context.Context, exec boil.ContextExecutor, columns boil.Columns) (int64, error) {
+	if !boil.TimestampsAreSkipped(ctx) {
+		currTime := time.Now().In(boil.GetLocation())
+
+		o.UpdatedAt = currTime
+	}
+
+	var err error
+	if err = o.doBeforeUpdateHooks(ctx, exec); err != nil {
+		return 0, err
+	}
+	key := makeCacheKey(columns, nil)
+	friendRequestUpdateCacheMut.RLock()
+	cache, cached := friendRequestUpdateCache[key]
+	friendRequestUpdateCacheMut.RUnlock()
+
+	if !cached {
+		wl := columns.UpdateColumnSet(
+			friendRequestAllColumns,
+			friendRequestPrimaryKeyColumns,
+		)
+
+		if !columns.IsWhitelist() {
+			wl = strmangle.SetComplement(wl, []string{"created_at"})
+		}
+		if len(wl) == 0 {
+			return 0, errors.New("models: unable to update friend_requests, could not build whitelist")
+		}
+
+		cache.query = fmt.Sprintf("UPDATE \"friend_requests\" SET %s WHERE %s",
+			strmangle.SetParamNames("\"", "\"", 1, wl),
+			strmangle.WhereClause("\"", "\"", len(wl)+1, friendRequestPrimaryKeyColumns),
+		)
+		cache.valueMapping, err = queries.BindMapping(friendRequestType, friendRequestMapping, append(wl, friendRequestPrimaryKeyColumns...))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	values := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), cache.valueMapping)
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, cache.query)
+		fmt.Fprintln(writer, values)
+	}
+	var result sql.Result
+	result, err = exec.ExecContext(ctx, cache.query, values...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update friend_requests row")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by update for friend_requests")
+	}
+
+	if !cached {
+		friendRequestUpdateCacheMut.Lock()
+		friendRequestUpdateCache[key] = cache
+		friendRequestUpdateCacheMut.Unlock()
+	}
+
+	return rowsAff, o.doAfterUpdateHooks(ctx, exec)
+}
+
+// UpdateAll updates all rows with the specified column values.
+func (q friendRequestQuery) UpdateAll(ctx context.Context, exec boil.ContextExecutor, cols M) (int64, error) {
+	queries.SetUpdate(q.Query, cols)
+
+	result, err := q.Query.ExecContext(ctx, exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all for friend_requests")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected for friend_requests")
+	}
+
+	return rowsAff, nil
+}
+
+// UpdateAll updates all rows with the specified column values, using an executor.
+func (o FriendRequestSlice) UpdateAll(ctx context.Context, exec boil.ContextExecutor, cols M) (int64, error) {
+	ln := int64(len(o))
+	if ln == 0 {
+		return 0, nil
+	}
+
+	if len(cols) == 0 {
+		return 0, errors.New("models: update all requires at least one column argument")
+	}
+
+	colNames := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+
+	i := 0
+	for name, value := range cols {
+		colNames[i] = name
+		args[i] = value
+		i++
+	}
+
+	// Append all of the primary key values for each column
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), friendRequestPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := fmt.Sprintf("UPDATE \"friend_requests\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, colNames),
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), len(colNames)+1, friendRequestPrimaryKeyColumns, len(o)))
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, args...)
+	}
+	result, err := exec.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all in friendRequest slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected all in update all friendRequest")
+	}
+	return rowsAff, nil
+}
+
+// Upsert attempts an insert using an executor, and does an update or ignore on conflict.
+// See boil.Columns documentation for how to properly use updateColumns and insertColumns.
+func (o *FriendRequest) Upsert(ctx context.Context, exec boil.ContextExecutor, updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no friend_requests provided for upsert")
+	}
+	if !boil.TimestampsAreSkipped(ctx) {
+		currTime := time.Now().In(boil.GetLocation())
+
+		if o.CreatedAt.IsZero() {
+			o.CreatedAt = currTime
+		}
+		o.UpdatedAt = currTime
+	}
+
+	if err := o.doBeforeUpsertHooks(ctx, exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(friendRequestColumnsWithDefault, o)
+
+	// Build cache key in-line uglily - mysql vs psql problems
+	buf := strmangle.GetBuffer()
+	if updateOnConflict {
+		buf.WriteByte('t')
+	} else {
+		buf.WriteByte('f')
+	}
+	buf.WriteByte('.')
+	for _, c := range conflictColumns {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(updateColumns.Kind))
+	for _, c := range updateColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(insertColumns.Kind))
+	for _, c := range insertColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	for _, c := range nzDefaults {
+		buf.WriteString(c)
+	}
+	key := buf.String()
+	strmangle.PutBuffer(buf)
+
+	friendRequestUpsertCacheMut.RLock()
+	cache, cached := friendRequestUpsertCache[key]
+	friendRequestUpsertCacheMut.RUnlock()
+
+	var err error
+
+	if !cached {
+		insert, ret := insertColumns.InsertColumnSet(
+			friendRequestAllColumns,
+			friendRequestColumnsWithDefault,
+			friendRequestColumnsWithoutDefault,
+			nzDefaults,
+		)
+		update := updateColumns.UpdateColumnSet(
+			friendRequestAllColumns,
+			friendRequestPrimaryKeyColumns,
+		)
+
+		if updateOnConflict && len(update) == 0 {
+			return errors.New("models: unable to upsert friend_requests, could not build update column list")
+		}
+
+		conflict := conflictColumns
+		if len(conflict) == 0 {
+			conflict = make([]string, len(friendRequestPrimaryKeyColumns))
+			copy(conflict, friendRequestPrimaryKeyColumns)
+		}
+		cache.query = buildUpsertQueryPostgres(dialect, "\"friend_requests\"", updateOnConflict, ret, update, conflict, insert)
+
+		cache.valueMapping, err = queries.BindMapping(friendRequestType, friendRequestMapping, insert)
+		if err != nil {
+			return err
+		}
+		if len(ret) != 0 {
+			cache.retMapping, err = queries.BindMapping(friendRequestType, friendRequestMapping, ret)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+	var returns []interface{}
+	if len(cache.retMapping) != 0 {
+		returns = queries.PtrsFromMapping(value, cache.retMapping)
+	}
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, cache.query)
+		fmt.Fprintln(writer, vals)
+	}
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRowContext(ctx, cache.query, vals...).Scan(returns...)
+		if err == sql.ErrNoRows {
+			err = nil // Postgres doesn't return anything when there's no update
+		}
+	} else {
+		_, err = exec.ExecContext(ctx, cache.query, vals...)
+	}
+	if err != nil {
+		return errors.Wrap(err, "models: unable to upsert friend_requests")
+	}
+
+	if !cached {
+		friendRequestUpsertCacheMut.Lock()
+		friendRequestUpsertCache[key] = cache
+		friendRequestUpsertCacheMut.Unlock()
+	}
+
+	return o.doAfterUpsertHooks(ctx, exec)
+}
+
+// Delete deletes a single FriendRequest record with an executor.
+// Delete will match against the primary key column to find the record to delete.
+func (o *FriendRequest) Delete(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	if o == nil {
+		return 0, errors.New("models: no FriendRequest provided for delete")
+	}
+
+	if err := o.doBeforeDeleteHooks(ctx, exec); err != nil {
+		return 0, err
+	}
+
+	args := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), friendRequestPrimaryKeyMapping)
+	sql := "DELETE FROM \"friend_requests\" WHERE \"id\"=$1"
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, args...)
+	}
+	result, err := exec.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete from friend_requests")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by delete for friend_requests")
+	}
+
+	if err := o.doAfterDeleteHooks(ctx, exec); err != nil {
+		return 0, err
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAll deletes all matching rows.
+func (q friendRequestQuery) DeleteAll(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	if q.Query == nil {
+		return 0, errors.New("models: no friendRequestQuery provided for delete all")
+	}
+
+	queries.SetDelete(q.Query)
+
+	result, err := q.Query.ExecContext(ctx, exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from friend_requests")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for friend_requests")
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAll deletes all rows in the slice, using an executor.
+func (o FriendRequestSlice) DeleteAll(ctx context.Context, exec boil.ContextExecutor) (int64, error) {
+	if len(o) == 0 {
+		return 0, nil
+	}
+
+	if len(friendRequestBeforeDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doBeforeDeleteHooks(ctx, exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	var args []interface{}
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), friendRequestPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "DELETE FROM \"friend_requests\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, friendRequestPrimaryKeyColumns, len(o))
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, args)
+	}
+	result, err := exec.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from friendRequest slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for friend_requests")
+	}
+
+	if len(friendRequestAfterDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterDeleteHooks(ctx, exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return rowsAff, nil
+}
+
+// Reload refetches the object from the database
+// using the primary keys with an executor.
+func (o *FriendRequest) Reload(ctx context.Context, exec boil.ContextExecutor) error {
+	ret, err := FindFriendRequest(ctx, exec, o.ID)
+	if err != nil {
+		return err
+	}
+
+	*o = *ret
+	return nil
+}
+
+// ReloadAll refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *FriendRequestSlice) ReloadAll(ctx context.Context, exec boil.ContextExecutor) error {
+	if o == nil || len(*o) == 0 {
+		return nil
+	}
+
+	slice := FriendRequestSlice{}
+	var args []interface{}
+	for _, obj := range *o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), friendRequestPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "SELECT \"friend_requests\".* FROM \"friend_requests\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, friendRequestPrimaryKeyColumns, len(*o))
+
+	q := queries.Raw(sql, args...)
+
+	err := q.Bind(ctx, exec, &slice)
+	if err != nil {
+		return errors.Wrap(err, "models: unable to reload all in FriendRequestSlice")
+	}
+
+	*o = slice
+
+	return nil
+}
+
+// FriendRequestExists checks if the FriendRequest row exists.
+func FriendRequestExists(ctx context.Context, exec boil.ContextExecutor, iD int) (bool, error) {
+	var exists bool
+	sql := "select exists(select 1 from \"friend_requests\" where \"id\"=$1 limit 1)"
+
+	if boil.IsDebug(ctx) {
+		writer := boil.DebugWriterFrom(ctx)
+		fmt.Fprintln(writer, sql)
+		fmt.Fprintln(writer, iD)
+	}
+	row := exec.QueryRowContext(ctx, sql, iD)
+
+	err := row.Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "models: unable to check if friend_requests exists")
+	}
+
+	return exists, nil
+}
@@ -63,50 +63,6 @@ var UserTableColumns = struct {
 
 // Generated where
 
-type whereHelperstring struct{ field string }
-
-func (w whereHelperstring) EQ(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
-func (w whereHelperstring) NEQ(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
-func (w whereHelperstring) LT(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
-func (w whereHelperstring) LTE(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
-func (w whereHelperstring) GT(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
-func (w whereHelperstring) GTE(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
-func (w whereHelperstring) IN(slice []string) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
-}
-func (w whereHelperstring) NIN(slice []string) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
-}
-
-type whereHelpertime_Time struct{ field string }
-
-func (w whereHelpertime_Time) EQ(x time.Time) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.EQ, x)
-}
-func (w whereHelpertime_Time) NEQ(x time.Time) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.NEQ, x)
-}
-func (w whereHelpertime_Time) LT(x time.Time) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.LT, x)
-}
-func (w whereHelpertime_Time) LTE(x time.Time) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.LTE, x)
-}
-func (w whereHelpertime_Time) GT(x time.Time) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.GT, x)
-}
-func (w whereHelpertime_Time) GTE(x time.Time) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.GTE, x)
-}
-
 var UserWhere = struct {
 	ID        whereHelperint
 	Name      whereHelperstring
@@ -123,6 +79,8 @@ var UserWhere = struct {
 
 // UserRels is where relationship names are stored.
 var UserRels = struct {
+	RequestorFriendRequests            string
+	TargetFriendRequests               string
 	FriendFriends                      string
 	Friends                            string
 	SubscriptionRequestorSubscriptions string
@@ -130,6 +88,8 @@ var UserRels = struct {
 	RequestorUserBlocks                string
 	TargetUserBlocks                   string
 }{
+	RequestorFriendRequests:            "RequestorFriendRequests",
+	TargetFriendRequests:               "TargetFriendRequests",
 	FriendFriends:                      "FriendFriends",
 	Friends:                            "Friends",
 	SubscriptionRequestorSubscriptions: "SubscriptionRequestorSubscriptions",
@@ -140,12 +100,14 @@ var UserRels = struct {
 
 // userR is where relationships are stored.
 type userR struct {
-	FriendFriends                      FriendSlice       `boil:"FriendFriends" json:"FriendFriends" toml:"FriendFriends" yaml:"FriendFriends"`
-	Friends                            FriendSlice       `boil:"Friends" json:"Friends" toml:"Friends" yaml:"Friends"`
-	SubscriptionRequestorSubscriptions SubscriptionSlice `boil:"SubscriptionRequestorSubscriptions" json:"SubscriptionRequestorSubscriptions" toml:"SubscriptionRequestorSubscriptions" yaml:"SubscriptionRequestorSubscriptions"`
-	SubscriptionTargetSubscriptions    SubscriptionSlice `boil:"SubscriptionTargetSubscriptions" json:"SubscriptionTargetSubscriptions" toml:"SubscriptionTargetSubscriptions" yaml:"SubscriptionTargetSubscriptions"`
-	RequestorUserBlocks                UserBlockSlice    `boil:"RequestorUserBlocks" json:"RequestorUserBlocks" toml:"RequestorUserBlocks" yaml:"RequestorUserBlocks"`
-	TargetUserBlocks                   UserBlockSlice    `boil:"TargetUserBlocks" json:"TargetUserBlocks" toml:"TargetUserBlocks" yaml:"TargetUserBlocks"`
+	RequestorFriendRequests            FriendRequestSlice `boil:"RequestorFriendRequests" json:"RequestorFriendRequests" toml:"RequestorFriendRequests" yaml:"RequestorFriendRequests"`
+	TargetFriendRequests               FriendRequestSlice `boil:"TargetFriendRequests" json:"TargetFriendRequests" toml:"TargetFriendRequests" yaml:"TargetFriendRequests"`
+	FriendFriends                      FriendSlice        `boil:"FriendFriends" json:"FriendFriends" toml:"FriendFriends" yaml:"FriendFriends"`
+	Friends                            FriendSlice        `boil:"Friends" json:"Friends" toml:"Friends" yaml:"Friends"`
+	SubscriptionRequestorSubscriptions SubscriptionSlice  `boil:"SubscriptionRequestorSubscriptions" json:"SubscriptionRequestorSubscriptions" toml:"SubscriptionRequestorSubscriptions" yaml:"SubscriptionRequestorSubscriptions"`
+	SubscriptionTargetSubscriptions    SubscriptionSlice  `boil:"SubscriptionTargetSubscriptions" json:"SubscriptionTargetSubscriptions" toml:"SubscriptionTargetSubscriptions" yaml:"SubscriptionTargetSubscriptions"`
+	RequestorUserBlocks                UserBlockSlice     `boil:"RequestorUserBlocks" json:"RequestorUserBlocks" toml:"RequestorUserBlocks" yaml:"RequestorUserBlocks"`
+	TargetUserBlocks                   UserBlockSlice     `boil:"TargetUserBlocks" json:"TargetUserBlocks" toml:"TargetUserBlocks" yaml:"TargetUserBlocks"`
 }
 
 // NewStruct creates a new relationship struct
@@ -438,6 +400,48 @@ func (q userQuery) Exists(ctx context.Context, exec boil.ContextExecutor) (bool,
 	return count > 0, nil
 }
 
+// RequestorFriendRequests retrieves all the friend_request's FriendRequests with an executor via requestor_id column.
+func (o *User) RequestorFriendRequests(mods ...qm.QueryMod) friendRequestQuery {
+	var queryMods []qm.QueryMod
+	if len(mods) != 0 {
+		queryMods = append(queryMods, mods...)
+	}
+
+	queryMods = append(queryMods,
+		qm.Where("\"friend_requests\".\"requestor_id\"=?", o.ID),
+	)
+
+	query := FriendRequests(queryMods...)
+	queries.SetFrom(query.Query, "\"friend_requests\"")
+
+	if len(queries.GetSelect(query.Query)) == 0 {
+		queries.SetSelect(query.Query, []string{"\"friend_requests\".*"})
+	}
+
+	return query
+}
+
+// TargetFriendRequests retrieves all the friend_request's FriendRequests with an executor via target_id column.
+func (o *User) TargetFriendRequests(mods ...qm.QueryMod) friendRequestQuery {
+	var queryMods []qm.QueryMod
+	if len(mods) != 0 {
+		queryMods = append(queryMods, mods...)
+	}
+
+	queryMods = append(queryMods,
+		qm.Where("\"friend_requests\".\"target_id\"=?", o.ID),
+	)
+
+	query := FriendRequests(queryMods...)
+	queries.SetFrom(query.Query, "\"friend_requests\"")
+
+	if len(queries.GetSelect(query.Query)) == 0 {
+		queries.SetSelect(query.Query, []string{"\"friend_requests\".*"})
+	}
+
+	return query
+}
+
 // FriendFriends retrieves all the friend's Friends with an executor via friend_id column.
 func (o *User) FriendFriends(mods ...qm.QueryMod) friendQuery {
 	var queryMods []qm.QueryMod
@@ -564,6 +568,202 @@ func (o *User) TargetUserBlocks(mods ...qm.QueryMod) userBlockQuery {
 	return query
 }
 
+// LoadRequestorFriendRequests allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for a 1-M or N-M relationship.
+func (userL) LoadRequestorFriendRequests(ctx context.Context, e boil.ContextExecutor, singular bool, maybeUser interface{}, mods queries.Applicator) error {
+	var slice []*User
+	var object *User
+
+	if singular {
+		object = maybeUser.(*User)
+	} else {
+		slice = *maybeUser.(*[]*User)
+	}
+
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &userR{}
+		}
+		args = append(args, object.ID)
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &userR{}
+			}
+
+			for _, a := range args {
+				if a == obj.ID {
+					continue Outer
+				}
+			}
+
+			args = append(args, obj.ID)
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`friend_requests`),
+		qm.WhereIn(`friend_requests.requestor_id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load friend_requests")
+	}
+
+	var resultSlice []*FriendRequest
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice friend_requests")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results in eager load on friend_requests")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for friend_requests")
+	}
+
+	if len(friendRequestAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+	if singular {
+		object.R.RequestorFriendRequests = resultSlice
+		for _, foreign := range resultSlice {
+			if foreign.R == nil {
+				foreign.R = &friendRequestR{}
+			}
+			foreign.R.Requestor = object
+		}
+		return nil
+	}
+
+	for _, foreign := range resultSlice {
+		for _, local := range slice {
+			if local.ID == foreign.RequestorID {
+				local.R.RequestorFriendRequests = append(local.R.RequestorFriendRequests, foreign)
+				if foreign.R == nil {
+					foreign.R = &friendRequestR{}
+				}
+				foreign.R.Requestor = local
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// LoadTargetFriendRequests allows an eager lookup of values, cached into the
+// loaded structs of the objects. This is for a 1-M or N-M relationship.
+func (userL) LoadTargetFriendRequests(ctx context.Context, e boil.ContextExecutor, singular bool, maybeUser interface{}, mods queries.Applicator) error {
+	var slice []*User
+	var object *User
+
+	if singular {
+		object = maybeUser.(*User)
+	} else {
+		slice = *maybeUser.(*[]*User)
+	}
+
+	args := make([]interface{}, 0, 1)
+	if singular {
+		if object.R == nil {
+			object.R = &userR{}
+		}
+		args = append(args, object.ID)
+	} else {
+	Outer:
+		for _, obj := range slice {
+			if obj.R == nil {
+				obj.R = &userR{}
+			}
+
+			for _, a := range args {
+				if a == obj.ID {
+					continue Outer
+				}
+			}
+
+			args = append(args, obj.ID)
+		}
+	}
+
+	if len(args) == 0 {
+		return nil
+	}
+
+	query := NewQuery(
+		qm.From(`friend_requests`),
+		qm.WhereIn(`friend_requests.target_id in ?`, args...),
+	)
+	if mods != nil {
+		mods.Apply(query)
+	}
+
+	results, err := query.QueryContext(ctx, e)
+	if err != nil {
+		return errors.Wrap(err, "failed to eager load friend_requests")
+	}
+
+	var resultSlice []*FriendRequest
+	if err = queries.Bind(results, &resultSlice); err != nil {
+		return errors.Wrap(err, "failed to bind eager loaded slice friend_requests")
+	}
+
+	if err = results.Close(); err != nil {
+		return errors.Wrap(err, "failed to close results in eager load on friend_requests")
+	}
+	if err = results.Err(); err != nil {
+		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for friend_requests")
+	}
+
+	if len(friendRequestAfterSelectHooks) != 0 {
+		for _, obj := range resultSlice {
+			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
+				return err
+			}
+		}
+	}
+	if singular {
+		object.R.TargetFriendRequests = resultSlice
+		for _, foreign := range resultSlice {
+			if foreign.R == nil {
+				foreign.R = &friendRequestR{}
+			}
+			foreign.R.Target = object
+		}
+		return nil
+	}
+
+	for _, foreign := range resultSlice {
+		for _, local := range slice {
+			if local.ID == foreign.TargetID {
+				local.R.TargetFriendRequests = append(local.R.TargetFriendRequests, foreign)
+				if foreign.R == nil {
+					foreign.R = &friendRequestR{}
+				}
+				foreign.R.Target = local
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
 // LoadFriendFriends allows an eager lookup of values, cached into the
 // loaded structs of the objects. This is for a 1-M or N-M relationship.
 func (userL) LoadFriendFriends(ctx context.Context, e boil.ContextExecutor, singular bool, maybeUser interface{}, mods queries.Applicator) error {
@@ -1152,6 +1352,112 @@ func (userL) LoadTargetUserBlocks(ctx context.Context, e boil.ContextExecutor, s
 	return nil
 }
 
+// AddRequestorFriendRequests adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.RequestorFriendRequests.
+// Sets related.R.Requestor appropriately.
+func (o *User) AddRequestorFriendRequests(ctx context.Context, exec boil.ContextExecutor, insert bool, related ...*FriendRequest) error {
+	var err error
+	for _, rel := range related {
+		if insert {
+			rel.RequestorID = o.ID
+			if err = rel.Insert(ctx, exec, boil.Infer()); err != nil {
+				return errors.Wrap(err, "failed to insert into foreign table")
+			}
+		} else {
+			updateQuery := fmt.Sprintf(
+				"UPDATE \"friend_requests\" SET %s WHERE %s",
+				strmangle.SetParamNames("\"", "\"", 1, []string{"requestor_id"}),
+				strmangle.WhereClause("\"", "\"", 2, friendRequestPrimaryKeyColumns),
+			)
+			values := []interface{}{o.ID, rel.ID}
+
+			if boil.IsDebug(ctx) {
+				writer := boil.DebugWriterFrom(ctx)
+				fmt.Fprintln(writer, updateQuery)
+				fmt.Fprintln(writer, values)
+			}
+			if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+				return errors.Wrap(err, "failed to update foreign table")
+			}
+
+			rel.RequestorID = o.ID
+		}
+	}
+
+	if o.R == nil {
+		o.R = &userR{
+			RequestorFriendRequests: related,
+		}
+	} else {
+		o.R.RequestorFriendRequests = append(o.R.RequestorFriendRequests, related...)
+	}
+
+	for _, rel := range related {
+		if rel.R == nil {
+			rel.R = &friendRequestR{
+				Requestor: o,
+			}
+		} else {
+			rel.R.Requestor = o
+		}
+	}
+	return nil
+}
+
+// AddTargetFriendRequests adds the given related objects to the existing relationships
+// of the user, optionally inserting them as new records.
+// Appends related to o.R.TargetFriendRequests.
+// Sets related.R.Target appropriately.
+func (o *User) AddTargetFriendRequests(ctx context.Context, exec boil.ContextExecutor, insert bool, related ...*FriendRequest) error {
+	var err error
+	for _, rel := range related {
+		if insert {
+			rel.TargetID = o.ID
+			if err = rel.Insert(ctx, exec, boil.Infer()); err != nil {
+				return errors.Wrap(err, "failed to insert into foreign table")
+			}
+		} else {
+			updateQuery := fmt.Sprintf(
+				"UPDATE \"friend_requests\" SET %s WHERE %s",
+				strmangle.SetParamNames("\"", "\"", 1, []string{"target_id"}),
+				strmangle.WhereClause("\"", "\"", 2, friendRequestPrimaryKeyColumns),
+			)
+			values := []interface{}{o.ID, rel.ID}
+
+			if boil.IsDebug(ctx) {
+				writer := boil.DebugWriterFrom(ctx)
+				fmt.Fprintln(writer, updateQuery)
+				fmt.Fprintln(writer, values)
+			}
+			if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
+				return errors.Wrap(err, "failed to update foreign table")
+			}
+
+			rel.TargetID = o.ID
+		}
+	}
+
+	if o.R == nil {
+		o.R = &userR{
+			TargetFriendRequests: related,
+		}
+	} else {
+		o.R.TargetFriendRequests = append(o.R.TargetFriendRequests, related...)
+	}
+
+	for _, rel := range related {
+		if rel.R == nil {
+			rel.R = &friendRequestR{
+				Target: o,
+			}
+		} else {
+			rel.R.Target = o
+		}
+	}
+	return nil
+}
+
 // AddFriendFriends adds the given related objects to the existing relationships
 // of the user, optionally inserting them as new records.
 // Appends related to o.R.FriendFriends.
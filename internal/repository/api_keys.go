@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// Insert a new record into api_keys table, ErrExistedAPIKey is returned when the name or the hash has been used
+func (_self DBRepo) CreateAPIKey(ctx context.Context, name string, keyHash string) (*models.APIKey, error) {
+	apiKey := models.APIKey{
+		Name:      name,
+		KeyHash:   keyHash,
+		CreatedAt: time.Now(),
+	}
+	// The insert is ignored on conflict so no id is returned
+	if err := apiKey.Upsert(ctx, _self.exec(), false, nil, boil.None(), boil.Infer()); err != nil {
+		return nil, err
+	}
+	if apiKey.ID == 0 {
+		return nil, ErrExistedAPIKey
+	}
+	return &apiKey, nil
+}
+
+// Get a key which has not been revoked by the hash of the key
+func (_self DBRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	apiKey, err := models.APIKeys(
+		models.APIKeyWhere.KeyHash.EQ(keyHash),
+		models.APIKeyWhere.RevokedAt.IsNull(),
+	).One(ctx, _self.exec())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotExistedAPIKey
+	}
+	return apiKey, err
+}
+
+// Get all keys including revoked keys in order of creation
+func (_self DBRepo) GetAPIKeys(ctx context.Context) (models.APIKeySlice, error) {
+	return models.APIKeys(qm.OrderBy(models.APIKeyColumns.ID)).All(ctx, _self.exec())
+}
+
+// Revoke a key by its name, ErrNotExistedAPIKey is returned when the key does not exist or has been revoked
+func (_self DBRepo) RevokeAPIKey(ctx context.Context, name string) error {
+	rowsAff, err := models.APIKeys(
+		models.APIKeyWhere.Name.EQ(name),
+		models.APIKeyWhere.RevokedAt.IsNull(),
+	).UpdateAll(ctx, _self.exec(), models.M{
+		models.APIKeyColumns.RevokedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return ErrNotExistedAPIKey
+	}
+	return nil
+}
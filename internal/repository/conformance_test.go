@@ -0,0 +1,459 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+// newRepoFunc creates a repository without any data for a conformance test
+type newRepoFunc func(t *testing.T) SpecRepo
+
+func TestConformance_DBRepo(t *testing.T) {
+	runConformanceSuite(t, func(t *testing.T) SpecRepo {
+		db, err := openTestDatabase()
+		require.NoError(t, err)
+		loadSqlTestFile(t, db, "testdata/empty.sql")
+		return NewDBRepo(db)
+	})
+}
+
+func TestConformance_MemoryRepo(t *testing.T) {
+	runConformanceSuite(t, func(t *testing.T) SpecRepo {
+		return NewMemoryRepo()
+	})
+}
+
+// seedConformanceGraph will be creating the same users and relationships as testdata/friends.sql through SpecRepo,
+// ids of users are returned by their names
+func seedConformanceGraph(t *testing.T, ctx context.Context, repo SpecRepo) map[string]int {
+	ids := make(map[string]int)
+	for _, name := range []string{"john", "andy", "common", "lisa", "kate"} {
+		user, err := repo.CreateUser(ctx, name, name+"@example.com")
+		require.NoError(t, err)
+		ids[name] = user.ID
+	}
+
+	require.NoError(t, repo.CreateFriend(ctx, ids["john"], ids["common"]))
+	require.NoError(t, repo.CreateFriend(ctx, ids["andy"], ids["common"]))
+	require.NoError(t, repo.CreateFriend(ctx, ids["common"], ids["lisa"]))
+	require.NoError(t, repo.CreateUserBlock(ctx, ids["john"], ids["lisa"]))
+	require.NoError(t, repo.CreateUserBlock(ctx, ids["john"], ids["kate"]))
+	require.NoError(t, repo.CreateSubscription(ctx, ids["andy"], ids["lisa"]))
+
+	require.NoError(t, repo.CreateFriendRequest(ctx, ids["lisa"], ids["kate"]))
+	require.NoError(t, repo.CreateFriendRequest(ctx, ids["kate"], ids["andy"]))
+	require.NoError(t, repo.CreateFriendRequest(ctx, ids["andy"], ids["john"]))
+	rejected, err := repo.GetPendingFriendRequest(ctx, ids["andy"], ids["john"])
+	require.NoError(t, err)
+	require.NoError(t, repo.UpdateFriendRequestStatus(ctx, rejected.ID, FriendRequestRejected))
+	require.NoError(t, repo.CreateFriendRequest(ctx, ids["lisa"], ids["andy"]))
+	return ids
+}
+
+// Get pairs of user ids of friendships
+func friendPairs(friends models.FriendSlice) [][2]int {
+	pairs := make([][2]int, len(friends))
+	for i, friend := range friends {
+		pairs[i] = [2]int{friend.UserID, friend.FriendID}
+	}
+	return pairs
+}
+
+// runConformanceSuite will be verifying that a SpecRepo implementation has the same semantics as DBRepo
+func runConformanceSuite(t *testing.T, newRepo newRepoFunc) {
+	tcs := map[string]func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int){
+		"create and get users by case-insensitive email": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			user, err := repo.GetUserByEmail(ctx, "JOHN@Example.com")
+			require.NoError(t, err)
+			require.Equal(t, ids["john"], user.ID)
+			require.Equal(t, "john", user.Name)
+			require.Equal(t, "john@example.com", user.Email)
+
+			userId, err := repo.GetUserIDByEmail(ctx, "Andy@EXAMPLE.com")
+			require.NoError(t, err)
+			require.Equal(t, ids["andy"], userId)
+
+			isExisted, err := repo.IsExistedUser(ctx, "lisa@example.COM")
+			require.NoError(t, err)
+			require.True(t, isExisted)
+
+			_, err = repo.CreateUser(ctx, "john", " John@Example.com ")
+			require.Equal(t, ErrExistedUser, err)
+
+			created, err := repo.CreateUser(ctx, "tom", " Tom@Example.COM ")
+			require.NoError(t, err)
+			require.Equal(t, "Tom@example.com", created.Email)
+			require.False(t, created.CreatedAt.IsZero())
+
+			_, err = repo.GetUserByEmail(ctx, "unknown@example.com")
+			require.Equal(t, ErrNotExistedUser, err)
+			_, err = repo.GetUserIDByEmail(ctx, "unknown@example.com")
+			require.Equal(t, ErrNotExistedUser, err)
+			isExisted, err = repo.IsExistedUser(ctx, "unknown@example.com")
+			require.NoError(t, err)
+			require.False(t, isExisted)
+		},
+		"update name of users": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			require.NoError(t, repo.UpdateUserName(ctx, ids["john"], "johnny"))
+			user, err := repo.GetUserByEmail(ctx, "john@example.com")
+			require.NoError(t, err)
+			require.Equal(t, "johnny", user.Name)
+
+			require.Equal(t, ErrNotExistedUser, repo.UpdateUserName(ctx, ids["kate"]+100, "nobody"))
+		},
+		"list and count users": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			users, err := repo.GetUsers(ctx, 0, 2)
+			require.NoError(t, err)
+			require.Len(t, users, 2)
+			require.Equal(t, "john@example.com", users[0].Email)
+			require.Equal(t, "andy@example.com", users[1].Email)
+
+			users, err = repo.GetUsers(ctx, ids["andy"], 10)
+			require.NoError(t, err)
+			require.Len(t, users, 3)
+			require.Equal(t, "common@example.com", users[0].Email)
+			require.Equal(t, "kate@example.com", users[2].Email)
+
+			count, err := repo.CountUsers(ctx)
+			require.NoError(t, err)
+			require.Equal(t, int64(5), count)
+
+			users, err = repo.GetUsersByIDs(ctx, []int{ids["andy"], ids["john"], ids["kate"] + 100})
+			require.NoError(t, err)
+			require.Len(t, users, 2)
+			require.ElementsMatch(t, []string{"john@example.com", "andy@example.com"}, []string{users[0].Email, users[1].Email})
+
+			emails, err := repo.GetEmailsByUserIDs(ctx, []int{ids["andy"], ids["john"]})
+			require.NoError(t, err)
+			require.Equal(t, []string{"john@example.com", "andy@example.com"}, emails)
+
+			emails, err = repo.GetEmailsByUserIDs(ctx, []int{})
+			require.NoError(t, err)
+			require.Equal(t, []string{}, emails)
+		},
+		"create and delete friendships regardless of order of users": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			require.NoError(t, repo.CreateFriend(ctx, ids["lisa"], ids["john"]))
+			require.Equal(t, ErrExistedFriendship, repo.CreateFriend(ctx, ids["john"], ids["lisa"]))
+
+			isExisted, err := repo.IsExistedFriend(ctx, ids["john"], ids["lisa"])
+			require.NoError(t, err)
+			require.True(t, isExisted)
+
+			err = repo.CreateFriend(ctx, ids["john"], ids["john"])
+			require.Error(t, err)
+			err = repo.CreateFriend(ctx, ids["john"], ids["kate"]+100)
+			require.Error(t, err)
+			require.NotEqual(t, ErrExistedFriendship, err)
+
+			require.NoError(t, repo.DeleteFriend(ctx, ids["common"], ids["john"]))
+			require.Equal(t, ErrNotExistedFriendship, repo.DeleteFriend(ctx, ids["john"], ids["common"]))
+			isExisted, err = repo.IsExistedFriend(ctx, ids["common"], ids["john"])
+			require.NoError(t, err)
+			require.False(t, isExisted)
+		},
+		"get friendships in the canonical order": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			friends, err := repo.GetFriendsByID(ctx, ids["common"])
+			require.NoError(t, err)
+			require.ElementsMatch(t, [][2]int{
+				{ids["john"], ids["common"]},
+				{ids["andy"], ids["common"]},
+				{ids["common"], ids["lisa"]},
+			}, friendPairs(friends))
+
+			require.NoError(t, repo.CreateFriend(ctx, ids["kate"], ids["john"]))
+			friends, err = repo.GetUnblockedFriendsByIDs(ctx, []int{ids["lisa"], ids["kate"]})
+			require.NoError(t, err)
+			require.ElementsMatch(t, [][2]int{{ids["common"], ids["lisa"]}}, friendPairs(friends))
+
+			friends, err = repo.GetUnblockedFriendsByIDs(ctx, []int{})
+			require.NoError(t, err)
+			require.Len(t, friends, 0)
+		},
+		"list pages of unblocked friends": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			page, err := repo.GetUnblockedFriendEmails(ctx, ids["common"], 0, 2)
+			require.NoError(t, err)
+			require.Equal(t, FriendPage{Emails: []string{"john@example.com", "andy@example.com"}, Total: 3, NextAfterID: ids["andy"]}, page)
+
+			page, err = repo.GetUnblockedFriendEmails(ctx, ids["common"], ids["andy"], 2)
+			require.NoError(t, err)
+			require.Equal(t, FriendPage{Emails: []string{"lisa@example.com"}, Total: 3}, page)
+
+			page, err = repo.GetUnblockedFriendEmails(ctx, ids["common"], ids["lisa"], 2)
+			require.NoError(t, err)
+			require.Equal(t, FriendPage{Emails: []string{}, Total: 3}, page)
+
+			require.NoError(t, repo.CreateFriend(ctx, ids["john"], ids["lisa"]))
+			page, err = repo.GetUnblockedFriendEmails(ctx, ids["john"], 0, 10)
+			require.NoError(t, err)
+			require.Equal(t, FriendPage{Emails: []string{"common@example.com"}, Total: 1}, page)
+		},
+		"list pages of common friends": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			page, err := repo.GetCommonFriendEmails(ctx, ids["john"], ids["andy"], 0, 10)
+			require.NoError(t, err)
+			require.Equal(t, FriendPage{Emails: []string{"common@example.com"}, Total: 1}, page)
+
+			require.NoError(t, repo.CreateUserBlock(ctx, ids["common"], ids["andy"]))
+			page, err = repo.GetCommonFriendEmails(ctx, ids["john"], ids["andy"], 0, 10)
+			require.NoError(t, err)
+			require.Equal(t, FriendPage{Emails: []string{}}, page)
+		},
+		"create and delete subscriptions": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			require.Equal(t, ErrExistedSubscription, repo.CreateSubscription(ctx, ids["andy"], ids["lisa"]))
+
+			isSubscribed, err := repo.IsSubscribedUser(ctx, ids["lisa"], ids["andy"])
+			require.NoError(t, err)
+			require.True(t, isSubscribed)
+
+			require.Equal(t, ErrNotExistedSubscription, repo.DeleteSubscription(ctx, ids["lisa"], ids["andy"]))
+			require.NoError(t, repo.DeleteSubscription(ctx, ids["andy"], ids["lisa"]))
+			isSubscribed, err = repo.IsSubscribedUser(ctx, ids["andy"], ids["lisa"])
+			require.NoError(t, err)
+			require.False(t, isSubscribed)
+
+			require.Error(t, repo.CreateSubscription(ctx, ids["andy"], ids["kate"]+100))
+		},
+		"create and delete blocking relationships": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			require.Equal(t, ErrExistedBlockedUser, repo.CreateUserBlock(ctx, ids["john"], ids["lisa"]))
+
+			isBlocked, err := repo.IsBlockedUser(ctx, ids["lisa"], ids["john"])
+			require.NoError(t, err)
+			require.True(t, isBlocked)
+
+			userBlocks, err := repo.GetUserBlocksByID(ctx, ids["kate"])
+			require.NoError(t, err)
+			require.Len(t, userBlocks, 1)
+			require.Equal(t, ids["john"], userBlocks[0].RequestorID)
+			require.Equal(t, ids["kate"], userBlocks[0].TargetID)
+
+			require.Equal(t, ErrNotExistedBlockedUser, repo.DeleteUserBlock(ctx, ids["lisa"], ids["john"]))
+			require.NoError(t, repo.DeleteUserBlock(ctx, ids["john"], ids["lisa"]))
+			isBlocked, err = repo.IsBlockedUser(ctx, ids["john"], ids["lisa"])
+			require.NoError(t, err)
+			require.False(t, isBlocked)
+		},
+		"get recipients without blocked users": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			require.NoError(t, repo.CreateFriend(ctx, ids["john"], ids["lisa"]))
+			require.NoError(t, repo.CreateSubscription(ctx, ids["kate"], ids["lisa"]))
+			require.NoError(t, repo.CreateSubscription(ctx, ids["common"], ids["lisa"]))
+			require.NoError(t, repo.CreateUserBlock(ctx, ids["lisa"], ids["kate"]))
+
+			recipients, err := repo.GetRecipientEmails(ctx, ids["lisa"], "", 10)
+			require.NoError(t, err)
+			require.Equal(t, []string{"andy@example.com", "common@example.com"}, recipients)
+
+			recipients, err = repo.GetRecipientEmails(ctx, ids["lisa"], "", 1)
+			require.NoError(t, err)
+			require.Equal(t, []string{"andy@example.com"}, recipients)
+
+			recipients, err = repo.GetRecipientEmails(ctx, ids["lisa"], "andy@example.com", 1)
+			require.NoError(t, err)
+			require.Equal(t, []string{"common@example.com"}, recipients)
+		},
+		"get friend suggestions": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			suggestions, err := repo.GetFriendSuggestions(ctx, ids["john"])
+			require.NoError(t, err)
+			require.Equal(t, []FriendSuggestion{{Email: "andy@example.com", MutualFriends: 1}}, suggestions)
+
+			suggestions, err = repo.GetFriendSuggestions(ctx, ids["lisa"])
+			require.NoError(t, err)
+			require.Equal(t, []FriendSuggestion{}, suggestions)
+
+			require.NoError(t, repo.CreateFriend(ctx, ids["kate"], ids["lisa"]))
+			require.NoError(t, repo.CreateFriend(ctx, ids["kate"], ids["common"]))
+			suggestions, err = repo.GetFriendSuggestions(ctx, ids["andy"])
+			require.NoError(t, err)
+			require.Equal(t, []FriendSuggestion{
+				{Email: "john@example.com", MutualFriends: 1},
+			}, suggestions)
+
+			// Friends of friends are ranked by number of mutual friends and then by email
+			for _, name := range []string{"tom", "zoe", "amy"} {
+				user, err := repo.CreateUser(ctx, name, name+"@example.com")
+				require.NoError(t, err)
+				ids[name] = user.ID
+			}
+			require.NoError(t, repo.CreateFriend(ctx, ids["tom"], ids["andy"]))
+			require.NoError(t, repo.CreateFriend(ctx, ids["tom"], ids["john"]))
+			require.NoError(t, repo.CreateFriend(ctx, ids["tom"], ids["zoe"]))
+			require.NoError(t, repo.CreateFriend(ctx, ids["tom"], ids["amy"]))
+			suggestions, err = repo.GetFriendSuggestions(ctx, ids["andy"])
+			require.NoError(t, err)
+			require.Equal(t, []FriendSuggestion{
+				{Email: "john@example.com", MutualFriends: 2},
+				{Email: "amy@example.com", MutualFriends: 1},
+				{Email: "zoe@example.com", MutualFriends: 1},
+			}, suggestions)
+		},
+		"create and list friend requests": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			require.Equal(t, ErrExistedFriendRequest, repo.CreateFriendRequest(ctx, ids["lisa"], ids["kate"]))
+			require.NoError(t, repo.CreateFriendRequest(ctx, ids["kate"], ids["lisa"]))
+			require.Error(t, repo.CreateFriendRequest(ctx, ids["john"], ids["kate"]+100))
+
+			isPending, err := repo.IsPendingFriendRequest(ctx, ids["andy"], ids["kate"])
+			require.NoError(t, err)
+			require.True(t, isPending)
+			isPending, err = repo.IsPendingFriendRequest(ctx, ids["john"], ids["andy"])
+			require.NoError(t, err)
+			require.False(t, isPending)
+
+			_, err = repo.GetPendingFriendRequest(ctx, ids["andy"], ids["john"])
+			require.Equal(t, ErrNotExistedFriendRequest, err)
+			friendRequest, err := repo.GetPendingFriendRequest(ctx, ids["kate"], ids["andy"])
+			require.NoError(t, err)
+			require.Equal(t, FriendRequestPending, friendRequest.Status)
+
+			incoming, err := repo.GetIncomingFriendRequests(ctx, ids["andy"])
+			require.NoError(t, err)
+			require.Len(t, incoming, 2)
+			require.Equal(t, ids["kate"], incoming[0].RequestorID)
+			require.Equal(t, ids["lisa"], incoming[1].RequestorID)
+
+			outgoing, err := repo.GetOutgoingFriendRequests(ctx, ids["lisa"])
+			require.NoError(t, err)
+			require.Len(t, outgoing, 2)
+			require.Equal(t, ids["kate"], outgoing[0].TargetID)
+			require.Equal(t, ids["andy"], outgoing[1].TargetID)
+		},
+		"accept friend requests": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			friendRequest, err := repo.GetPendingFriendRequest(ctx, ids["kate"], ids["andy"])
+			require.NoError(t, err)
+			require.NoError(t, repo.AcceptFriendRequest(ctx, friendRequest.ID))
+			require.Equal(t, ErrNotExistedFriendRequest, repo.AcceptFriendRequest(ctx, friendRequest.ID))
+
+			isExisted, err := repo.IsExistedFriend(ctx, ids["andy"], ids["kate"])
+			require.NoError(t, err)
+			require.True(t, isExisted)
+			isPending, err := repo.IsPendingFriendRequest(ctx, ids["andy"], ids["kate"])
+			require.NoError(t, err)
+			require.False(t, isPending)
+
+			// A request of users who are already friends is not accepted and kept pending
+			friendRequest, err = repo.GetPendingFriendRequest(ctx, ids["lisa"], ids["kate"])
+			require.NoError(t, err)
+			require.NoError(t, repo.CreateFriend(ctx, ids["kate"], ids["lisa"]))
+			require.Equal(t, ErrExistedFriendship, repo.AcceptFriendRequest(ctx, friendRequest.ID))
+			isPending, err = repo.IsPendingFriendRequest(ctx, ids["lisa"], ids["kate"])
+			require.NoError(t, err)
+			require.True(t, isPending)
+		},
+		"change status of friend requests": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			friendRequest, err := repo.GetPendingFriendRequest(ctx, ids["lisa"], ids["andy"])
+			require.NoError(t, err)
+			require.NoError(t, repo.UpdateFriendRequestStatus(ctx, friendRequest.ID, FriendRequestCancelled))
+			require.Equal(t, ErrNotExistedFriendRequest, repo.UpdateFriendRequestStatus(ctx, friendRequest.ID, FriendRequestRejected))
+
+			outgoing, err := repo.GetOutgoingFriendRequests(ctx, ids["lisa"])
+			require.NoError(t, err)
+			require.Len(t, outgoing, 1)
+			require.Equal(t, ids["kate"], outgoing[0].TargetID)
+
+			// A new request can be sent after the previous one was closed
+			require.NoError(t, repo.CreateFriendRequest(ctx, ids["lisa"], ids["andy"]))
+		},
+		"create, get and revoke API keys": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			apiKeys := repo.(APIKeyStore)
+			ci, err := apiKeys.CreateAPIKey(ctx, "ci", "hash-ci")
+			require.NoError(t, err)
+			require.Equal(t, "ci", ci.Name)
+			require.Equal(t, "hash-ci", ci.KeyHash)
+			require.False(t, ci.RevokedAt.Valid)
+			_, err = apiKeys.CreateAPIKey(ctx, "ci", "hash-other")
+			require.Equal(t, ErrExistedAPIKey, err)
+			_, err = apiKeys.CreateAPIKey(ctx, "other", "hash-ci")
+			require.Equal(t, ErrExistedAPIKey, err)
+			_, err = apiKeys.CreateAPIKey(ctx, "backend", "hash-backend")
+			require.NoError(t, err)
+
+			apiKey, err := apiKeys.GetAPIKeyByHash(ctx, "hash-ci")
+			require.NoError(t, err)
+			require.Equal(t, ci.ID, apiKey.ID)
+			_, err = apiKeys.GetAPIKeyByHash(ctx, "hash-other")
+			require.Equal(t, ErrNotExistedAPIKey, err)
+
+			require.NoError(t, apiKeys.RevokeAPIKey(ctx, "ci"))
+			require.Equal(t, ErrNotExistedAPIKey, apiKeys.RevokeAPIKey(ctx, "ci"))
+			_, err = apiKeys.GetAPIKeyByHash(ctx, "hash-ci")
+			require.Equal(t, ErrNotExistedAPIKey, err)
+
+			all, err := apiKeys.GetAPIKeys(ctx)
+			require.NoError(t, err)
+			require.Len(t, all, 2)
+			require.Equal(t, "ci", all[0].Name)
+			require.True(t, all[0].RevokedAt.Valid)
+			require.Equal(t, "backend", all[1].Name)
+			require.False(t, all[1].RevokedAt.Valid)
+		},
+		"delete users with their relationships": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			require.NoError(t, repo.DeleteUser(ctx, ids["lisa"]))
+			require.Equal(t, ErrNotExistedUser, repo.DeleteUser(ctx, ids["lisa"]))
+
+			_, err := repo.GetUserIDByEmail(ctx, "lisa@example.com")
+			require.Equal(t, ErrNotExistedUser, err)
+
+			friends, err := repo.GetFriendsByID(ctx, ids["common"])
+			require.NoError(t, err)
+			require.Len(t, friends, 2)
+			isSubscribed, err := repo.IsSubscribedUser(ctx, ids["andy"], ids["lisa"])
+			require.NoError(t, err)
+			require.False(t, isSubscribed)
+			userBlocks, err := repo.GetUserBlocksByID(ctx, ids["john"])
+			require.NoError(t, err)
+			require.Len(t, userBlocks, 1)
+			incoming, err := repo.GetIncomingFriendRequests(ctx, ids["andy"])
+			require.NoError(t, err)
+			require.Len(t, incoming, 1)
+
+			count, err := repo.CountUsers(ctx)
+			require.NoError(t, err)
+			require.Equal(t, int64(4), count)
+
+			// The email can be used again
+			_, err = repo.CreateUser(ctx, "lisa", "lisa@example.com")
+			require.NoError(t, err)
+		},
+		"commit and roll back units of work": func(t *testing.T, ctx context.Context, repo SpecRepo, ids map[string]int) {
+			err := repo.WithTx(ctx, func(txRepo SpecRepo) error {
+				if err := txRepo.LockUsers(ctx, ids["john"], ids["andy"]); err != nil {
+					return err
+				}
+				return txRepo.CreateFriend(ctx, ids["john"], ids["andy"])
+			})
+			require.NoError(t, err)
+			isExisted, err := repo.IsExistedFriend(ctx, ids["john"], ids["andy"])
+			require.NoError(t, err)
+			require.True(t, isExisted)
+
+			errFailed := errors.New("unit of work failed")
+			err = repo.WithTx(ctx, func(txRepo SpecRepo) error {
+				if _, err := txRepo.CreateUser(ctx, "tom", "tom@example.com"); err != nil {
+					return err
+				}
+				if err := txRepo.DeleteFriend(ctx, ids["john"], ids["andy"]); err != nil {
+					return err
+				}
+				return errFailed
+			})
+			require.Equal(t, errFailed, err)
+
+			isExisted, err = repo.IsExistedUser(ctx, "tom@example.com")
+			require.NoError(t, err)
+			require.False(t, isExisted)
+			isExisted, err = repo.IsExistedFriend(ctx, ids["john"], ids["andy"])
+			require.NoError(t, err)
+			require.True(t, isExisted)
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newRepo(t)
+			ids := seedConformanceGraph(t, ctx, repo)
+			tc(t, ctx, repo, ids)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"strings"
+)
+
+// EmailNormalizer normalizes emails before validating, storing or looking them up:
+// spaces are trimmed and the domain part is lowercased
+type EmailNormalizer struct {
+	// Lowercase the local part of emails too, it is EMAIL_LOWERCASE_LOCAL_PART of config.Config
+	LowercaseLocalPart bool
+}
+
+func NewEmailNormalizer(lowercaseLocalPart bool) EmailNormalizer {
+	return EmailNormalizer{
+		LowercaseLocalPart: lowercaseLocalPart,
+	}
+}
+
+// Normalize an email, an email without @ is only trimmed
+func (_self EmailNormalizer) Normalize(email string) string {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+	local, domain := email[:at], strings.ToLower(email[at+1:])
+	if _self.LowercaseLocalPart {
+		local = strings.ToLower(local)
+	}
+	return local + "@" + domain
+}
+
+// Normalize a list of emails, nil is kept
+func (_self EmailNormalizer) NormalizeAll(emails []string) []string {
+	if emails == nil {
+		return nil
+	}
+	normalized := make([]string, len(emails))
+	for i, email := range emails {
+		normalized[i] = _self.Normalize(email)
+	}
+	return normalized
+}
+
+// Get an email to store, the local part is kept as the caller has normalized it
+func storedEmail(email string) string {
+	return EmailNormalizer{}.Normalize(email)
+}
+
+// Get the case-insensitive identity of an email, it matches lower(email) of users table
+func emailKey(email string) string {
+	return strings.ToLower(storedEmail(email))
+}
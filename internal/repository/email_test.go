@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_NormalizeEmail(t *testing.T) {
+	tcs := map[string]struct {
+		email          string
+		lowercaseLocal bool
+		expResult      string
+	}{
+		"success with trimming spaces and lowercasing the email": {
+			email:          "  Andy@Example.COM ",
+			lowercaseLocal: true,
+			expResult:      "andy@example.com",
+		},
+		"success with keeping case of the local part": {
+			email:          " Andy@Example.COM",
+			lowercaseLocal: false,
+			expResult:      "Andy@example.com",
+		},
+		"success with an email without domain": {
+			email:          " Andy ",
+			lowercaseLocal: true,
+			expResult:      "Andy",
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tc.expResult, NewEmailNormalizer(tc.lowercaseLocal).Normalize(tc.email))
+		})
+	}
+}
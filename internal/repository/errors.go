@@ -0,0 +1,18 @@
+package repository
+
+import "github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/apperrors"
+
+var (
+	ErrNotExistedFriendship    = apperrors.NotFound("friendship_not_found", "The friend relationship does not exist")
+	ErrNotExistedSubscription  = apperrors.NotFound("subscription_not_found", "The subscription does not exist")
+	ErrNotExistedBlockedUser   = apperrors.NotFound("blocking_not_found", "The blocking relationship does not exist")
+	ErrNotExistedFriendRequest = apperrors.NotFound("friend_request_not_found", "The pending friend request does not exist")
+	ErrNotExistedUser          = apperrors.NotFound("user_not_found", "The user does not exist")
+	ErrNotExistedAPIKey        = apperrors.NotFound("api_key_not_found", "The API key does not exist")
+	ErrExistedUser             = apperrors.Conflict("user_exists", "The user has been existed")
+	ErrExistedFriendship       = apperrors.Conflict("friendship_exists", "The friend relationship has been existed")
+	ErrExistedSubscription     = apperrors.Conflict("subscription_exists", "The users have subscribed each other")
+	ErrExistedBlockedUser      = apperrors.Conflict("blocking_exists", "The users have blocked each other")
+	ErrExistedFriendRequest    = apperrors.Conflict("friend_request_exists", "The friend request has been existed")
+	ErrExistedAPIKey           = apperrors.Conflict("api_key_exists", "The API key has been existed")
+)
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// Statuses of a record in friend_requests table
+const (
+	FriendRequestPending   = "pending"
+	FriendRequestAccepted  = "accepted"
+	FriendRequestRejected  = "rejected"
+	FriendRequestCancelled = "cancelled"
+)
+
+// Insert a new pending record into friend_requests table, only one pending request is inserted for the same users
+func (_self DBRepo) CreateFriendRequest(ctx context.Context, requestorId int, targetId int) error {
+	query := `INSERT INTO friend_requests (requestor_id, target_id, status, created_at, updated_at)
+	    VALUES ($1, $2, $3, $4, $4) ON CONFLICT DO NOTHING`
+	return _self.insertOnConflict(ctx, ErrExistedFriendRequest, query, requestorId, targetId, FriendRequestPending, time.Now())
+}
+
+// Verify a pending friend request between users in either direction
+func (_self DBRepo) IsPendingFriendRequest(ctx context.Context, userId int, friendId int) (bool, error) {
+	return models.FriendRequests(
+		qm.WhereIn("requestor_id in ?", userId, friendId),
+		qm.AndIn("target_id in ?", userId, friendId),
+		models.FriendRequestWhere.Status.EQ(FriendRequestPending)).
+		Exists(ctx, _self.exec())
+}
+
+// Get a pending friend request which was sent from requestor to target
+func (_self DBRepo) GetPendingFriendRequest(ctx context.Context, requestorId int, targetId int) (*models.FriendRequest, error) {
+	friendRequest, err := models.FriendRequests(
+		models.FriendRequestWhere.RequestorID.EQ(requestorId),
+		models.FriendRequestWhere.TargetID.EQ(targetId),
+		models.FriendRequestWhere.Status.EQ(FriendRequestPending),
+	).One(ctx, _self.exec())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotExistedFriendRequest
+	}
+	return friendRequest, err
+}
+
+// Get pending friend requests which were sent to user
+func (_self DBRepo) GetIncomingFriendRequests(ctx context.Context, userId int) (models.FriendRequestSlice, error) {
+	return models.FriendRequests(
+		models.FriendRequestWhere.TargetID.EQ(userId),
+		models.FriendRequestWhere.Status.EQ(FriendRequestPending),
+		qm.OrderBy(models.FriendRequestColumns.CreatedAt),
+	).All(ctx, _self.exec())
+}
+
+// Get pending friend requests which were sent by user
+func (_self DBRepo) GetOutgoingFriendRequests(ctx context.Context, userId int) (models.FriendRequestSlice, error) {
+	return models.FriendRequests(
+		models.FriendRequestWhere.RequestorID.EQ(userId),
+		models.FriendRequestWhere.Status.EQ(FriendRequestPending),
+		qm.OrderBy(models.FriendRequestColumns.CreatedAt),
+	).All(ctx, _self.exec())
+}
+
+// Accept a pending friend request and insert the friendship in the same transaction,
+// ErrExistedFriendship is returned and the request is kept pending when the users are already friends
+func (_self DBRepo) AcceptFriendRequest(ctx context.Context, requestId int) error {
+	return _self.inTx(ctx, func(txRepo DBRepo) error {
+		friendRequest, err := models.FriendRequests(
+			models.FriendRequestWhere.ID.EQ(requestId),
+			models.FriendRequestWhere.Status.EQ(FriendRequestPending),
+			txRepo.forUpdate(),
+		).One(ctx, txRepo.exec())
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNotExistedFriendRequest
+		}
+		if err != nil {
+			return err
+		}
+
+		friendRequest.Status = FriendRequestAccepted
+		if _, err := friendRequest.Update(ctx, txRepo.exec(), boil.Whitelist(models.FriendRequestColumns.Status, models.FriendRequestColumns.UpdatedAt)); err != nil {
+			return err
+		}
+
+		return txRepo.CreateFriend(ctx, friendRequest.RequestorID, friendRequest.TargetID)
+	})
+}
+
+// Change status of a pending friend request, such as rejected or cancelled
+func (_self DBRepo) UpdateFriendRequestStatus(ctx context.Context, requestId int, status string) error {
+	rowsAff, err := models.FriendRequests(
+		models.FriendRequestWhere.ID.EQ(requestId),
+		models.FriendRequestWhere.Status.EQ(FriendRequestPending),
+	).UpdateAll(ctx, _self.exec(), models.M{
+		models.FriendRequestColumns.Status:    status,
+		models.FriendRequestColumns.UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return ErrNotExistedFriendRequest
+	}
+	return nil
+}
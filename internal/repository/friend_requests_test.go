@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_CreateFriendRequest(t *testing.T) {
+	tcs := map[string]struct {
+		requestorId    int
+		targetId       int
+		expError       error
+		expSQLiteError error
+	}{
+		"success with adding input of userIds": {
+			requestorId: 100,
+			targetId:    101,
+		},
+		"query by an existing pending request": {
+			requestorId: 103,
+			targetId:    104,
+			expError:    ErrExistedFriendRequest,
+		},
+		"query by an unknown input userIds": {
+			requestorId:    99,
+			targetId:       100,
+			expError:       errors.New("pq: insert or update on table \"friend_requests\" violates foreign key constraint \"friend_requests_requestor_id_fkey\""),
+			expSQLiteError: errors.New("FOREIGN KEY constraint failed"),
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			err := repo.CreateFriendRequest(ctx, tc.requestorId, tc.targetId)
+			if expError := dialectError(repo, tc.expError, tc.expSQLiteError); expError != nil {
+				require.EqualError(t, err, expError.Error())
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRepository_IsPendingFriendRequest(t *testing.T) {
+	tcs := map[string]struct {
+		userId    int
+		friendId  int
+		expResult bool
+	}{
+		"success with the reversed order of a pending request": {
+			userId:    104,
+			friendId:  103,
+			expResult: true,
+		},
+		"query by a rejected request": {
+			userId:    101,
+			friendId:  100,
+			expResult: false,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			result, err := repo.IsPendingFriendRequest(ctx, tc.userId, tc.friendId)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expResult, result)
+		})
+	}
+}
+
+func TestRepository_GetPendingFriendRequest(t *testing.T) {
+	tcs := map[string]struct {
+		requestorId int
+		targetId    int
+		expResult   int
+		expError    error
+	}{
+		"success with adding input of userIds": {
+			requestorId: 103,
+			targetId:    104,
+			expResult:   200,
+		},
+		"query by the reversed order of userIds": {
+			requestorId: 104,
+			targetId:    103,
+			expError:    ErrNotExistedFriendRequest,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			result, err := repo.GetPendingFriendRequest(ctx, tc.requestorId, tc.targetId)
+
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult, result.ID)
+			}
+		})
+	}
+}
+
+func TestRepository_GetIncomingFriendRequests(t *testing.T) {
+	tcs := map[string]struct {
+		userId    int
+		expResult models.FriendRequestSlice
+	}{
+		"success with adding input of userId": {
+			userId: 104,
+			expResult: models.FriendRequestSlice{
+				&models.FriendRequest{ID: 200, RequestorID: 103, TargetID: 104, Status: FriendRequestPending},
+			},
+		},
+		"query by an user without pending request": {
+			userId: 100,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			result, err := repo.GetIncomingFriendRequests(ctx, tc.userId)
+
+			require.NoError(t, err)
+			require.Equal(t, len(tc.expResult), len(result))
+			for i, ss := range tc.expResult {
+				require.Equal(t, ss.ID, result[i].ID)
+				require.Equal(t, ss.RequestorID, result[i].RequestorID)
+				require.Equal(t, ss.TargetID, result[i].TargetID)
+				require.Equal(t, ss.Status, result[i].Status)
+			}
+		})
+	}
+}
+
+func TestRepository_GetOutgoingFriendRequests(t *testing.T) {
+	tcs := map[string]struct {
+		userId    int
+		expResult models.FriendRequestSlice
+	}{
+		"success with adding input of userId": {
+			userId: 104,
+			expResult: models.FriendRequestSlice{
+				&models.FriendRequest{ID: 201, RequestorID: 104, TargetID: 101, Status: FriendRequestPending},
+			},
+		},
+		"query by an user with a rejected request only": {
+			userId: 101,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			result, err := repo.GetOutgoingFriendRequests(ctx, tc.userId)
+
+			require.NoError(t, err)
+			require.Equal(t, len(tc.expResult), len(result))
+			for i, ss := range tc.expResult {
+				require.Equal(t, ss.ID, result[i].ID)
+				require.Equal(t, ss.RequestorID, result[i].RequestorID)
+				require.Equal(t, ss.TargetID, result[i].TargetID)
+				require.Equal(t, ss.Status, result[i].Status)
+			}
+		})
+	}
+}
+
+func TestRepository_AcceptFriendRequest(t *testing.T) {
+	tcs := map[string]struct {
+		requestId int
+		userId    int
+		friendId  int
+		expError  error
+	}{
+		"success with a pending request": {
+			requestId: 200,
+			userId:    103,
+			friendId:  104,
+		},
+		"query by a rejected request": {
+			requestId: 202,
+			expError:  ErrNotExistedFriendRequest,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			err := repo.AcceptFriendRequest(ctx, tc.requestId)
+
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+			} else {
+				require.NoError(t, err)
+				isExisted, err := repo.IsExistedFriend(ctx, tc.userId, tc.friendId)
+				require.NoError(t, err)
+				require.True(t, isExisted)
+				isPending, err := repo.IsPendingFriendRequest(ctx, tc.userId, tc.friendId)
+				require.NoError(t, err)
+				require.False(t, isPending)
+			}
+		})
+	}
+}
+
+func TestRepository_UpdateFriendRequestStatus(t *testing.T) {
+	tcs := map[string]struct {
+		requestId int
+		status    string
+		expError  error
+	}{
+		"success with a pending request": {
+			requestId: 201,
+			status:    FriendRequestCancelled,
+		},
+		"query by a rejected request": {
+			requestId: 202,
+			status:    FriendRequestCancelled,
+			expError:  ErrNotExistedFriendRequest,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			err := repo.UpdateFriendRequestStatus(ctx, tc.requestId, tc.status)
+
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+			} else {
+				require.NoError(t, err)
+				friendRequest, err := models.FindFriendRequest(ctx, db, tc.requestId)
+				require.NoError(t, err)
+				require.Equal(t, tc.status, friendRequest.Status)
+			}
+		})
+	}
+}
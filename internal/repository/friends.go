@@ -2,28 +2,158 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 
 	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
-	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
-// Insert a new record into friends table
+// Order user ids of a friendship as it is stored in friends table, user_id is always less than friend_id
+func canonicalFriendIDs(userId int, friendId int) (int, int) {
+	if userId > friendId {
+		return friendId, userId
+	}
+	return userId, friendId
+}
+
+// Insert a new record into friends table in the canonical order, an existing friendship is not inserted again
 func (_self DBRepo) CreateFriend(ctx context.Context, userId int, friendId int) error {
-	friend := models.Friend{
-		UserID:   userId,
-		FriendID: friendId,
+	userId, friendId = canonicalFriendIDs(userId, friendId)
+	query := `INSERT INTO friends (user_id, friend_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	return _self.insertOnConflict(ctx, ErrExistedFriendship, query, userId, friendId)
+}
+
+// Delete a friendship from friends table regardless of the given order of user ids
+func (_self DBRepo) DeleteFriend(ctx context.Context, userId int, friendId int) error {
+	userId, friendId = canonicalFriendIDs(userId, friendId)
+	rowsAff, err := models.Friends(
+		models.FriendWhere.UserID.EQ(userId),
+		models.FriendWhere.FriendID.EQ(friendId),
+	).DeleteAll(ctx, _self.exec())
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return ErrNotExistedFriendship
 	}
-	return friend.Insert(ctx, _self.Db, boil.Infer())
+	return nil
 }
 
-// Get friendship slice from friends table by user id
+// Get friendship slice from friends table by user id, the user is stored in either
+// user_id or friend_id column depending on the canonical order of the friendship
 func (_self DBRepo) GetFriendsByID(ctx context.Context, userId int) (models.FriendSlice, error) {
 	return models.Friends(
 		qm.Select(models.FriendColumns.UserID, models.FriendColumns.FriendID),
 		qm.Where("user_id = ?", userId), qm.Or("friend_id = ?", userId),
-	).All(ctx, _self.Db)
+	).All(ctx, _self.exec())
+}
+
+// Get friendship slice of any of user ids, friendships between users who have blocked each other are skipped
+func (_self DBRepo) GetUnblockedFriendsByIDs(ctx context.Context, userIds []int) (models.FriendSlice, error) {
+	if len(userIds) == 0 {
+		return models.FriendSlice{}, nil
+	}
+
+	return models.Friends(
+		qm.Select(models.FriendColumns.UserID, models.FriendColumns.FriendID),
+		qm.Expr(models.FriendWhere.UserID.IN(userIds), qm.Or2(models.FriendWhere.FriendID.IN(userIds))),
+		qm.Where(`NOT EXISTS(
+	        SELECT 1 FROM user_blocks b
+	        WHERE (b.requestor_id = friends.user_id AND b.target_id = friends.friend_id)
+	        OR (b.requestor_id = friends.friend_id AND b.target_id = friends.user_id)
+	    )`),
+	).All(ctx, _self.exec())
+}
+
+// FriendPage is a page of emails of friends ordered by user id
+type FriendPage struct {
+	Emails []string
+	// Number of friends of all pages
+	Total int
+	// Id of the last friend of the page when there is a next page, it is 0 for the last page
+	NextAfterID int
+}
+
+type friendPageRow struct {
+	Total int    `boil:"total"`
+	ID    int    `boil:"id"`
+	Email string `boil:"email"`
+}
+
+// Query of ids of friends of the user in userParam, friendships between users who have blocked each other are skipped.
+// Friendships are stored once, so the user is looked up in each column by its own index
+func unblockedFriendIDsQuery(userParam string) string {
+	return fmt.Sprintf(`SELECT f.friend_id AS id FROM friends f
+	        WHERE f.user_id = %[1]s AND NOT EXISTS(
+	            SELECT 1 FROM user_blocks b
+	            WHERE (b.requestor_id = f.user_id AND b.target_id = f.friend_id)
+	            OR (b.requestor_id = f.friend_id AND b.target_id = f.user_id)
+	        )
+	        UNION ALL
+	        SELECT f.user_id AS id FROM friends f
+	        WHERE f.friend_id = %[1]s AND NOT EXISTS(
+	            SELECT 1 FROM user_blocks b
+	            WHERE (b.requestor_id = f.user_id AND b.target_id = f.friend_id)
+	            OR (b.requestor_id = f.friend_id AND b.target_id = f.user_id)
+	        )`, userParam)
+}
+
+// Query of a page of friends in friend_ids with the total number of friends,
+// a row with id 0 is returned for an empty page so the total is always returned
+func friendPageQuery(friendIDs string, afterParam string, limitParam string) string {
+	return fmt.Sprintf(`WITH friend_ids AS (%s)
+	    SELECT t.total, COALESCE(p.id, 0) AS id, COALESCE(p.email, '') AS email
+	    FROM (SELECT COUNT(*) AS total FROM friend_ids) t
+	    LEFT JOIN (
+	        SELECT u.id, u.email FROM friend_ids fi
+	        JOIN users u ON u.id = fi.id
+	        WHERE fi.id > %s
+	        ORDER BY fi.id
+	        LIMIT %s
+	    ) p ON true
+	    ORDER BY p.id`, friendIDs, afterParam, limitParam)
+}
+
+// Get a page of emails of friends of a user after afterId in a single query,
+// friendships between users who have blocked each other are skipped
+func (_self DBRepo) GetUnblockedFriendEmails(ctx context.Context, userId int, afterId int, limit int) (FriendPage, error) {
+	query := friendPageQuery(unblockedFriendIDsQuery("$1"), "$2", "$3")
+	return _self.getFriendPage(ctx, limit, query, userId, afterId, limit+1)
+}
+
+// Get a page of emails of common friends of 2 users after afterId in a single query,
+// friendships between users who have blocked each other are skipped
+func (_self DBRepo) GetCommonFriendEmails(ctx context.Context, userId int, otherId int, afterId int, limit int) (FriendPage, error) {
+	friendIDs := fmt.Sprintf("SELECT id FROM (%s) a INTERSECT SELECT id FROM (%s) b", unblockedFriendIDsQuery("$1"), unblockedFriendIDsQuery("$2"))
+	query := friendPageQuery(friendIDs, "$3", "$4")
+	return _self.getFriendPage(ctx, limit, query, userId, otherId, afterId, limit+1)
+}
+
+// Run a query of friendPageQuery which gets one more friend than the limit to know whether there is a next page
+func (_self DBRepo) getFriendPage(ctx context.Context, limit int, query string, args ...interface{}) (FriendPage, error) {
+	var rows []friendPageRow
+	if err := _self.raw(query, args...).Bind(ctx, _self.exec(), &rows); err != nil {
+		return FriendPage{}, err
+	}
+
+	page := FriendPage{Emails: []string{}}
+	lastId := 0
+	for _, row := range rows {
+		page.Total = row.Total
+		if row.ID == 0 {
+			continue
+		}
+		if len(page.Emails) == limit {
+			page.NextAfterID = lastId
+			break
+		}
+		page.Emails = append(page.Emails, row.Email)
+		lastId = row.ID
+	}
+	return page, nil
 }
 
 // Get blocked user relationship slice from user_blocks table by user id
@@ -31,21 +161,34 @@ func (_self DBRepo) GetUserBlocksByID(ctx context.Context, userId int) (models.U
 	return models.UserBlocks(
 		qm.Select(models.UserBlockColumns.RequestorID, models.UserBlockColumns.TargetID),
 		qm.Where("requestor_id = ?", userId), qm.Or("target_id = ?", userId),
-	).All(ctx, _self.Db)
+	).All(ctx, _self.exec())
 }
 
-// Insert a new record into subscriptions table
+// Insert a new record into subscriptions table, an existing subscription is not inserted again
 func (_self DBRepo) CreateSubscription(ctx context.Context, requestorId int, targetId int) error {
-	subscription := models.Subscription{
-		SubscriptionRequestorID: requestorId,
-		SubscriptionTargetID:    targetId,
+	query := `INSERT INTO subscriptions (subscription_requestor_id, subscription_target_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	return _self.insertOnConflict(ctx, ErrExistedSubscription, query, requestorId, targetId)
+}
+
+// Delete a subscription of requestor to target from subscriptions table
+func (_self DBRepo) DeleteSubscription(ctx context.Context, requestorId int, targetId int) error {
+	rowsAff, err := models.Subscriptions(
+		models.SubscriptionWhere.SubscriptionRequestorID.EQ(requestorId),
+		models.SubscriptionWhere.SubscriptionTargetID.EQ(targetId),
+	).DeleteAll(ctx, _self.exec())
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return ErrNotExistedSubscription
 	}
-	return subscription.Insert(ctx, _self.Db, boil.Infer())
+	return nil
 }
 
-// Get users slice (who are not blocked by sender) by user id
-func (_self DBRepo) GetRecipientEmails(ctx context.Context, senderId int) ([]models.User, error) {
-	query := `SELECT DISTINCT val.email FROM (
+// Get emails of friends and subscribers of sender (who are not blocked by sender) after afterEmail ordered by email,
+// at most limit emails are returned. Emails are compared byte by byte, so they are ordered like sorted Go strings
+func (_self DBRepo) GetRecipientEmails(ctx context.Context, senderId int, afterEmail string, limit int) ([]string, error) {
+	query := fmt.Sprintf(`SELECT val.email FROM (
 	        SELECT u.id, u.email
 	        FROM users u JOIN friends f ON (u.id = f.user_id OR u.id = f.friend_id)
 	        WHERE u.id <> $1 AND (f.user_id = $1 OR f.friend_id = $1)
@@ -54,35 +197,55 @@ func (_self DBRepo) GetRecipientEmails(ctx context.Context, senderId int) ([]mod
 	        FROM subscriptions s JOIN users u ON s.subscription_requestor_id = u.id
 	        WHERE u.id <> $1 AND s.subscription_target_id = $1
 	    ) AS val
-	    WHERE NOT EXISTS(
+	    WHERE val.email COLLATE %[1]s > $2 AND NOT EXISTS(
 	        SELECT 1 FROM user_blocks b
 	        WHERE (b.requestor_id = val.id AND b.target_id = $1) OR (b.target_id = val.id AND b.requestor_id = $1)
-	)`
+	    )
+	    ORDER BY val.email COLLATE %[1]s
+	    LIMIT $3`, _self.binaryCollation())
 
-	nonBlockUsers := make([]models.User, 0)
-	err := queries.Raw(query, senderId).Bind(ctx, _self.Db, &nonBlockUsers)
-	if err != nil {
+	var rows []struct {
+		Email string `boil:"email"`
+	}
+	if err := _self.raw(query, senderId, afterEmail, limit).Bind(ctx, _self.exec(), &rows); err != nil {
 		return nil, err
 	}
 
-	return nonBlockUsers, nil
+	emails := make([]string, len(rows))
+	for i, row := range rows {
+		emails[i] = row.Email
+	}
+	return emails, nil
 }
 
-// Insert a blocking relationship of users into user_blocks table
+// Insert a blocking relationship of users into user_blocks table, an existing block is not inserted again
 func (_self DBRepo) CreateUserBlock(ctx context.Context, requestorId int, targetId int) error {
-	userBlock := models.UserBlock{
-		RequestorID: requestorId,
-		TargetID:    targetId,
+	query := `INSERT INTO user_blocks (requestor_id, target_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	return _self.insertOnConflict(ctx, ErrExistedBlockedUser, query, requestorId, targetId)
+}
+
+// Delete a blocking relationship which was created by requestor from user_blocks table
+func (_self DBRepo) DeleteUserBlock(ctx context.Context, requestorId int, targetId int) error {
+	rowsAff, err := models.UserBlocks(
+		models.UserBlockWhere.RequestorID.EQ(requestorId),
+		models.UserBlockWhere.TargetID.EQ(targetId),
+	).DeleteAll(ctx, _self.exec())
+	if err != nil {
+		return err
 	}
-	return userBlock.Insert(ctx, _self.Db, boil.Infer())
+	if rowsAff == 0 {
+		return ErrNotExistedBlockedUser
+	}
+	return nil
 }
 
-// Verify a existing friendship
+// Verify a existing friendship regardless of the given order of user ids
 func (_self DBRepo) IsExistedFriend(ctx context.Context, userId int, friendId int) (bool, error) {
+	userId, friendId = canonicalFriendIDs(userId, friendId)
 	return models.Friends(
-		qm.WhereIn("user_id in ?", userId, friendId),
-		qm.AndIn("friend_id in ?", userId, friendId)).
-		Exists(ctx, _self.Db)
+		models.FriendWhere.UserID.EQ(userId),
+		models.FriendWhere.FriendID.EQ(friendId)).
+		Exists(ctx, _self.exec())
 }
 
 // Verify a blocking relationship of users
@@ -90,7 +253,7 @@ func (_self DBRepo) IsBlockedUser(ctx context.Context, userId int, friendId int)
 	return models.UserBlocks(
 		qm.WhereIn("requestor_id in ?", userId, friendId),
 		qm.AndIn("target_id in ?", userId, friendId)).
-		Exists(ctx, _self.Db)
+		Exists(ctx, _self.exec())
 }
 
 // Verify a subscription relationship of users
@@ -98,26 +261,33 @@ func (_self DBRepo) IsSubscribedUser(ctx context.Context, requestorId int, targe
 	return models.Subscriptions(
 		qm.WhereIn("subscription_requestor_id in ?", requestorId, targetId),
 		qm.AndIn("subscription_target_id in ?", requestorId, targetId)).
-		Exists(ctx, _self.Db)
+		Exists(ctx, _self.exec())
 }
 
-// Get a user id from users table by email
+// Get a user id from users table by case-insensitive email
 func (_self DBRepo) GetUserIDByEmail(ctx context.Context, email string) (int, error) {
 	var userId int
-	user, err := models.Users(qm.Select(models.UserColumns.ID), qm.Where("email = ?", email)).One(ctx, _self.Db)
+	user, err := models.Users(qm.Select(models.UserColumns.ID), qm.Where("lower(email) = ?", emailKey(email))).One(ctx, _self.exec())
+	if errors.Is(err, sql.ErrNoRows) {
+		return userId, ErrNotExistedUser
+	}
 	if err != nil {
 		return userId, err
 	}
 	return user.ID, nil
 }
 
-// Get list of emails by list of corresponding ids from users table
+// Get list of emails ordered by ids of the corresponding users from users table
 func (_self DBRepo) GetEmailsByUserIDs(ctx context.Context, userIDs []int) ([]string, error) {
 	if len(userIDs) == 0 {
 		return []string{}, nil
 	}
 
-	users, err := models.Users(qm.Select(models.UserColumns.Email), models.UserWhere.ID.IN(userIDs)).All(ctx, _self.Db)
+	users, err := models.Users(
+		qm.Select(models.UserColumns.Email),
+		models.UserWhere.ID.IN(userIDs),
+		qm.OrderBy(models.UserColumns.ID),
+	).All(ctx, _self.exec())
 	if err != nil {
 		return nil, err
 	}
@@ -129,7 +299,87 @@ func (_self DBRepo) GetEmailsByUserIDs(ctx context.Context, userIDs []int) ([]st
 	return emails, nil
 }
 
-// Get all users from users table
-func (_self DBRepo) GetUsers(ctx context.Context) (models.UserSlice, error) {
-	return models.Users().All(ctx, _self.Db)
+// FriendSuggestion is a friend of friends who is suggested to a user
+type FriendSuggestion struct {
+	Email         string `boil:"email" json:"email"`
+	MutualFriends int    `boil:"mutual_friends" json:"mutual_friends"`
+}
+
+// Get friends of friends who are not friend of user yet, ranked by number of mutual friends.
+// Users who are in a blocking relationship or a pending friend request with user are excluded
+func (_self DBRepo) GetFriendSuggestions(ctx context.Context, userId int) ([]FriendSuggestion, error) {
+	query := `WITH user_friends AS (
+	        SELECT CASE WHEN f.user_id = $1 THEN f.friend_id ELSE f.user_id END AS id
+	        FROM friends f
+	        WHERE f.user_id = $1 OR f.friend_id = $1
+	    )
+	    SELECT u.email, COUNT(*) AS mutual_friends
+	    FROM user_friends uf
+	    JOIN friends f ON (f.user_id = uf.id OR f.friend_id = uf.id)
+	    JOIN users u ON u.id = CASE WHEN f.user_id = uf.id THEN f.friend_id ELSE f.user_id END
+	    WHERE u.id <> $1
+	    AND NOT EXISTS(SELECT 1 FROM user_friends WHERE user_friends.id = u.id)
+	    AND NOT EXISTS(
+	        SELECT 1 FROM user_blocks b
+	        WHERE (b.requestor_id = u.id AND b.target_id = $1) OR (b.target_id = u.id AND b.requestor_id = $1)
+	    )
+	    AND NOT EXISTS(
+	        SELECT 1 FROM friend_requests r
+	        WHERE r.status = $2
+	        AND ((r.requestor_id = u.id AND r.target_id = $1) OR (r.target_id = u.id AND r.requestor_id = $1))
+	    )
+	    GROUP BY u.id, u.email
+	    ORDER BY mutual_friends DESC, u.email`
+
+	suggestions := make([]FriendSuggestion, 0)
+	err := _self.raw(query, userId, FriendRequestPending).Bind(ctx, _self.exec(), &suggestions)
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// Get users slice by list of ids from users table
+func (_self DBRepo) GetUsersByIDs(ctx context.Context, userIDs []int) (models.UserSlice, error) {
+	if len(userIDs) == 0 {
+		return models.UserSlice{}, nil
+	}
+
+	return models.Users(models.UserWhere.ID.IN(userIDs)).All(ctx, _self.exec())
+}
+
+// Get a page of users ordered by id from users table, the page starts after the user of afterId
+func (_self DBRepo) GetUsers(ctx context.Context, afterId int, limit int) (models.UserSlice, error) {
+	return models.Users(
+		models.UserWhere.ID.GT(afterId),
+		qm.OrderBy(models.UserColumns.ID),
+		qm.Limit(limit),
+	).All(ctx, _self.exec())
+}
+
+// Count all users of users table
+func (_self DBRepo) CountUsers(ctx context.Context) (int64, error) {
+	return models.Users().Count(ctx, _self.exec())
+}
+
+// Get a raw query with placeholders of the dialect of the repository
+func (_self DBRepo) raw(query string, args ...interface{}) *queries.Query {
+	return queries.Raw(_self.rebind(query), args...)
+}
+
+// Run an INSERT ... ON CONFLICT DO NOTHING query, errExisted is returned when no row is inserted
+func (_self DBRepo) insertOnConflict(ctx context.Context, errExisted error, query string, args ...interface{}) error {
+	result, err := _self.raw(query, args...).ExecContext(ctx, _self.exec())
+	if err != nil {
+		return err
+	}
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return errExisted
+	}
+	return nil
 }
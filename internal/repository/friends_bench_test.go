@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Number of friends in a page of benchmarks
+const benchPageLimit = 100
+
+// newBenchRepo will be seeding a friendship graph of 100k users
+func newBenchRepo(b *testing.B) DBRepo {
+	db, err := openTestDatabase()
+	require.NoError(b, err)
+	loadSqlTestFile(b, db, "testdata/friend_graph.sql")
+	return NewDBRepo(db)
+}
+
+// Get ids of unblocked friends in 2 round trips with filtering in Go,
+// it is the way friends were listed before GetUnblockedFriendEmails
+func getUnblockedFriendIDsInRoundTrips(ctx context.Context, repo DBRepo, userId int) ([]int, error) {
+	friends, err := repo.GetFriendsByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := repo.GetUserBlocksByID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make(map[int]bool)
+	for _, block := range blocks {
+		blocked[block.RequestorID] = true
+		blocked[block.TargetID] = true
+	}
+	friendIds := make([]int, 0)
+	for _, friend := range friends {
+		friendId := friend.FriendID
+		if friendId == userId {
+			friendId = friend.UserID
+		}
+		if !blocked[friendId] {
+			friendIds = append(friendIds, friendId)
+		}
+	}
+	return friendIds, nil
+}
+
+// Get emails of the first page of friend ids in a round trip
+func getFriendEmailsOfFirstPage(ctx context.Context, repo DBRepo, friendIds []int) ([]string, error) {
+	sort.Ints(friendIds)
+	if len(friendIds) > benchPageLimit {
+		friendIds = friendIds[:benchPageLimit]
+	}
+	return repo.GetEmailsByUserIDs(ctx, friendIds)
+}
+
+func BenchmarkRepository_GetFriendEmailsInRoundTrips(b *testing.B) {
+	ctx := context.Background()
+	repo := newBenchRepo(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		friendIds, err := getUnblockedFriendIDsInRoundTrips(ctx, repo, 1)
+		require.NoError(b, err)
+		_, err = getFriendEmailsOfFirstPage(ctx, repo, friendIds)
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkRepository_GetUnblockedFriendEmails(b *testing.B) {
+	ctx := context.Background()
+	repo := newBenchRepo(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := repo.GetUnblockedFriendEmails(ctx, 1, 0, benchPageLimit)
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkRepository_GetCommonFriendEmailsInRoundTrips(b *testing.B) {
+	ctx := context.Background()
+	repo := newBenchRepo(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		firstIds, err := getUnblockedFriendIDsInRoundTrips(ctx, repo, 1)
+		require.NoError(b, err)
+		secondIds, err := getUnblockedFriendIDsInRoundTrips(ctx, repo, 2)
+		require.NoError(b, err)
+
+		firstMap := make(map[int]bool)
+		for _, id := range firstIds {
+			firstMap[id] = true
+		}
+		commonIds := make([]int, 0)
+		for _, id := range secondIds {
+			if firstMap[id] {
+				commonIds = append(commonIds, id)
+			}
+		}
+		_, err = getFriendEmailsOfFirstPage(ctx, repo, commonIds)
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkRepository_GetCommonFriendEmails(b *testing.B) {
+	ctx := context.Background()
+	repo := newBenchRepo(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := repo.GetCommonFriendEmails(ctx, 1, 2, 0, benchPageLimit)
+		require.NoError(b, err)
+	}
+}
@@ -12,8 +12,17 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// openTestDatabase will be opening the database of DATABASE_URL
+func openTestDatabase() (*sql.DB, error) {
+	cfg, _, err := config.Load(nil)
+	if err != nil {
+		return nil, err
+	}
+	return config.NewDatabase(cfg.Database)
+}
+
 // loadSqlTestFile will be loading a mock testdata
-func loadSqlTestFile(t *testing.T, db *sql.DB, sqlfile string) {
+func loadSqlTestFile(t testing.TB, db *sql.DB, sqlfile string) {
 	// Read sql file
 	b, err := ioutil.ReadFile(sqlfile)
 	require.NoError(t, err)
@@ -22,37 +31,123 @@ func loadSqlTestFile(t *testing.T, db *sql.DB, sqlfile string) {
 	require.NoError(t, err)
 }
 
+// runTestRepos will be running a test case against a Postgres and a SQLite repository which have the testdata
+// of friends.sql, the database of the repository is given for setting up more testdata
+func runTestRepos(t *testing.T, desc string, test func(t *testing.T, repo DBRepo, db *sql.DB)) {
+	t.Run(desc, func(t *testing.T) {
+		t.Run("Postgres", func(t *testing.T) {
+			db, err := openTestDatabase()
+			require.NoError(t, err)
+			loadSqlTestFile(t, db, "testdata/friends.sql")
+			test(t, NewDBRepo(db), db)
+		})
+		t.Run("SQLite", func(t *testing.T) {
+			db := newSQLiteTestDatabase(t)
+			loadSqlTestFile(t, db, "testdata/sqlite_friends.sql")
+			test(t, NewSQLiteRepo(db), db)
+		})
+	})
+}
+
+// dialectError will be getting the expected error of a test case for the dialect of a repository,
+// SQLite reports violated constraints with other messages than Postgres
+func dialectError(repo DBRepo, expError error, expSQLiteError error) error {
+	if repo.dialect == dialectSQLite && expSQLiteError != nil {
+		return expSQLiteError
+	}
+	return expError
+}
+
 func TestRepository_CreateFriend(t *testing.T) {
 	tcs := map[string]struct {
-		userId   int
-		friendId int
-		expError error
+		userId         int
+		friendId       int
+		expError       error
+		expSQLiteError error
 	}{
 		"success with adding input of userIds": {
 			userId:   100,
 			friendId: 104,
 		},
-		"query by an unknown input userIds": {
+		"success with storing the reversed order of userIds in the canonical order": {
+			userId:   104,
+			friendId: 100,
+		},
+		"query by an existing friendship": {
 			userId:   100,
-			friendId: 99,
-			expError: errors.New("models: unable to insert into friends: pq: insert or update on table \"friends\" violates foreign key constraint \"friends_friend_id_fkey\""),
+			friendId: 102,
+			expError: ErrExistedFriendship,
+		},
+		"query by an existing friendship in the reversed order": {
+			userId:   102,
+			friendId: 100,
+			expError: ErrExistedFriendship,
+		},
+		"query by a self friendship": {
+			userId:         100,
+			friendId:       100,
+			expError:       errors.New("pq: new row for relation \"friends\" violates check constraint \"constraint_friends_not_self\""),
+			expSQLiteError: errors.New("CHECK constraint failed: constraint_friends_not_self"),
+		},
+		"query by an unknown input userIds": {
+			userId:         100,
+			friendId:       99,
+			expError:       errors.New("pq: insert or update on table \"friends\" violates foreign key constraint \"friends_user_id_fkey\""),
+			expSQLiteError: errors.New("FOREIGN KEY constraint failed"),
 		},
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
-			require.NoError(t, err)
-			repo := NewDBRepo(db)
+			err := repo.CreateFriend(ctx, tc.userId, tc.friendId)
+			if expError := dialectError(repo, tc.expError, tc.expSQLiteError); expError != nil {
+				require.EqualError(t, err, expError.Error())
+			} else {
+				require.NoError(t, err)
+				isStored, err := models.Friends(
+					models.FriendWhere.UserID.EQ(100),
+					models.FriendWhere.FriendID.EQ(104),
+				).Exists(ctx, db)
+				require.NoError(t, err)
+				require.True(t, isStored)
+			}
+		})
+	}
+}
 
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
-			err = repo.CreateFriend(ctx, tc.userId, tc.friendId)
+func TestRepository_DeleteFriend(t *testing.T) {
+	tcs := map[string]struct {
+		userId   int
+		friendId int
+		expError error
+	}{
+		"success with the stored order of userIds": {
+			userId:   100,
+			friendId: 102,
+		},
+		"success with the reversed order of userIds": {
+			userId:   102,
+			friendId: 100,
+		},
+		"query by a non-existing friendship": {
+			userId:   100,
+			friendId: 101,
+			expError: ErrNotExistedFriendship,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			err := repo.DeleteFriend(ctx, tc.userId, tc.friendId)
 			if tc.expError != nil {
 				require.EqualError(t, err, tc.expError.Error())
 			} else {
 				require.NoError(t, err)
+				isExisted, err := repo.IsExistedFriend(ctx, tc.userId, tc.friendId)
+				require.NoError(t, err)
+				require.False(t, isExisted)
 			}
 		})
 	}
@@ -70,6 +165,11 @@ func TestRepository_IsExistedFriend(t *testing.T) {
 			friendId:  102,
 			expResult: true,
 		},
+		"success with the reversed order of userIds": {
+			userId:    102,
+			friendId:  100,
+			expResult: true,
+		},
 		"query by an unknown input userIds": {
 			userId:    100,
 			friendId:  99,
@@ -78,14 +178,8 @@ func TestRepository_IsExistedFriend(t *testing.T) {
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
-			require.NoError(t, err)
-			repo := NewDBRepo(db)
-
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
 			result, err := repo.IsExistedFriend(ctx, tc.userId, tc.friendId)
 
 			if tc.expError != nil {
@@ -118,14 +212,8 @@ func TestRepository_IsBlockedUser(t *testing.T) {
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
-			require.NoError(t, err)
-			repo := NewDBRepo(db)
-
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
 			result, err := repo.IsBlockedUser(ctx, tc.userId, tc.friendId)
 
 			if tc.expError != nil {
@@ -157,15 +245,47 @@ func TestRepository_GetFriendsByID(t *testing.T) {
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
+			result, err := repo.GetFriendsByID(ctx, tc.userId)
+
 			require.NoError(t, err)
-			repo := NewDBRepo(db)
+			require.Equal(t, len(tc.expResult), len(result))
+			for i, ss := range tc.expResult {
+				require.Equal(t, ss, result[i])
+			}
+		})
+	}
+}
 
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
-			result, err := repo.GetFriendsByID(ctx, tc.userId)
+func TestRepository_GetUnblockedFriendsByIDs(t *testing.T) {
+	tcs := map[string]struct {
+		userIds   []int
+		expResult models.FriendSlice
+	}{
+		"success with adding input of userIds": {
+			userIds: []int{101, 103},
+			expResult: models.FriendSlice{
+				&models.Friend{UserID: 101, FriendID: 102},
+				&models.Friend{UserID: 102, FriendID: 103},
+			},
+		},
+		"query by an user who has blocked a friend": {
+			userIds:   []int{104},
+			expResult: models.FriendSlice{},
+		},
+		"query by an empty input userIds": {
+			userIds:   []int{},
+			expResult: models.FriendSlice{},
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			_, err := db.Exec("INSERT INTO friends(user_id, friend_id) VALUES (100, 104)")
+			require.NoError(t, err)
+			result, err := repo.GetUnblockedFriendsByIDs(ctx, tc.userIds)
 
 			require.NoError(t, err)
 			require.Equal(t, len(tc.expResult), len(result))
@@ -176,6 +296,116 @@ func TestRepository_GetFriendsByID(t *testing.T) {
 	}
 }
 
+func TestRepository_GetUnblockedFriendEmails(t *testing.T) {
+	tcs := map[string]struct {
+		userId    int
+		afterId   int
+		limit     int
+		setupSql  string
+		expResult FriendPage
+	}{
+		"success with all friends in a page": {
+			userId:    102,
+			limit:     10,
+			expResult: FriendPage{Emails: []string{"john@example.com", "andy@example.com", "lisa@example.com"}, Total: 3},
+		},
+		"success with a next page": {
+			userId:    102,
+			limit:     2,
+			expResult: FriendPage{Emails: []string{"john@example.com", "andy@example.com"}, Total: 3, NextAfterID: 101},
+		},
+		"success with the last page": {
+			userId:    102,
+			afterId:   101,
+			limit:     2,
+			expResult: FriendPage{Emails: []string{"lisa@example.com"}, Total: 3},
+		},
+		"success with an empty page after the last friend": {
+			userId:    102,
+			afterId:   103,
+			limit:     2,
+			expResult: FriendPage{Emails: []string{}, Total: 3},
+		},
+		"skip a friend who has blocked the user": {
+			userId:    102,
+			limit:     10,
+			setupSql:  "INSERT INTO user_blocks(requestor_id, target_id) VALUES (103, 102)",
+			expResult: FriendPage{Emails: []string{"john@example.com", "andy@example.com"}, Total: 2},
+		},
+		"query by an user without friends": {
+			userId:    104,
+			limit:     10,
+			expResult: FriendPage{Emails: []string{}},
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			if tc.setupSql != "" {
+				_, err := db.Exec(tc.setupSql)
+				require.NoError(t, err)
+			}
+			result, err := repo.GetUnblockedFriendEmails(ctx, tc.userId, tc.afterId, tc.limit)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expResult, result)
+		})
+	}
+}
+
+func TestRepository_GetCommonFriendEmails(t *testing.T) {
+	tcs := map[string]struct {
+		userId    int
+		otherId   int
+		afterId   int
+		limit     int
+		setupSql  string
+		expResult FriendPage
+	}{
+		"success with a common friend": {
+			userId:    100,
+			otherId:   101,
+			limit:     10,
+			expResult: FriendPage{Emails: []string{"common@example.com"}, Total: 1},
+		},
+		"success with a next page": {
+			userId:    100,
+			otherId:   101,
+			limit:     1,
+			setupSql:  "DELETE FROM user_blocks WHERE requestor_id = 100 AND target_id = 103; INSERT INTO friends(user_id, friend_id) VALUES (100, 103), (101, 103)",
+			expResult: FriendPage{Emails: []string{"common@example.com"}, Total: 2, NextAfterID: 102},
+		},
+		"skip a common friend who has blocked one of users": {
+			userId:    100,
+			otherId:   101,
+			limit:     10,
+			setupSql:  "INSERT INTO user_blocks(requestor_id, target_id) VALUES (102, 101)",
+			expResult: FriendPage{Emails: []string{}},
+		},
+		"query by users without common friends": {
+			userId:    100,
+			otherId:   104,
+			limit:     10,
+			expResult: FriendPage{Emails: []string{}},
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			if tc.setupSql != "" {
+				_, err := db.Exec(tc.setupSql)
+				require.NoError(t, err)
+			}
+			result, err := repo.GetCommonFriendEmails(ctx, tc.userId, tc.otherId, tc.afterId, tc.limit)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expResult, result)
+		})
+	}
+}
+
 func TestRepository_GetUserBlocksByID(t *testing.T) {
 	tcs := map[string]struct {
 		userId    int
@@ -196,14 +426,8 @@ func TestRepository_GetUserBlocksByID(t *testing.T) {
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
-			require.NoError(t, err)
-			repo := NewDBRepo(db)
-
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
 			result, err := repo.GetUserBlocksByID(ctx, tc.userId)
 
 			require.NoError(t, err)
@@ -217,36 +441,71 @@ func TestRepository_GetUserBlocksByID(t *testing.T) {
 
 func TestRepository_CreateSubscription(t *testing.T) {
 	tcs := map[string]struct {
-		requestorId int
-		targetId    int
-		expError    error
+		requestorId    int
+		targetId       int
+		expError       error
+		expSQLiteError error
 	}{
 		"success with adding input of userIds": {
 			requestorId: 102,
 			targetId:    103,
 		},
+		"query by an existing subscription": {
+			requestorId: 101,
+			targetId:    103,
+			expError:    ErrExistedSubscription,
+		},
 		"query by an unknown input userIds": {
-			requestorId: 99,
-			targetId:    100,
-			expError:    errors.New("models: unable to insert into subscriptions: pq: insert or update on table \"subscriptions\" violates foreign key constraint \"subscriptions_subscription_requestor_id_fkey\""),
+			requestorId:    99,
+			targetId:       100,
+			expError:       errors.New("pq: insert or update on table \"subscriptions\" violates foreign key constraint \"subscriptions_subscription_requestor_id_fkey\""),
+			expSQLiteError: errors.New("FOREIGN KEY constraint failed"),
 		},
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
-			require.NoError(t, err)
-			repo := NewDBRepo(db)
+			err := repo.CreateSubscription(ctx, tc.requestorId, tc.targetId)
 
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
-			err = repo.CreateSubscription(ctx, tc.requestorId, tc.targetId)
+			if expError := dialectError(repo, tc.expError, tc.expSQLiteError); expError != nil {
+				require.EqualError(t, err, expError.Error())
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRepository_DeleteSubscription(t *testing.T) {
+	tcs := map[string]struct {
+		requestorId int
+		targetId    int
+		expError    error
+	}{
+		"success with adding input of userIds": {
+			requestorId: 101,
+			targetId:    103,
+		},
+		"query by a reversed subscription": {
+			requestorId: 103,
+			targetId:    101,
+			expError:    ErrNotExistedSubscription,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			err := repo.DeleteSubscription(ctx, tc.requestorId, tc.targetId)
 
 			if tc.expError != nil {
 				require.EqualError(t, err, tc.expError.Error())
 			} else {
 				require.NoError(t, err)
+				isSubscribed, err := repo.IsSubscribedUser(ctx, tc.requestorId, tc.targetId)
+				require.NoError(t, err)
+				require.False(t, isSubscribed)
 			}
 		})
 	}
@@ -254,42 +513,80 @@ func TestRepository_CreateSubscription(t *testing.T) {
 
 func TestRepository_GetRecipientEmails(t *testing.T) {
 	tcs := map[string]struct {
-		senderId  int
-		expResult []models.User
-		expError  error
+		senderId   int
+		afterEmail string
+		limit      int
+		expResult  []string
+		expError   error
 	}{
 		"success with adding input of userId": {
-			senderId: 100,
-			expResult: []models.User{
-				{Email: "common@example.com"},
-			},
+			senderId:  100,
+			limit:     10,
+			expResult: []string{"common@example.com"},
+		},
+		"success with a page after an email": {
+			senderId:   100,
+			afterEmail: "common@example.com",
+			limit:      10,
+			expResult:  []string{},
 		},
 		"query by an unknown input userId": {
-			senderId: 99,
+			senderId:  99,
+			limit:     10,
+			expResult: []string{},
 		},
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
+			result, err := repo.GetRecipientEmails(ctx, tc.senderId, tc.afterEmail, tc.limit)
+
 			require.NoError(t, err)
-			repo := NewDBRepo(db)
+			require.Equal(t, tc.expResult, result)
+		})
+	}
+}
 
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
-			result, err := repo.GetRecipientEmails(ctx, tc.senderId)
+func TestRepository_CreateUserBlock(t *testing.T) {
+	tcs := map[string]struct {
+		requestorId    int
+		targetId       int
+		expError       error
+		expSQLiteError error
+	}{
+		"success with adding input of userIds": {
+			requestorId: 100,
+			targetId:    101,
+		},
+		"query by an existing blocking relationship": {
+			requestorId: 100,
+			targetId:    103,
+			expError:    ErrExistedBlockedUser,
+		},
+		"query by an unknown input userIds": {
+			requestorId:    99,
+			targetId:       101,
+			expError:       errors.New("pq: insert or update on table \"user_blocks\" violates foreign key constraint \"user_blocks_requestor_id_fkey\""),
+			expSQLiteError: errors.New("FOREIGN KEY constraint failed"),
+		},
+	}
 
-			require.NoError(t, err)
-			require.Equal(t, len(tc.expResult), len(result))
-			for i, ss := range tc.expResult {
-				require.Equal(t, ss.Email, result[i].Email)
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			err := repo.CreateUserBlock(ctx, tc.requestorId, tc.targetId)
+
+			if expError := dialectError(repo, tc.expError, tc.expSQLiteError); expError != nil {
+				require.EqualError(t, err, expError.Error())
+			} else {
+				require.NoError(t, err)
 			}
 		})
 	}
 }
 
-func TestRepository_CreateUserBlock(t *testing.T) {
+func TestRepository_DeleteUserBlock(t *testing.T) {
 	tcs := map[string]struct {
 		requestorId int
 		targetId    int
@@ -297,30 +594,27 @@ func TestRepository_CreateUserBlock(t *testing.T) {
 	}{
 		"success with adding input of userIds": {
 			requestorId: 100,
-			targetId:    101,
+			targetId:    103,
 		},
-		"query by an unknown input userIds": {
-			requestorId: 99,
-			targetId:    101,
-			expError:    errors.New("models: unable to insert into user_blocks: pq: insert or update on table \"user_blocks\" violates foreign key constraint \"user_blocks_requestor_id_fkey\""),
+		"query by the target of a blocking relationship": {
+			requestorId: 103,
+			targetId:    100,
+			expError:    ErrNotExistedBlockedUser,
 		},
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
-			require.NoError(t, err)
-			repo := NewDBRepo(db)
-
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
-			err = repo.CreateUserBlock(ctx, tc.requestorId, tc.targetId)
+			err := repo.DeleteUserBlock(ctx, tc.requestorId, tc.targetId)
 
 			if tc.expError != nil {
 				require.EqualError(t, err, tc.expError.Error())
 			} else {
 				require.NoError(t, err)
+				isBlocked, err := repo.IsBlockedUser(ctx, tc.requestorId, tc.targetId)
+				require.NoError(t, err)
+				require.False(t, isBlocked)
 			}
 		})
 	}
@@ -346,14 +640,8 @@ func TestRepository_IsSubscribedFriend(t *testing.T) {
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
-			require.NoError(t, err)
-			repo := NewDBRepo(db)
-
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
 			result, err := repo.IsSubscribedUser(ctx, tc.requestorId, tc.targetId)
 
 			if tc.expError != nil {
@@ -376,21 +664,19 @@ func TestRepository_GetUserIDByEmail(t *testing.T) {
 			email:     "john@example.com",
 			expResult: 100,
 		},
+		"success with a mixed case email with spaces": {
+			email:     " John@Example.COM ",
+			expResult: 100,
+		},
 		"query by an unknown input email": {
 			email:    "test@example.com",
-			expError: errors.New("sql: no rows in result set"),
+			expError: ErrNotExistedUser,
 		},
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
-			require.NoError(t, err)
-			repo := NewDBRepo(db)
-
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
 			result, err := repo.GetUserIDByEmail(ctx, tc.email)
 
 			if tc.expError != nil {
@@ -420,14 +706,8 @@ func TestRepository_GetEmailsByUserIDs(t *testing.T) {
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
-			require.NoError(t, err)
-			repo := NewDBRepo(db)
-
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
 			result, err := repo.GetEmailsByUserIDs(ctx, tc.userIds)
 
 			require.NoError(t, err)
@@ -439,12 +719,82 @@ func TestRepository_GetEmailsByUserIDs(t *testing.T) {
 	}
 }
 
+func TestRepository_GetFriendSuggestions(t *testing.T) {
+	tcs := map[string]struct {
+		userId    int
+		expResult []FriendSuggestion
+	}{
+		"success with adding input of userId": {
+			userId: 100,
+			expResult: []FriendSuggestion{
+				{Email: "andy@example.com", MutualFriends: 1},
+			},
+		},
+		"query by an user with a pending request to a friend of friend": {
+			userId: 101,
+			expResult: []FriendSuggestion{
+				{Email: "john@example.com", MutualFriends: 1},
+			},
+		},
+		"query by an user who is blocked and has pending request with friends of friend": {
+			userId:    103,
+			expResult: []FriendSuggestion{},
+		},
+		"query by an unknown input userId": {
+			userId:    99,
+			expResult: []FriendSuggestion{},
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			result, err := repo.GetFriendSuggestions(ctx, tc.userId)
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expResult, result)
+		})
+	}
+}
+
+func TestRepository_GetUsersByIDs(t *testing.T) {
+	tcs := map[string]struct {
+		userIds   []int
+		expResult []string
+	}{
+		"success with adding input of userIds": {
+			userIds:   []int{100, 101},
+			expResult: []string{"john@example.com", "andy@example.com"},
+		},
+		"query by an unknown input userIds": {
+			userIds:   []int{99},
+			expResult: []string{},
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			result, err := repo.GetUsersByIDs(ctx, tc.userIds)
+
+			require.NoError(t, err)
+			require.Equal(t, len(tc.expResult), len(result))
+			for i, ss := range tc.expResult {
+				require.Equal(t, ss, result[i].Email)
+			}
+		})
+	}
+}
+
 func TestRepository_GetUsers(t *testing.T) {
 	tcs := map[string]struct {
+		afterId   int
+		limit     int
 		expResult models.UserSlice
 		expError  error
 	}{
 		"successfully get all users": {
+			limit: 10,
 			expResult: models.UserSlice{
 				&models.User{Name: "john", Email: "john@example.com"},
 				&models.User{Name: "andy", Email: "andy@example.com"},
@@ -453,18 +803,25 @@ func TestRepository_GetUsers(t *testing.T) {
 				&models.User{Name: "kate", Email: "kate@example.com"},
 			},
 		},
+		"successfully get a page of users after an id": {
+			afterId: 101,
+			limit:   2,
+			expResult: models.UserSlice{
+				&models.User{Name: "common", Email: "common@example.com"},
+				&models.User{Name: "lisa", Email: "lisa@example.com"},
+			},
+		},
+		"get an empty page after the last id": {
+			afterId:   104,
+			limit:     2,
+			expResult: models.UserSlice{},
+		},
 	}
 
 	for desc, tc := range tcs {
-		t.Run(desc, func(t *testing.T) {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
 			ctx := context.Background()
-			db, err := config.NewDatabase()
-			require.NoError(t, err)
-			repo := NewDBRepo(db)
-
-			// load testdata
-			loadSqlTestFile(t, db, "testdata/friends.sql")
-			result, err := repo.GetUsers(ctx)
+			result, err := repo.GetUsers(ctx, tc.afterId, tc.limit)
 			require.NoError(t, err)
 			require.Equal(t, len(tc.expResult), len(result))
 			for i, ss := range tc.expResult {
@@ -473,3 +830,11 @@ func TestRepository_GetUsers(t *testing.T) {
 		})
 	}
 }
+
+func TestRepository_CountUsers(t *testing.T) {
+	runTestRepos(t, "success with counting all users", func(t *testing.T, repo DBRepo, db *sql.DB) {
+		count, err := repo.CountUsers(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, int64(5), count)
+	})
+}
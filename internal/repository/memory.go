@@ -0,0 +1,873 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+)
+
+// Error of a friendship of a user with itself, it is the CHECK constraint of friends table
+var errSelfFriendship = errors.New("a user cannot be friend of itself")
+
+// Error of a relationship with a user who does not exist, it is the foreign key of relationship tables
+func errMissingUser(userId int) error {
+	return fmt.Errorf("user %d does not exist", userId)
+}
+
+// Users of a relationship, the first user is requestor of subscriptions and blocking relationships
+// and the lower id of friendships
+type userPair struct {
+	first  int
+	second int
+}
+
+// Tables of the in-memory repository, relationships are mapped to their order of insertion
+type memoryData struct {
+	lastUserID          int
+	lastFriendRequestID int
+	lastRelationship    int
+	lastAPIKeyID        int
+
+	users          map[int]models.User
+	friends        map[userPair]int
+	subscriptions  map[userPair]int
+	userBlocks     map[userPair]int
+	friendRequests map[int]models.FriendRequest
+	apiKeys        map[int]models.APIKey
+}
+
+func newMemoryData() *memoryData {
+	return &memoryData{
+		users:          make(map[int]models.User),
+		friends:        make(map[userPair]int),
+		subscriptions:  make(map[userPair]int),
+		userBlocks:     make(map[userPair]int),
+		friendRequests: make(map[int]models.FriendRequest),
+		apiKeys:        make(map[int]models.APIKey),
+	}
+}
+
+// Copy the tables, it is restored when a unit of work fails
+func (_self *memoryData) clone() *memoryData {
+	data := *_self
+	data.users = make(map[int]models.User, len(_self.users))
+	for id, user := range _self.users {
+		data.users[id] = user
+	}
+	data.friends = clonePairs(_self.friends)
+	data.subscriptions = clonePairs(_self.subscriptions)
+	data.userBlocks = clonePairs(_self.userBlocks)
+	data.friendRequests = make(map[int]models.FriendRequest, len(_self.friendRequests))
+	for id, friendRequest := range _self.friendRequests {
+		data.friendRequests[id] = friendRequest
+	}
+	data.apiKeys = make(map[int]models.APIKey, len(_self.apiKeys))
+	for id, apiKey := range _self.apiKeys {
+		data.apiKeys[id] = apiKey
+	}
+	return &data
+}
+
+func clonePairs(pairs map[userPair]int) map[userPair]int {
+	result := make(map[userPair]int, len(pairs))
+	for pair, order := range pairs {
+		result[pair] = order
+	}
+	return result
+}
+
+// Get pairs which match in order of insertion
+func sortedPairs(pairs map[userPair]int, match func(pair userPair) bool) []userPair {
+	result := make([]userPair, 0)
+	for pair := range pairs {
+		if match(pair) {
+			result = append(result, pair)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return pairs[result[i]] < pairs[result[j]] })
+	return result
+}
+
+// Insert a relationship of existing users, errExisted is returned when the relationship has been existed
+func (_self *memoryData) insertPair(pairs map[userPair]int, pair userPair, errExisted error) error {
+	if _, ok := pairs[pair]; ok {
+		return errExisted
+	}
+	for _, userId := range []int{pair.first, pair.second} {
+		if _, ok := _self.users[userId]; !ok {
+			return errMissingUser(userId)
+		}
+	}
+	_self.lastRelationship++
+	pairs[pair] = _self.lastRelationship
+	return nil
+}
+
+// Delete a relationship, errNotExisted is returned when the relationship does not exist
+func deletePair(pairs map[userPair]int, pair userPair, errNotExisted error) error {
+	if _, ok := pairs[pair]; !ok {
+		return errNotExisted
+	}
+	delete(pairs, pair)
+	return nil
+}
+
+// Verify a relationship whose both users are any of the given users
+func hasPairAmong(pairs map[userPair]int, userId int, otherId int) bool {
+	for _, first := range []int{userId, otherId} {
+		for _, second := range []int{userId, otherId} {
+			if _, ok := pairs[userPair{first, second}]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Verify a blocking relationship between users in either direction
+func (_self *memoryData) isBlocked(userId int, otherId int) bool {
+	_, blocked := _self.userBlocks[userPair{userId, otherId}]
+	_, blockedBy := _self.userBlocks[userPair{otherId, userId}]
+	return blocked || blockedBy
+}
+
+// Get ids of friends of a user in ascending order
+func (_self *memoryData) friendIDs(userId int) []int {
+	ids := make([]int, 0)
+	for pair := range _self.friends {
+		if pair.first == userId {
+			ids = append(ids, pair.second)
+		}
+		if pair.second == userId {
+			ids = append(ids, pair.first)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Get ids of friends of a user in ascending order, friends in a blocking relationship with the user are skipped
+func (_self *memoryData) unblockedFriendIDs(userId int) []int {
+	ids := make([]int, 0)
+	for _, id := range _self.friendIDs(userId) {
+		if !_self.isBlocked(userId, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Get a page of emails of friends in ascending order of ids after afterId
+func (_self *memoryData) friendPage(ids []int, afterId int, limit int) FriendPage {
+	page := FriendPage{Emails: []string{}, Total: len(ids)}
+	lastId := 0
+	for _, id := range ids[sort.SearchInts(ids, afterId+1):] {
+		if len(page.Emails) == limit {
+			page.NextAfterID = lastId
+			break
+		}
+		page.Emails = append(page.Emails, _self.users[id].Email)
+		lastId = id
+	}
+	return page
+}
+
+// Get ids of users in ascending order
+func (_self *memoryData) userIDs() []int {
+	ids := make([]int, 0, len(_self.users))
+	for id := range _self.users {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Get id of a user by case-insensitive email
+func (_self *memoryData) userIDByEmail(email string) (int, bool) {
+	key := emailKey(email)
+	for id, user := range _self.users {
+		if emailKey(user.Email) == key {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// Get friend requests which match in order of created_at
+func (_self *memoryData) sortedFriendRequests(match func(friendRequest models.FriendRequest) bool) models.FriendRequestSlice {
+	result := models.FriendRequestSlice{}
+	for _, friendRequest := range _self.friendRequests {
+		if match(friendRequest) {
+			friendRequest := friendRequest
+			result = append(result, &friendRequest)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.Before(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Verify a pending friend request whose both users are any of the given users
+func (_self *memoryData) isPendingFriendRequest(userId int, friendId int) bool {
+	for _, friendRequest := range _self.friendRequests {
+		if friendRequest.Status != FriendRequestPending {
+			continue
+		}
+		if (friendRequest.RequestorID == userId || friendRequest.RequestorID == friendId) &&
+			(friendRequest.TargetID == userId || friendRequest.TargetID == friendId) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get id of the pending friend request from requestor to target
+func (_self *memoryData) pendingFriendRequestID(requestorId int, targetId int) (int, bool) {
+	for id, friendRequest := range _self.friendRequests {
+		if friendRequest.RequestorID == requestorId && friendRequest.TargetID == targetId && friendRequest.Status == FriendRequestPending {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// Verify a status of friend requests, it is the CHECK constraint of friend_requests table
+func isFriendRequestStatus(status string) bool {
+	switch status {
+	case FriendRequestPending, FriendRequestAccepted, FriendRequestRejected, FriendRequestCancelled:
+		return true
+	}
+	return false
+}
+
+type memoryStore struct {
+	mu   sync.Mutex
+	data *memoryData
+}
+
+// MemoryRepo is a thread-safe SpecRepo which keeps data in memory with the same semantics as DBRepo,
+// it is used by tests and by the server without a database
+type MemoryRepo struct {
+	store *memoryStore
+	// The repository is used in a unit of work which holds the lock of store
+	inTx bool
+}
+
+func NewMemoryRepo() MemoryRepo {
+	return MemoryRepo{
+		store: &memoryStore{data: newMemoryData()},
+	}
+}
+
+// Run fn with the tables while holding the lock of store, the lock is already held in a unit of work
+func (_self MemoryRepo) do(ctx context.Context, fn func(data *memoryData) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !_self.inTx {
+		_self.store.mu.Lock()
+		defer _self.store.mu.Unlock()
+	}
+	return fn(_self.store.data)
+}
+
+// Run a unit of work while holding the lock of store, changes of fn are discarded when fn fails
+func (_self MemoryRepo) WithTx(ctx context.Context, fn func(repo SpecRepo) error) error {
+	if _self.inTx {
+		return fn(_self)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	_self.store.mu.Lock()
+	defer _self.store.mu.Unlock()
+
+	snapshot := _self.store.data.clone()
+	if err := fn(MemoryRepo{store: _self.store, inTx: true}); err != nil {
+		_self.store.data = snapshot
+		return err
+	}
+	return nil
+}
+
+// Users do not have to be locked, a unit of work holds the lock of the whole store
+func (_self MemoryRepo) LockUsers(ctx context.Context, userIds ...int) error {
+	return ctx.Err()
+}
+
+// Insert a friendship in the canonical order, an existing friendship is not inserted again
+func (_self MemoryRepo) CreateFriend(ctx context.Context, userId int, friendId int) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		if userId == friendId {
+			return errSelfFriendship
+		}
+		userId, friendId = canonicalFriendIDs(userId, friendId)
+		return data.insertPair(data.friends, userPair{userId, friendId}, ErrExistedFriendship)
+	})
+}
+
+// Delete a friendship regardless of the given order of user ids
+func (_self MemoryRepo) DeleteFriend(ctx context.Context, userId int, friendId int) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		userId, friendId = canonicalFriendIDs(userId, friendId)
+		return deletePair(data.friends, userPair{userId, friendId}, ErrNotExistedFriendship)
+	})
+}
+
+// Get friendships of a user
+func (_self MemoryRepo) GetFriendsByID(ctx context.Context, userId int) (models.FriendSlice, error) {
+	friends := models.FriendSlice{}
+	err := _self.do(ctx, func(data *memoryData) error {
+		for _, pair := range sortedPairs(data.friends, func(pair userPair) bool {
+			return pair.first == userId || pair.second == userId
+		}) {
+			friends = append(friends, &models.Friend{UserID: pair.first, FriendID: pair.second})
+		}
+		return nil
+	})
+	return friends, err
+}
+
+// Get friendships of any of user ids, friendships between users who have blocked each other are skipped
+func (_self MemoryRepo) GetUnblockedFriendsByIDs(ctx context.Context, userIds []int) (models.FriendSlice, error) {
+	friends := models.FriendSlice{}
+	err := _self.do(ctx, func(data *memoryData) error {
+		ids := make(map[int]bool)
+		for _, id := range userIds {
+			ids[id] = true
+		}
+		for _, pair := range sortedPairs(data.friends, func(pair userPair) bool {
+			return (ids[pair.first] || ids[pair.second]) && !data.isBlocked(pair.first, pair.second)
+		}) {
+			friends = append(friends, &models.Friend{UserID: pair.first, FriendID: pair.second})
+		}
+		return nil
+	})
+	return friends, err
+}
+
+// Get a page of emails of friends of a user after afterId,
+// friendships between users who have blocked each other are skipped
+func (_self MemoryRepo) GetUnblockedFriendEmails(ctx context.Context, userId int, afterId int, limit int) (FriendPage, error) {
+	var page FriendPage
+	err := _self.do(ctx, func(data *memoryData) error {
+		page = data.friendPage(data.unblockedFriendIDs(userId), afterId, limit)
+		return nil
+	})
+	return page, err
+}
+
+// Get a page of emails of common friends of 2 users after afterId,
+// friendships between users who have blocked each other are skipped
+func (_self MemoryRepo) GetCommonFriendEmails(ctx context.Context, userId int, otherId int, afterId int, limit int) (FriendPage, error) {
+	var page FriendPage
+	err := _self.do(ctx, func(data *memoryData) error {
+		otherFriends := make(map[int]bool)
+		for _, id := range data.unblockedFriendIDs(otherId) {
+			otherFriends[id] = true
+		}
+		commonIds := make([]int, 0)
+		for _, id := range data.unblockedFriendIDs(userId) {
+			if otherFriends[id] {
+				commonIds = append(commonIds, id)
+			}
+		}
+		page = data.friendPage(commonIds, afterId, limit)
+		return nil
+	})
+	return page, err
+}
+
+// Get blocking relationships which were created by or for a user
+func (_self MemoryRepo) GetUserBlocksByID(ctx context.Context, userId int) (models.UserBlockSlice, error) {
+	userBlocks := models.UserBlockSlice{}
+	err := _self.do(ctx, func(data *memoryData) error {
+		for _, pair := range sortedPairs(data.userBlocks, func(pair userPair) bool {
+			return pair.first == userId || pair.second == userId
+		}) {
+			userBlocks = append(userBlocks, &models.UserBlock{RequestorID: pair.first, TargetID: pair.second})
+		}
+		return nil
+	})
+	return userBlocks, err
+}
+
+// Insert a subscription of requestor to target, an existing subscription is not inserted again
+func (_self MemoryRepo) CreateSubscription(ctx context.Context, requestorId int, targetId int) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		return data.insertPair(data.subscriptions, userPair{requestorId, targetId}, ErrExistedSubscription)
+	})
+}
+
+// Delete a subscription of requestor to target
+func (_self MemoryRepo) DeleteSubscription(ctx context.Context, requestorId int, targetId int) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		return deletePair(data.subscriptions, userPair{requestorId, targetId}, ErrNotExistedSubscription)
+	})
+}
+
+// Get emails of friends and subscribers of sender (who are not blocked by sender) after afterEmail ordered by email,
+// at most limit emails are returned
+func (_self MemoryRepo) GetRecipientEmails(ctx context.Context, senderId int, afterEmail string, limit int) ([]string, error) {
+	emails := make([]string, 0)
+	err := _self.do(ctx, func(data *memoryData) error {
+		recipientIds := make(map[int]bool)
+		for _, id := range data.friendIDs(senderId) {
+			recipientIds[id] = true
+		}
+		for pair := range data.subscriptions {
+			if pair.second == senderId {
+				recipientIds[pair.first] = true
+			}
+		}
+
+		for id := range recipientIds {
+			if email := data.users[id].Email; id != senderId && email > afterEmail && !data.isBlocked(senderId, id) {
+				emails = append(emails, email)
+			}
+		}
+		sort.Strings(emails)
+		if len(emails) > limit {
+			emails = emails[:limit]
+		}
+		return nil
+	})
+	return emails, err
+}
+
+// Insert a blocking relationship of users, an existing block is not inserted again
+func (_self MemoryRepo) CreateUserBlock(ctx context.Context, requestorId int, targetId int) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		return data.insertPair(data.userBlocks, userPair{requestorId, targetId}, ErrExistedBlockedUser)
+	})
+}
+
+// Delete a blocking relationship which was created by requestor
+func (_self MemoryRepo) DeleteUserBlock(ctx context.Context, requestorId int, targetId int) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		return deletePair(data.userBlocks, userPair{requestorId, targetId}, ErrNotExistedBlockedUser)
+	})
+}
+
+// Verify a existing friendship regardless of the given order of user ids
+func (_self MemoryRepo) IsExistedFriend(ctx context.Context, userId int, friendId int) (bool, error) {
+	var existed bool
+	err := _self.do(ctx, func(data *memoryData) error {
+		userId, friendId = canonicalFriendIDs(userId, friendId)
+		_, existed = data.friends[userPair{userId, friendId}]
+		return nil
+	})
+	return existed, err
+}
+
+// Verify a blocking relationship of users
+func (_self MemoryRepo) IsBlockedUser(ctx context.Context, userId int, friendId int) (bool, error) {
+	var blocked bool
+	err := _self.do(ctx, func(data *memoryData) error {
+		blocked = hasPairAmong(data.userBlocks, userId, friendId)
+		return nil
+	})
+	return blocked, err
+}
+
+// Verify a subscription relationship of users
+func (_self MemoryRepo) IsSubscribedUser(ctx context.Context, requestorId int, targetId int) (bool, error) {
+	var subscribed bool
+	err := _self.do(ctx, func(data *memoryData) error {
+		subscribed = hasPairAmong(data.subscriptions, requestorId, targetId)
+		return nil
+	})
+	return subscribed, err
+}
+
+// Get a user id by case-insensitive email
+func (_self MemoryRepo) GetUserIDByEmail(ctx context.Context, email string) (int, error) {
+	var userId int
+	err := _self.do(ctx, func(data *memoryData) error {
+		id, ok := data.userIDByEmail(email)
+		if !ok {
+			return ErrNotExistedUser
+		}
+		userId = id
+		return nil
+	})
+	return userId, err
+}
+
+// Get list of emails ordered by ids of the corresponding users
+func (_self MemoryRepo) GetEmailsByUserIDs(ctx context.Context, userIDs []int) ([]string, error) {
+	emails := []string{}
+	err := _self.do(ctx, func(data *memoryData) error {
+		ids := make(map[int]bool)
+		for _, id := range userIDs {
+			ids[id] = true
+		}
+		for _, id := range data.userIDs() {
+			if ids[id] {
+				emails = append(emails, data.users[id].Email)
+			}
+		}
+		return nil
+	})
+	return emails, err
+}
+
+// Get users by list of ids in order of ids
+func (_self MemoryRepo) GetUsersByIDs(ctx context.Context, userIDs []int) (models.UserSlice, error) {
+	users := models.UserSlice{}
+	err := _self.do(ctx, func(data *memoryData) error {
+		ids := make(map[int]bool)
+		for _, id := range userIDs {
+			ids[id] = true
+		}
+		for _, id := range data.userIDs() {
+			if ids[id] {
+				user := data.users[id]
+				users = append(users, &user)
+			}
+		}
+		return nil
+	})
+	return users, err
+}
+
+// Get a page of users ordered by id, the page starts after the user of afterId
+func (_self MemoryRepo) GetUsers(ctx context.Context, afterId int, limit int) (models.UserSlice, error) {
+	users := models.UserSlice{}
+	err := _self.do(ctx, func(data *memoryData) error {
+		for _, id := range data.userIDs() {
+			if len(users) == limit {
+				break
+			}
+			if id > afterId {
+				user := data.users[id]
+				users = append(users, &user)
+			}
+		}
+		return nil
+	})
+	return users, err
+}
+
+// Count all users
+func (_self MemoryRepo) CountUsers(ctx context.Context) (int64, error) {
+	var count int64
+	err := _self.do(ctx, func(data *memoryData) error {
+		count = int64(len(data.users))
+		return nil
+	})
+	return count, err
+}
+
+// Insert a new user with a normalized email, ErrExistedUser is returned when the email has been used
+func (_self MemoryRepo) CreateUser(ctx context.Context, name string, email string) (*models.User, error) {
+	var user models.User
+	err := _self.do(ctx, func(data *memoryData) error {
+		if _, ok := data.userIDByEmail(email); ok {
+			return ErrExistedUser
+		}
+		now := time.Now()
+		data.lastUserID++
+		user = models.User{ID: data.lastUserID, Name: name, Email: storedEmail(email), CreatedAt: now, UpdatedAt: now}
+		data.users[user.ID] = user
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Get a user by case-insensitive email
+func (_self MemoryRepo) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := _self.do(ctx, func(data *memoryData) error {
+		id, ok := data.userIDByEmail(email)
+		if !ok {
+			return ErrNotExistedUser
+		}
+		user = data.users[id]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Verify a existing user by case-insensitive email
+func (_self MemoryRepo) IsExistedUser(ctx context.Context, email string) (bool, error) {
+	var existed bool
+	err := _self.do(ctx, func(data *memoryData) error {
+		_, existed = data.userIDByEmail(email)
+		return nil
+	})
+	return existed, err
+}
+
+// Update name of a user, updated_at is set to current time
+func (_self MemoryRepo) UpdateUserName(ctx context.Context, userId int, name string) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		user, ok := data.users[userId]
+		if !ok {
+			return ErrNotExistedUser
+		}
+		user.Name = name
+		user.UpdatedAt = time.Now()
+		data.users[userId] = user
+		return nil
+	})
+}
+
+// Delete a user with all of friendships, subscriptions, blocking relationships and friend requests of the user
+func (_self MemoryRepo) DeleteUser(ctx context.Context, userId int) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		if _, ok := data.users[userId]; !ok {
+			return ErrNotExistedUser
+		}
+		for _, pairs := range []map[userPair]int{data.friends, data.subscriptions, data.userBlocks} {
+			for pair := range pairs {
+				if pair.first == userId || pair.second == userId {
+					delete(pairs, pair)
+				}
+			}
+		}
+		for id, friendRequest := range data.friendRequests {
+			if friendRequest.RequestorID == userId || friendRequest.TargetID == userId {
+				delete(data.friendRequests, id)
+			}
+		}
+		delete(data.users, userId)
+		return nil
+	})
+}
+
+// Get friends of friends who are not friend of user yet, ranked by number of mutual friends.
+// Users who are in a blocking relationship or a pending friend request with user are excluded
+func (_self MemoryRepo) GetFriendSuggestions(ctx context.Context, userId int) ([]FriendSuggestion, error) {
+	suggestions := make([]FriendSuggestion, 0)
+	err := _self.do(ctx, func(data *memoryData) error {
+		friendIds := data.friendIDs(userId)
+		isFriend := make(map[int]bool)
+		for _, id := range friendIds {
+			isFriend[id] = true
+		}
+
+		mutualFriends := make(map[int]int)
+		for _, friendId := range friendIds {
+			for _, id := range data.friendIDs(friendId) {
+				if id == userId || isFriend[id] || data.isBlocked(userId, id) {
+					continue
+				}
+				if data.isPendingFriendRequest(userId, id) {
+					continue
+				}
+				mutualFriends[id]++
+			}
+		}
+
+		for id, count := range mutualFriends {
+			suggestions = append(suggestions, FriendSuggestion{Email: data.users[id].Email, MutualFriends: count})
+		}
+		sort.Slice(suggestions, func(i, j int) bool {
+			if suggestions[i].MutualFriends != suggestions[j].MutualFriends {
+				return suggestions[i].MutualFriends > suggestions[j].MutualFriends
+			}
+			return suggestions[i].Email < suggestions[j].Email
+		})
+		return nil
+	})
+	return suggestions, err
+}
+
+// Insert a new pending friend request, only one pending request is inserted for the same users
+func (_self MemoryRepo) CreateFriendRequest(ctx context.Context, requestorId int, targetId int) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		if _, ok := data.pendingFriendRequestID(requestorId, targetId); ok {
+			return ErrExistedFriendRequest
+		}
+		for _, userId := range []int{requestorId, targetId} {
+			if _, ok := data.users[userId]; !ok {
+				return errMissingUser(userId)
+			}
+		}
+		now := time.Now()
+		data.lastFriendRequestID++
+		data.friendRequests[data.lastFriendRequestID] = models.FriendRequest{
+			ID:          data.lastFriendRequestID,
+			RequestorID: requestorId,
+			TargetID:    targetId,
+			Status:      FriendRequestPending,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		return nil
+	})
+}
+
+// Verify a pending friend request between users in either direction
+func (_self MemoryRepo) IsPendingFriendRequest(ctx context.Context, userId int, friendId int) (bool, error) {
+	var pending bool
+	err := _self.do(ctx, func(data *memoryData) error {
+		pending = data.isPendingFriendRequest(userId, friendId)
+		return nil
+	})
+	return pending, err
+}
+
+// Get a pending friend request which was sent from requestor to target
+func (_self MemoryRepo) GetPendingFriendRequest(ctx context.Context, requestorId int, targetId int) (*models.FriendRequest, error) {
+	var friendRequest models.FriendRequest
+	err := _self.do(ctx, func(data *memoryData) error {
+		id, ok := data.pendingFriendRequestID(requestorId, targetId)
+		if !ok {
+			return ErrNotExistedFriendRequest
+		}
+		friendRequest = data.friendRequests[id]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &friendRequest, nil
+}
+
+// Get pending friend requests which were sent to user
+func (_self MemoryRepo) GetIncomingFriendRequests(ctx context.Context, userId int) (models.FriendRequestSlice, error) {
+	var friendRequests models.FriendRequestSlice
+	err := _self.do(ctx, func(data *memoryData) error {
+		friendRequests = data.sortedFriendRequests(func(friendRequest models.FriendRequest) bool {
+			return friendRequest.TargetID == userId && friendRequest.Status == FriendRequestPending
+		})
+		return nil
+	})
+	return friendRequests, err
+}
+
+// Get pending friend requests which were sent by user
+func (_self MemoryRepo) GetOutgoingFriendRequests(ctx context.Context, userId int) (models.FriendRequestSlice, error) {
+	var friendRequests models.FriendRequestSlice
+	err := _self.do(ctx, func(data *memoryData) error {
+		friendRequests = data.sortedFriendRequests(func(friendRequest models.FriendRequest) bool {
+			return friendRequest.RequestorID == userId && friendRequest.Status == FriendRequestPending
+		})
+		return nil
+	})
+	return friendRequests, err
+}
+
+// Accept a pending friend request and insert the friendship
+func (_self MemoryRepo) AcceptFriendRequest(ctx context.Context, requestId int) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		friendRequest, ok := data.friendRequests[requestId]
+		if !ok || friendRequest.Status != FriendRequestPending {
+			return ErrNotExistedFriendRequest
+		}
+		if friendRequest.RequestorID == friendRequest.TargetID {
+			return errSelfFriendship
+		}
+
+		userId, friendId := canonicalFriendIDs(friendRequest.RequestorID, friendRequest.TargetID)
+		if err := data.insertPair(data.friends, userPair{userId, friendId}, ErrExistedFriendship); err != nil {
+			return err
+		}
+
+		friendRequest.Status = FriendRequestAccepted
+		friendRequest.UpdatedAt = time.Now()
+		data.friendRequests[requestId] = friendRequest
+		return nil
+	})
+}
+
+// Change status of a pending friend request, such as rejected or cancelled
+func (_self MemoryRepo) UpdateFriendRequestStatus(ctx context.Context, requestId int, status string) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		if !isFriendRequestStatus(status) {
+			return fmt.Errorf("invalid status of friend request: %s", status)
+		}
+		friendRequest, ok := data.friendRequests[requestId]
+		if !ok || friendRequest.Status != FriendRequestPending {
+			return ErrNotExistedFriendRequest
+		}
+		friendRequest.Status = status
+		friendRequest.UpdatedAt = time.Now()
+		data.friendRequests[requestId] = friendRequest
+		return nil
+	})
+}
+
+// Create a key, ErrExistedAPIKey is returned when the name or the hash has been used
+func (_self MemoryRepo) CreateAPIKey(ctx context.Context, name string, keyHash string) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	err := _self.do(ctx, func(data *memoryData) error {
+		for _, existed := range data.apiKeys {
+			if existed.Name == name || existed.KeyHash == keyHash {
+				return ErrExistedAPIKey
+			}
+		}
+		data.lastAPIKeyID++
+		apiKey = models.APIKey{ID: data.lastAPIKeyID, Name: name, KeyHash: keyHash, CreatedAt: time.Now()}
+		data.apiKeys[apiKey.ID] = apiKey
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// Get a key which has not been revoked by the hash of the key
+func (_self MemoryRepo) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var apiKey models.APIKey
+	err := _self.do(ctx, func(data *memoryData) error {
+		for _, existed := range data.apiKeys {
+			if existed.KeyHash == keyHash && !existed.RevokedAt.Valid {
+				apiKey = existed
+				return nil
+			}
+		}
+		return ErrNotExistedAPIKey
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// Get all keys including revoked keys in order of creation
+func (_self MemoryRepo) GetAPIKeys(ctx context.Context) (models.APIKeySlice, error) {
+	apiKeys := models.APIKeySlice{}
+	err := _self.do(ctx, func(data *memoryData) error {
+		for _, apiKey := range data.apiKeys {
+			apiKeys = append(apiKeys, &apiKey)
+		}
+		sort.Slice(apiKeys, func(i, j int) bool { return apiKeys[i].ID < apiKeys[j].ID })
+		return nil
+	})
+	return apiKeys, err
+}
+
+// Revoke a key by its name, ErrNotExistedAPIKey is returned when the key does not exist or has been revoked
+func (_self MemoryRepo) RevokeAPIKey(ctx context.Context, name string) error {
+	return _self.do(ctx, func(data *memoryData) error {
+		for id, apiKey := range data.apiKeys {
+			if apiKey.Name == name && !apiKey.RevokedAt.Valid {
+				apiKey.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				data.apiKeys[id] = apiKey
+				return nil
+			}
+		}
+		return ErrNotExistedAPIKey
+	})
+}
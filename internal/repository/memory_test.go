@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRepo_ConcurrentUnitsOfWork(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepo()
+	john, err := repo.CreateUser(ctx, "john", "john@example.com")
+	require.NoError(t, err)
+	andy, err := repo.CreateUser(ctx, "andy", "andy@example.com")
+	require.NoError(t, err)
+
+	// Concurrent check-then-insert units of work create the friendship only once
+	var wg sync.WaitGroup
+	results := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			userId, friendId := john.ID, andy.ID
+			if i%2 == 0 {
+				userId, friendId = friendId, userId
+			}
+			results <- repo.WithTx(ctx, func(txRepo SpecRepo) error {
+				isExisted, err := txRepo.IsExistedFriend(ctx, userId, friendId)
+				if err != nil {
+					return err
+				}
+				if isExisted {
+					return ErrExistedFriendship
+				}
+				return txRepo.CreateFriend(ctx, userId, friendId)
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	created := 0
+	for err := range results {
+		if err == nil {
+			created++
+			continue
+		}
+		require.Equal(t, ErrExistedFriendship, err)
+	}
+	require.Equal(t, 1, created)
+
+	friends, err := repo.GetFriendsByID(ctx, john.ID)
+	require.NoError(t, err)
+	require.Len(t, friends, 1)
+}
+
+func TestMemoryRepo_CanceledContext(t *testing.T) {
+	repo := NewMemoryRepo()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := repo.CreateUser(ctx, "john", "john@example.com")
+	require.Equal(t, context.Canceled, err)
+	err = repo.WithTx(ctx, func(txRepo SpecRepo) error {
+		return nil
+	})
+	require.Equal(t, context.Canceled, err)
+
+	count, err := repo.CountUsers(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(0), count)
+}
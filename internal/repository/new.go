@@ -1,13 +1,135 @@
 package repository
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// SQL dialects of databases which are supported by DBRepo
+const (
+	dialectPostgres = "postgres"
+	dialectSQLite   = "sqlite"
+)
 
 type DBRepo struct {
-	Db *sql.DB
+	Db      *sql.DB
+	tx      *sql.Tx
+	dialect string
+	// Optional wrapper of executors of queries, ex: to trace SQL statements
+	wrapExec func(exec boil.ContextExecutor) boil.ContextExecutor
 }
 
 func NewDBRepo(db *sql.DB) DBRepo {
 	return DBRepo{
-		Db: db,
+		Db:      db,
+		dialect: dialectPostgres,
+	}
+}
+
+// NewSQLiteRepo creates a repository of a SQLite database which has been migrated by db/migrations/sqlite
+func NewSQLiteRepo(db *sql.DB) DBRepo {
+	return DBRepo{
+		Db:      db,
+		dialect: dialectSQLite,
+	}
+}
+
+// NewDriverRepo creates a repository of a database which is opened by the driver of config.NewDatabase
+func NewDriverRepo(db *sql.DB, driver string) DBRepo {
+	if driver == config.DriverSQLite {
+		return NewSQLiteRepo(db)
+	}
+	return NewDBRepo(db)
+}
+
+// WithExecutor gets a repository whose queries run on the executor which is returned by wrap,
+// the database or the transaction of the repository is given to wrap
+func (_self DBRepo) WithExecutor(wrap func(exec boil.ContextExecutor) boil.ContextExecutor) DBRepo {
+	_self.wrapExec = wrap
+	return _self
+}
+
+var postgresPlaceholder = regexp.MustCompile(`\$([0-9]+)`)
+
+// Get a raw query for the dialect of the repository, raw queries are written with Postgres placeholders ($1)
+// which are rewritten to numbered SQLite placeholders (?1), so a placeholder can be used many times in both dialects
+func (_self DBRepo) rebind(query string) string {
+	if _self.dialect != dialectSQLite {
+		return query
+	}
+	return postgresPlaceholder.ReplaceAllString(query, "?$1")
+}
+
+// Get the row locking clause of a SELECT query. SQLite has no row locks, its transactions
+// take the write lock of the database when they begin, so rows are already locked
+func (_self DBRepo) forUpdate() qm.QueryMod {
+	if _self.dialect == dialectSQLite {
+		return qm.For("")
 	}
+	return qm.For("UPDATE")
+}
+
+// Get the collation which compares strings byte by byte like Go does, it is the default collation of SQLite
+func (_self DBRepo) binaryCollation() string {
+	if _self.dialect == dialectSQLite {
+		return "BINARY"
+	}
+	return `"C"`
+}
+
+// Get executor of queries, it is the transaction when the repository is used in a unit of work
+func (_self DBRepo) exec() boil.ContextExecutor {
+	var exec boil.ContextExecutor = _self.Db
+	if _self.tx != nil {
+		exec = _self.tx
+	}
+	if _self.wrapExec != nil {
+		return _self.wrapExec(exec)
+	}
+	return exec
+}
+
+// Run fn in a transaction with a repository which uses the transaction,
+// the transaction is committed when fn succeeds and rolled back otherwise.
+// fn runs in the current transaction when the repository is already used in a unit of work
+func (_self DBRepo) inTx(ctx context.Context, fn func(txRepo DBRepo) error) error {
+	if _self.tx != nil {
+		return fn(_self)
+	}
+
+	tx, err := _self.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(DBRepo{Db: _self.Db, tx: tx, dialect: _self.dialect, wrapExec: _self.wrapExec}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Run a unit of work in a single transaction
+func (_self DBRepo) WithTx(ctx context.Context, fn func(repo SpecRepo) error) error {
+	return _self.inTx(ctx, func(txRepo DBRepo) error {
+		return fn(txRepo)
+	})
+}
+
+// Lock rows of users in order of ids until the end of the current transaction,
+// so checks and changes of relationships between the users do not interleave with other transactions
+func (_self DBRepo) LockUsers(ctx context.Context, userIds ...int) error {
+	_, err := models.Users(
+		qm.Select(models.UserColumns.ID),
+		models.UserWhere.ID.IN(userIds),
+		qm.OrderBy(models.UserColumns.ID),
+		_self.forUpdate(),
+	).All(ctx, _self.exec())
+	return err
 }
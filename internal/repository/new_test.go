@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_WithTx(t *testing.T) {
+	tcs := map[string]struct {
+		fnError   error
+		expError  error
+		expFriend bool
+	}{
+		"success with committing the unit of work": {
+			expFriend: true,
+		},
+		"failed with rolling back the unit of work": {
+			fnError:   errors.New("unit of work failed"),
+			expError:  errors.New("unit of work failed"),
+			expFriend: false,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			err := repo.WithTx(ctx, func(txRepo SpecRepo) error {
+				if err := txRepo.LockUsers(ctx, 100, 104); err != nil {
+					return err
+				}
+				if err := txRepo.CreateFriend(ctx, 100, 104); err != nil {
+					return err
+				}
+				return tc.fnError
+			})
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+			} else {
+				require.NoError(t, err)
+			}
+
+			isExisted, err := repo.IsExistedFriend(ctx, 100, 104)
+			require.NoError(t, err)
+			require.Equal(t, tc.expFriend, isExisted)
+		})
+	}
+}
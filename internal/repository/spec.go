@@ -8,16 +8,55 @@ import (
 
 // SpecRepo is the interface for repository methods
 type SpecRepo interface {
+	WithTx(ctx context.Context, fn func(repo SpecRepo) error) error
+	LockUsers(ctx context.Context, userIds ...int) error
 	CreateFriend(ctx context.Context, userId int, friendId int) error
+	DeleteFriend(ctx context.Context, userId int, friendId int) error
 	GetFriendsByID(ctx context.Context, userId int) (models.FriendSlice, error)
+	GetUnblockedFriendsByIDs(ctx context.Context, userIds []int) (models.FriendSlice, error)
+	GetUnblockedFriendEmails(ctx context.Context, userId int, afterId int, limit int) (FriendPage, error)
+	GetCommonFriendEmails(ctx context.Context, userId int, otherId int, afterId int, limit int) (FriendPage, error)
 	GetUserBlocksByID(ctx context.Context, userId int) (models.UserBlockSlice, error)
 	CreateSubscription(ctx context.Context, requestorId int, targetId int) error
-	GetRecipientEmails(ctx context.Context, senderId int) ([]models.User, error)
+	DeleteSubscription(ctx context.Context, requestorId int, targetId int) error
+	GetRecipientEmails(ctx context.Context, senderId int, afterEmail string, limit int) ([]string, error)
 	CreateUserBlock(ctx context.Context, requestorId int, targetId int) error
+	DeleteUserBlock(ctx context.Context, requestorId int, targetId int) error
 	IsExistedFriend(ctx context.Context, userId int, friendId int) (bool, error)
 	IsBlockedUser(ctx context.Context, userId int, friendId int) (bool, error)
 	IsSubscribedUser(ctx context.Context, requestorId int, targetId int) (bool, error)
 	GetUserIDByEmail(ctx context.Context, email string) (int, error)
 	GetEmailsByUserIDs(ctx context.Context, userIDs []int) ([]string, error)
-	GetUsers(ctx context.Context) (models.UserSlice, error)
+	GetUsersByIDs(ctx context.Context, userIDs []int) (models.UserSlice, error)
+	GetUsers(ctx context.Context, afterId int, limit int) (models.UserSlice, error)
+	CountUsers(ctx context.Context) (int64, error)
+	CreateUser(ctx context.Context, name string, email string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	IsExistedUser(ctx context.Context, email string) (bool, error)
+	UpdateUserName(ctx context.Context, userId int, name string) error
+	DeleteUser(ctx context.Context, userId int) error
+	GetFriendSuggestions(ctx context.Context, userId int) ([]FriendSuggestion, error)
+	CreateFriendRequest(ctx context.Context, requestorId int, targetId int) error
+	IsPendingFriendRequest(ctx context.Context, userId int, friendId int) (bool, error)
+	GetPendingFriendRequest(ctx context.Context, requestorId int, targetId int) (*models.FriendRequest, error)
+	GetIncomingFriendRequests(ctx context.Context, userId int) (models.FriendRequestSlice, error)
+	GetOutgoingFriendRequests(ctx context.Context, userId int) (models.FriendRequestSlice, error)
+	AcceptFriendRequest(ctx context.Context, requestId int) error
+	UpdateFriendRequestStatus(ctx context.Context, requestId int, status string) error
 }
+
+// APIKeyStore is the interface for API keys of clients of the API, keys are apart from SpecRepo
+// since they are not data of users and are not used in units of work
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, name string, keyHash string) (*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	GetAPIKeys(ctx context.Context) (models.APIKeySlice, error)
+	RevokeAPIKey(ctx context.Context, name string) error
+}
+
+var (
+	_ SpecRepo    = DBRepo{}
+	_ SpecRepo    = MemoryRepo{}
+	_ APIKeyStore = DBRepo{}
+	_ APIKeyStore = MemoryRepo{}
+)
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/db/migrations"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/migrate"
+	"github.com/stretchr/testify/require"
+)
+
+// newSQLiteTestDatabase will be creating a SQLite database of the test which is migrated by the embedded migrations
+// and has no data
+func newSQLiteTestDatabase(t testing.TB) *sql.DB {
+	driver, dsn, err := config.ParseDatabaseURL("sqlite://" + filepath.Join(t.TempDir(), "friendmanagement.db"))
+	require.NoError(t, err)
+	db, err := sql.Open(driver, dsn)
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, driver, migrations.SQLite())
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+	loadSqlTestFile(t, db, "testdata/sqlite_empty.sql")
+	return db
+}
+
+func TestConformance_SQLiteRepo(t *testing.T) {
+	runConformanceSuite(t, func(t *testing.T) SpecRepo {
+		return NewSQLiteRepo(newSQLiteTestDatabase(t))
+	})
+}
+
+func TestRepository_Rebind(t *testing.T) {
+	query := `SELECT id FROM users WHERE id <> $1 AND (id = $2 OR id = $1) LIMIT $10`
+
+	require.Equal(t, query, NewDBRepo(nil).rebind(query))
+	require.Equal(t, `SELECT id FROM users WHERE id <> ?1 AND (id = ?2 OR id = ?1) LIMIT ?10`, NewSQLiteRepo(nil).rebind(query))
+}
@@ -0,0 +1,2 @@
+-- Delete all existing data and restart ids of tables
+TRUNCATE TABLE users, friends, subscriptions, user_blocks, friend_requests, api_keys RESTART IDENTITY CASCADE;
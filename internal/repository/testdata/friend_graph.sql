@@ -0,0 +1,34 @@
+-- Seed a friendship graph of 100k users for benchmarks
+TRUNCATE TABLE users CASCADE;
+TRUNCATE TABLE friends CASCADE;
+TRUNCATE TABLE subscriptions CASCADE;
+TRUNCATE TABLE user_blocks CASCADE;
+TRUNCATE TABLE friend_requests CASCADE;
+
+INSERT INTO users(id, name, email, created_at, updated_at)
+SELECT i, 'user' || i, 'user' || i || '@example.com', now(), now()
+FROM generate_series(1, 100000) i;
+
+-- Every user is friend of 5 users with higher ids
+INSERT INTO friends(user_id, friend_id)
+SELECT i, i + d
+FROM generate_series(1, 100000) i, unnest(ARRAY[1, 7, 31, 127, 1021]) d
+WHERE i + d <= 100000;
+
+-- User 1 and user 2 have about 1000 friends, a third of them are common friends
+INSERT INTO friends(user_id, friend_id)
+SELECT 1, i FROM generate_series(3, 100000, 100) i
+ON CONFLICT DO NOTHING;
+INSERT INTO friends(user_id, friend_id)
+SELECT 2, i FROM generate_series(3, 100000, 300) i
+UNION ALL
+SELECT 2, i FROM generate_series(53, 100000, 150) i
+ON CONFLICT DO NOTHING;
+
+-- User 1 has blocked some of friends
+INSERT INTO user_blocks(requestor_id, target_id)
+SELECT 1, i FROM generate_series(3, 100000, 1000) i;
+
+ANALYZE users;
+ANALYZE friends;
+ANALYZE user_blocks;
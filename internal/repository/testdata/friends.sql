@@ -3,6 +3,7 @@ TRUNCATE TABLE users CASCADE;
 TRUNCATE TABLE friends CASCADE;
 TRUNCATE TABLE subscriptions CASCADE;
 TRUNCATE TABLE user_blocks CASCADE;
+TRUNCATE TABLE friend_requests CASCADE;
 
 
 INSERT INTO users(id, name, email, created_at, updated_at) VALUES
@@ -22,4 +23,8 @@ INSERT INTO user_blocks(requestor_id, target_id) VALUES (100,104);
 
 INSERT INTO subscriptions(subscription_requestor_id, subscription_target_id) VALUES (101,103);
 
-
+INSERT INTO friend_requests(id, requestor_id, target_id, status, created_at, updated_at) VALUES
+(200, 103, 104, 'pending', now(), now()),
+(201, 104, 101, 'pending', now(), now()),
+(202, 101, 100, 'rejected', now(), now()),
+(203, 103, 101, 'pending', now(), now());
@@ -0,0 +1,8 @@
+-- Delete all existing data and restart ids of tables of a SQLite database
+DELETE FROM friend_requests;
+DELETE FROM user_blocks;
+DELETE FROM subscriptions;
+DELETE FROM friends;
+DELETE FROM users;
+DELETE FROM api_keys;
+DELETE FROM sqlite_sequence;
@@ -0,0 +1,30 @@
+-- Delete all existing data before adding mock testdata to a SQLite database
+DELETE FROM friend_requests;
+DELETE FROM user_blocks;
+DELETE FROM subscriptions;
+DELETE FROM friends;
+DELETE FROM users;
+
+
+INSERT INTO users(id, name, email, created_at, updated_at) VALUES
+(100, 'john','john@example.com', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+(101, 'andy','andy@example.com', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+(102, 'common','common@example.com', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+(103, 'lisa','lisa@example.com', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+(104, 'kate','kate@example.com', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+
+INSERT INTO friends(user_id, friend_id) VALUES
+(100, 102),
+(101, 102),
+(102, 103);
+
+INSERT INTO user_blocks(requestor_id, target_id) VALUES (100,103);
+INSERT INTO user_blocks(requestor_id, target_id) VALUES (100,104);
+
+INSERT INTO subscriptions(subscription_requestor_id, subscription_target_id) VALUES (101,103);
+
+INSERT INTO friend_requests(id, requestor_id, target_id, status, created_at, updated_at) VALUES
+(200, 103, 104, 'pending', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+(201, 104, 101, 'pending', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+(202, 101, 100, 'rejected', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP),
+(203, 103, 101, 'pending', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// Insert a new record into users table with a normalized email, created_at and updated_at are set to current time.
+// ErrExistedUser is returned when the email has been used
+func (_self DBRepo) CreateUser(ctx context.Context, name string, email string) (*models.User, error) {
+	query := `INSERT INTO users (name, email, created_at, updated_at) VALUES ($1, $2, $3, $3)
+	    ON CONFLICT DO NOTHING
+	    RETURNING id, name, email, created_at, updated_at`
+
+	user := models.User{}
+	err := _self.raw(query, name, storedEmail(email), time.Now()).Bind(ctx, _self.exec(), &user)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrExistedUser
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Get a user from users table by case-insensitive email
+func (_self DBRepo) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := models.Users(qm.Where("lower(email) = ?", emailKey(email))).One(ctx, _self.exec())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotExistedUser
+	}
+	return user, err
+}
+
+// Verify a existing user by case-insensitive email
+func (_self DBRepo) IsExistedUser(ctx context.Context, email string) (bool, error) {
+	return models.Users(qm.Where("lower(email) = ?", emailKey(email))).Exists(ctx, _self.exec())
+}
+
+// Update name of a user, updated_at is set to current time
+func (_self DBRepo) UpdateUserName(ctx context.Context, userId int, name string) error {
+	user := models.User{
+		ID:   userId,
+		Name: name,
+	}
+	rowsAff, err := user.Update(ctx, _self.exec(), boil.Whitelist(models.UserColumns.Name, models.UserColumns.UpdatedAt))
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return ErrNotExistedUser
+	}
+	return nil
+}
+
+// Delete a user with all of friendships, subscriptions, blocking relationships and friend requests of the user
+// in the same transaction
+func (_self DBRepo) DeleteUser(ctx context.Context, userId int) error {
+	return _self.inTx(ctx, func(txRepo DBRepo) error {
+		tx := txRepo.exec()
+		if _, err := models.Friends(
+			qm.Where("user_id = ?", userId), qm.Or("friend_id = ?", userId),
+		).DeleteAll(ctx, tx); err != nil {
+			return err
+		}
+		if _, err := models.Subscriptions(
+			qm.Where("subscription_requestor_id = ?", userId), qm.Or("subscription_target_id = ?", userId),
+		).DeleteAll(ctx, tx); err != nil {
+			return err
+		}
+		if _, err := models.UserBlocks(
+			qm.Where("requestor_id = ?", userId), qm.Or("target_id = ?", userId),
+		).DeleteAll(ctx, tx); err != nil {
+			return err
+		}
+		if _, err := models.FriendRequests(
+			qm.Where("requestor_id = ?", userId), qm.Or("target_id = ?", userId),
+		).DeleteAll(ctx, tx); err != nil {
+			return err
+		}
+
+		rowsAff, err := models.Users(models.UserWhere.ID.EQ(userId)).DeleteAll(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if rowsAff == 0 {
+			return ErrNotExistedUser
+		}
+		return nil
+	})
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepository_CreateUser(t *testing.T) {
+	tcs := map[string]struct {
+		name     string
+		email    string
+		expEmail string
+		expError error
+	}{
+		"success with adding input of user": {
+			name:     "mike",
+			email:    "mike@example.com",
+			expEmail: "mike@example.com",
+		},
+		"success with storing a trimmed email with a lowercase domain": {
+			name:     "mike",
+			email:    " Mike@Example.COM ",
+			expEmail: "Mike@example.com",
+		},
+		"query by an existing email": {
+			name:     "john",
+			email:    "john@example.com",
+			expError: ErrExistedUser,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			user, err := repo.CreateUser(ctx, tc.name, tc.email)
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.name, user.Name)
+				require.Equal(t, tc.expEmail, user.Email)
+				require.False(t, user.CreatedAt.IsZero())
+				require.False(t, user.UpdatedAt.IsZero())
+			}
+		})
+	}
+}
+
+func TestRepository_GetUserByEmail(t *testing.T) {
+	tcs := map[string]struct {
+		email     string
+		expResult *models.User
+		expError  error
+	}{
+		"success with an input email": {
+			email:     "andy@example.com",
+			expResult: &models.User{ID: 101, Name: "andy", Email: "andy@example.com"},
+		},
+		"success with a mixed case email": {
+			email:     "Andy@EXAMPLE.com",
+			expResult: &models.User{ID: 101, Name: "andy", Email: "andy@example.com"},
+		},
+		"query by an unknown email": {
+			email:    "test@example.com",
+			expError: ErrNotExistedUser,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			user, err := repo.GetUserByEmail(ctx, tc.email)
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expResult.ID, user.ID)
+				require.Equal(t, tc.expResult.Name, user.Name)
+				require.Equal(t, tc.expResult.Email, user.Email)
+			}
+		})
+	}
+}
+
+func TestRepository_UpdateUserName(t *testing.T) {
+	tcs := map[string]struct {
+		userId   int
+		name     string
+		expError error
+	}{
+		"success with an input userId": {
+			userId: 100,
+			name:   "johnny",
+		},
+		"query by an unknown userId": {
+			userId:   99,
+			name:     "test",
+			expError: ErrNotExistedUser,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			err := repo.UpdateUserName(ctx, tc.userId, tc.name)
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+			} else {
+				require.NoError(t, err)
+				user, err := models.FindUser(ctx, db, tc.userId)
+				require.NoError(t, err)
+				require.Equal(t, tc.name, user.Name)
+			}
+		})
+	}
+}
+
+func TestRepository_DeleteUser(t *testing.T) {
+	tcs := map[string]struct {
+		userId   int
+		expError error
+	}{
+		"success with an input userId": {
+			userId: 100,
+		},
+		"query by an unknown userId": {
+			userId:   99,
+			expError: ErrNotExistedUser,
+		},
+	}
+
+	for desc, tc := range tcs {
+		runTestRepos(t, desc, func(t *testing.T, repo DBRepo, db *sql.DB) {
+			ctx := context.Background()
+			err := repo.DeleteUser(ctx, tc.userId)
+			if tc.expError != nil {
+				require.EqualError(t, err, tc.expError.Error())
+			} else {
+				require.NoError(t, err)
+				isExisted, err := models.UserExists(ctx, db, tc.userId)
+				require.NoError(t, err)
+				require.False(t, isExisted)
+
+				friends, err := repo.GetFriendsByID(ctx, tc.userId)
+				require.NoError(t, err)
+				require.Empty(t, friends)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Executor is an executor of queries which creates a span of each SQL statement run with a context.
+// Spans of queries end when the query returns, reading its rows is not included
+type Executor struct {
+	exec   boil.ContextExecutor
+	system attribute.KeyValue
+}
+
+// WrapExecutor gets the wrapper of executors of repository.DBRepo.WithExecutor for a database of the driver
+func WrapExecutor(driver string) func(exec boil.ContextExecutor) boil.ContextExecutor {
+	system := semconv.DBSystemNamePostgreSQL
+	if driver == config.DriverSQLite {
+		system = semconv.DBSystemNameSQLite
+	}
+	return func(exec boil.ContextExecutor) boil.ContextExecutor {
+		return Executor{exec: exec, system: system}
+	}
+}
+
+func (_self Executor) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return _self.exec.Exec(query, args...)
+}
+
+func (_self Executor) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return _self.exec.Query(query, args...)
+}
+
+func (_self Executor) QueryRow(query string, args ...interface{}) *sql.Row {
+	return _self.exec.QueryRow(query, args...)
+}
+
+func (_self Executor) ExecContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	ctx, span := _self.start(ctx, query)
+	defer end(span, &err)
+	return _self.exec.ExecContext(ctx, query, args...)
+}
+
+func (_self Executor) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	ctx, span := _self.start(ctx, query)
+	defer end(span, &err)
+	return _self.exec.QueryContext(ctx, query, args...)
+}
+
+func (_self Executor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := _self.start(ctx, query)
+	row := _self.exec.QueryRowContext(ctx, query, args...)
+	err := row.Err()
+	end(span, &err)
+	return row
+}
+
+// Start a client span of a statement, it is named by the first keyword of the statement, ex: SELECT.
+// Values of arguments are not recorded, they may be personal data such as emails
+func (_self Executor) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := "SQL"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		name = strings.ToUpper(fields[0])
+	}
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(_self.system, semconv.DBQueryText(query)),
+	)
+}
+
+// End a span of a statement, a failed statement is an error of the span
+func end(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestTracing_Middleware(t *testing.T) {
+	tcs := map[string]struct {
+		path        string
+		traceparent string
+		expName     string
+		expRoute    string
+		expStatus   int
+		expCode     codes.Code
+		expTraceID  string
+	}{
+		"success with the trace of the traceparent header": {
+			path:        "/v1/recipients",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			expName:     "GET /v1/recipients",
+			expRoute:    "/v1/recipients",
+			expStatus:   http.StatusOK,
+			expCode:     codes.Unset,
+			expTraceID:  "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		"success with a new trace and a server error": {
+			path:      "/v1/users/john@example.com",
+			expName:   "GET /v1/users/{email}",
+			expRoute:  "/v1/users/{email}",
+			expStatus: http.StatusInternalServerError,
+			expCode:   codes.Error,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			recorder := newSpanRecorder(t)
+			r := chi.NewRouter()
+			r.Use(Middleware)
+			r.Route("/v1", func(route chi.Router) {
+				route.Get("/recipients", func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte("{}"))
+				})
+				route.Get("/users/{email}", func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+				})
+			})
+
+			req, err := http.NewRequest("GET", tc.path, nil)
+			require.NoError(t, err)
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			require.Equal(t, tc.expName, span.Name())
+			require.Equal(t, tc.expCode, span.Status().Code)
+			require.Contains(t, span.Attributes(), attribute.String("http.route", tc.expRoute))
+			require.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", tc.expStatus))
+			for _, attr := range span.Attributes() {
+				require.NotEqual(t, "url.path", string(attr.Key))
+			}
+			if tc.expTraceID != "" {
+				require.Equal(t, tc.expTraceID, span.SpanContext().TraceID().String())
+				require.True(t, span.Parent().IsRemote())
+			} else {
+				require.False(t, span.Parent().IsValid())
+			}
+		})
+	}
+}
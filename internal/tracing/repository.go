@@ -0,0 +1,256 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/apperrors"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Repo is a repository which creates a span of each method of the repository it decorates,
+// spans of SQL statements of the method are its children
+type Repo struct {
+	repo repository.SpecRepo
+}
+
+func NewRepo(repo repository.SpecRepo) Repo {
+	return Repo{
+		repo: repo,
+	}
+}
+
+// Start a span of a method of the repository, ex: repository.GetRecipientEmails
+func startMethod(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "repository."+method)
+}
+
+// End a span of a method with the code of its error. Domain errors such as user_not_found are expected results,
+// only internal errors are errors of the span
+func endMethod(span trace.Span, err *error) {
+	if *err != nil {
+		code := apperrors.CodeOf(*err)
+		span.SetAttributes(attribute.String("error.code", code))
+		if code == apperrors.CodeInternal {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+	}
+	span.End()
+}
+
+// Run a unit of work with a decorated repository of the transaction
+func (_self Repo) WithTx(ctx context.Context, fn func(repo repository.SpecRepo) error) (err error) {
+	ctx, span := startMethod(ctx, "WithTx")
+	defer endMethod(span, &err)
+	return _self.repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+		return fn(Repo{repo: repo})
+	})
+}
+
+func (_self Repo) LockUsers(ctx context.Context, userIds ...int) (err error) {
+	ctx, span := startMethod(ctx, "LockUsers")
+	defer endMethod(span, &err)
+	return _self.repo.LockUsers(ctx, userIds...)
+}
+
+func (_self Repo) CreateFriend(ctx context.Context, userId int, friendId int) (err error) {
+	ctx, span := startMethod(ctx, "CreateFriend")
+	defer endMethod(span, &err)
+	return _self.repo.CreateFriend(ctx, userId, friendId)
+}
+
+func (_self Repo) DeleteFriend(ctx context.Context, userId int, friendId int) (err error) {
+	ctx, span := startMethod(ctx, "DeleteFriend")
+	defer endMethod(span, &err)
+	return _self.repo.DeleteFriend(ctx, userId, friendId)
+}
+
+func (_self Repo) GetFriendsByID(ctx context.Context, userId int) (result models.FriendSlice, err error) {
+	ctx, span := startMethod(ctx, "GetFriendsByID")
+	defer endMethod(span, &err)
+	return _self.repo.GetFriendsByID(ctx, userId)
+}
+
+func (_self Repo) GetUnblockedFriendsByIDs(ctx context.Context, userIds []int) (result models.FriendSlice, err error) {
+	ctx, span := startMethod(ctx, "GetUnblockedFriendsByIDs")
+	defer endMethod(span, &err)
+	return _self.repo.GetUnblockedFriendsByIDs(ctx, userIds)
+}
+
+func (_self Repo) GetUnblockedFriendEmails(ctx context.Context, userId int, afterId int, limit int) (result repository.FriendPage, err error) {
+	ctx, span := startMethod(ctx, "GetUnblockedFriendEmails")
+	defer endMethod(span, &err)
+	return _self.repo.GetUnblockedFriendEmails(ctx, userId, afterId, limit)
+}
+
+func (_self Repo) GetCommonFriendEmails(ctx context.Context, userId int, otherId int, afterId int, limit int) (result repository.FriendPage, err error) {
+	ctx, span := startMethod(ctx, "GetCommonFriendEmails")
+	defer endMethod(span, &err)
+	return _self.repo.GetCommonFriendEmails(ctx, userId, otherId, afterId, limit)
+}
+
+func (_self Repo) GetUserBlocksByID(ctx context.Context, userId int) (result models.UserBlockSlice, err error) {
+	ctx, span := startMethod(ctx, "GetUserBlocksByID")
+	defer endMethod(span, &err)
+	return _self.repo.GetUserBlocksByID(ctx, userId)
+}
+
+func (_self Repo) CreateSubscription(ctx context.Context, requestorId int, targetId int) (err error) {
+	ctx, span := startMethod(ctx, "CreateSubscription")
+	defer endMethod(span, &err)
+	return _self.repo.CreateSubscription(ctx, requestorId, targetId)
+}
+
+func (_self Repo) DeleteSubscription(ctx context.Context, requestorId int, targetId int) (err error) {
+	ctx, span := startMethod(ctx, "DeleteSubscription")
+	defer endMethod(span, &err)
+	return _self.repo.DeleteSubscription(ctx, requestorId, targetId)
+}
+
+func (_self Repo) GetRecipientEmails(ctx context.Context, senderId int, afterEmail string, limit int) (result []string, err error) {
+	ctx, span := startMethod(ctx, "GetRecipientEmails")
+	defer endMethod(span, &err)
+	return _self.repo.GetRecipientEmails(ctx, senderId, afterEmail, limit)
+}
+
+func (_self Repo) CreateUserBlock(ctx context.Context, requestorId int, targetId int) (err error) {
+	ctx, span := startMethod(ctx, "CreateUserBlock")
+	defer endMethod(span, &err)
+	return _self.repo.CreateUserBlock(ctx, requestorId, targetId)
+}
+
+func (_self Repo) DeleteUserBlock(ctx context.Context, requestorId int, targetId int) (err error) {
+	ctx, span := startMethod(ctx, "DeleteUserBlock")
+	defer endMethod(span, &err)
+	return _self.repo.DeleteUserBlock(ctx, requestorId, targetId)
+}
+
+func (_self Repo) IsExistedFriend(ctx context.Context, userId int, friendId int) (result bool, err error) {
+	ctx, span := startMethod(ctx, "IsExistedFriend")
+	defer endMethod(span, &err)
+	return _self.repo.IsExistedFriend(ctx, userId, friendId)
+}
+
+func (_self Repo) IsBlockedUser(ctx context.Context, userId int, friendId int) (result bool, err error) {
+	ctx, span := startMethod(ctx, "IsBlockedUser")
+	defer endMethod(span, &err)
+	return _self.repo.IsBlockedUser(ctx, userId, friendId)
+}
+
+func (_self Repo) IsSubscribedUser(ctx context.Context, requestorId int, targetId int) (result bool, err error) {
+	ctx, span := startMethod(ctx, "IsSubscribedUser")
+	defer endMethod(span, &err)
+	return _self.repo.IsSubscribedUser(ctx, requestorId, targetId)
+}
+
+func (_self Repo) GetUserIDByEmail(ctx context.Context, email string) (result int, err error) {
+	ctx, span := startMethod(ctx, "GetUserIDByEmail")
+	defer endMethod(span, &err)
+	return _self.repo.GetUserIDByEmail(ctx, email)
+}
+
+func (_self Repo) GetEmailsByUserIDs(ctx context.Context, userIDs []int) (result []string, err error) {
+	ctx, span := startMethod(ctx, "GetEmailsByUserIDs")
+	defer endMethod(span, &err)
+	return _self.repo.GetEmailsByUserIDs(ctx, userIDs)
+}
+
+func (_self Repo) GetUsersByIDs(ctx context.Context, userIDs []int) (result models.UserSlice, err error) {
+	ctx, span := startMethod(ctx, "GetUsersByIDs")
+	defer endMethod(span, &err)
+	return _self.repo.GetUsersByIDs(ctx, userIDs)
+}
+
+func (_self Repo) GetUsers(ctx context.Context, afterId int, limit int) (result models.UserSlice, err error) {
+	ctx, span := startMethod(ctx, "GetUsers")
+	defer endMethod(span, &err)
+	return _self.repo.GetUsers(ctx, afterId, limit)
+}
+
+func (_self Repo) CountUsers(ctx context.Context) (result int64, err error) {
+	ctx, span := startMethod(ctx, "CountUsers")
+	defer endMethod(span, &err)
+	return _self.repo.CountUsers(ctx)
+}
+
+func (_self Repo) CreateUser(ctx context.Context, name string, email string) (result *models.User, err error) {
+	ctx, span := startMethod(ctx, "CreateUser")
+	defer endMethod(span, &err)
+	return _self.repo.CreateUser(ctx, name, email)
+}
+
+func (_self Repo) GetUserByEmail(ctx context.Context, email string) (result *models.User, err error) {
+	ctx, span := startMethod(ctx, "GetUserByEmail")
+	defer endMethod(span, &err)
+	return _self.repo.GetUserByEmail(ctx, email)
+}
+
+func (_self Repo) IsExistedUser(ctx context.Context, email string) (result bool, err error) {
+	ctx, span := startMethod(ctx, "IsExistedUser")
+	defer endMethod(span, &err)
+	return _self.repo.IsExistedUser(ctx, email)
+}
+
+func (_self Repo) UpdateUserName(ctx context.Context, userId int, name string) (err error) {
+	ctx, span := startMethod(ctx, "UpdateUserName")
+	defer endMethod(span, &err)
+	return _self.repo.UpdateUserName(ctx, userId, name)
+}
+
+func (_self Repo) DeleteUser(ctx context.Context, userId int) (err error) {
+	ctx, span := startMethod(ctx, "DeleteUser")
+	defer endMethod(span, &err)
+	return _self.repo.DeleteUser(ctx, userId)
+}
+
+func (_self Repo) GetFriendSuggestions(ctx context.Context, userId int) (result []repository.FriendSuggestion, err error) {
+	ctx, span := startMethod(ctx, "GetFriendSuggestions")
+	defer endMethod(span, &err)
+	return _self.repo.GetFriendSuggestions(ctx, userId)
+}
+
+func (_self Repo) CreateFriendRequest(ctx context.Context, requestorId int, targetId int) (err error) {
+	ctx, span := startMethod(ctx, "CreateFriendRequest")
+	defer endMethod(span, &err)
+	return _self.repo.CreateFriendRequest(ctx, requestorId, targetId)
+}
+
+func (_self Repo) IsPendingFriendRequest(ctx context.Context, userId int, friendId int) (result bool, err error) {
+	ctx, span := startMethod(ctx, "IsPendingFriendRequest")
+	defer endMethod(span, &err)
+	return _self.repo.IsPendingFriendRequest(ctx, userId, friendId)
+}
+
+func (_self Repo) GetPendingFriendRequest(ctx context.Context, requestorId int, targetId int) (result *models.FriendRequest, err error) {
+	ctx, span := startMethod(ctx, "GetPendingFriendRequest")
+	defer endMethod(span, &err)
+	return _self.repo.GetPendingFriendRequest(ctx, requestorId, targetId)
+}
+
+func (_self Repo) GetIncomingFriendRequests(ctx context.Context, userId int) (result models.FriendRequestSlice, err error) {
+	ctx, span := startMethod(ctx, "GetIncomingFriendRequests")
+	defer endMethod(span, &err)
+	return _self.repo.GetIncomingFriendRequests(ctx, userId)
+}
+
+func (_self Repo) GetOutgoingFriendRequests(ctx context.Context, userId int) (result models.FriendRequestSlice, err error) {
+	ctx, span := startMethod(ctx, "GetOutgoingFriendRequests")
+	defer endMethod(span, &err)
+	return _self.repo.GetOutgoingFriendRequests(ctx, userId)
+}
+
+func (_self Repo) AcceptFriendRequest(ctx context.Context, requestId int) (err error) {
+	ctx, span := startMethod(ctx, "AcceptFriendRequest")
+	defer endMethod(span, &err)
+	return _self.repo.AcceptFriendRequest(ctx, requestId)
+}
+
+func (_self Repo) UpdateFriendRequestStatus(ctx context.Context, requestId int, status string) (err error) {
+	ctx, span := startMethod(ctx, "UpdateFriendRequestStatus")
+	defer endMethod(span, &err)
+	return _self.repo.UpdateFriendRequestStatus(ctx, requestId, status)
+}
@@ -0,0 +1,113 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestTracing_Repo(t *testing.T) {
+	type expSpan struct {
+		name      string
+		code      codes.Code
+		errorCode string
+	}
+	tcs := map[string]struct {
+		run      func(ctx context.Context, repo repository.SpecRepo) error
+		expSpans []expSpan
+	}{
+		"success with a span of the method": {
+			run: func(ctx context.Context, repo repository.SpecRepo) error {
+				_, err := repo.CreateUser(ctx, "john", "john@example.com")
+				return err
+			},
+			expSpans: []expSpan{{name: "repository.CreateUser"}},
+		},
+		"success with spans of a unit of work": {
+			run: func(ctx context.Context, repo repository.SpecRepo) error {
+				return repo.WithTx(ctx, func(repo repository.SpecRepo) error {
+					_, err := repo.CreateUser(ctx, "john", "john@example.com")
+					return err
+				})
+			},
+			expSpans: []expSpan{{name: "repository.CreateUser"}, {name: "repository.WithTx"}},
+		},
+		"success with the code of a domain error": {
+			run: func(ctx context.Context, repo repository.SpecRepo) error {
+				_, err := repo.GetUserByEmail(ctx, "andy@example.com")
+				require.ErrorIs(t, err, repository.ErrNotExistedUser)
+				return nil
+			},
+			expSpans: []expSpan{{name: "repository.GetUserByEmail", errorCode: "user_not_found"}},
+		},
+		"failed with an internal error": {
+			run: func(ctx context.Context, repo repository.SpecRepo) error {
+				canceled, cancel := context.WithCancel(ctx)
+				cancel()
+				require.Error(t, repo.DeleteFriend(canceled, 1, 2))
+				return nil
+			},
+			expSpans: []expSpan{{name: "repository.DeleteFriend", code: codes.Error, errorCode: "internal_error"}},
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			recorder := newSpanRecorder(t)
+
+			require.NoError(t, tc.run(context.Background(), NewRepo(repository.NewMemoryRepo())))
+
+			spans := recorder.Ended()
+			require.Len(t, spans, len(tc.expSpans))
+			for i, exp := range tc.expSpans {
+				require.Equal(t, exp.name, spans[i].Name())
+				require.Equal(t, exp.code, spans[i].Status().Code)
+				if exp.errorCode != "" {
+					require.Contains(t, spans[i].Attributes(), attribute.String("error.code", exp.errorCode))
+				}
+			}
+		})
+	}
+}
+
+func TestTracing_Executor(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	recorder := newSpanRecorder(t)
+	exec := WrapExecutor(config.DriverSQLite)(db)
+
+	ctx, parent := tracer.Start(context.Background(), "repository.GetUsers")
+	_, err = exec.ExecContext(ctx, "CREATE TABLE users (id integer PRIMARY KEY)")
+	require.NoError(t, err)
+	rows, err := exec.QueryContext(ctx, "select id from users")
+	require.NoError(t, err)
+	rows.Close()
+	require.Error(t, exec.QueryRowContext(ctx, "SELECT name FROM users").Scan(new(string)))
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+	for i, exp := range []struct {
+		name  string
+		query string
+		code  codes.Code
+	}{
+		{"CREATE", "CREATE TABLE users (id integer PRIMARY KEY)", codes.Unset},
+		{"SELECT", "select id from users", codes.Unset},
+		{"SELECT", "SELECT name FROM users", codes.Error},
+	} {
+		require.Equal(t, exp.name, spans[i].Name())
+		require.Equal(t, exp.code, spans[i].Status().Code)
+		require.Equal(t, parent.SpanContext().SpanID(), spans[i].Parent().SpanID())
+		require.Contains(t, spans[i].Attributes(), attribute.String("db.query.text", exp.query))
+		require.Contains(t, spans[i].Attributes(), attribute.String("db.system.name", "sqlite"))
+	}
+}
@@ -0,0 +1,55 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// testProvider will be the provider of tracer in tests, the global provider delegates to the first provider which is set
+var testProvider = sdktrace.NewTracerProvider()
+
+func init() {
+	otel.SetTracerProvider(testProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+}
+
+// newSpanRecorder will record spans which end until the test is done
+func newSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	testProvider.RegisterSpanProcessor(recorder)
+	t.Cleanup(func() {
+		testProvider.UnregisterSpanProcessor(recorder)
+	})
+	return recorder
+}
+
+func TestTracing_SetupFileExporter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "spans.json")
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: config.TracingFile, File: file, SampleRatio: 1})
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "GET /v1/recipients")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 1)
+	var exported struct {
+		Name string
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &exported))
+	require.Equal(t, "GET /v1/recipients", exported.Name)
+}
@@ -1,61 +1,249 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/auth"
 	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/controllers"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/metrics"
 	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/tracing"
 	"github.com/go-chi/chi"
+	"github.com/go-chi/cors"
 	"github.com/go-chi/httplog"
 
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	// Check .env.dev file is existing
-	if err := godotenv.Load(".env.dev"); err != nil {
+	// .env.dev is loaded for local development when it exists, variables which are already set are kept
+	if err := godotenv.Load(".env.dev"); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Fatal("failed to load env vars ", err)
 	}
 
-	// Create a database connection
-	db, err := config.NewDatabase()
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal("config error: ", err)
+	}
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(cfg.Database, args[1:]); err != nil {
+			log.Fatal("migrate error: ", err)
+		}
+		return
+	}
+
+	// Spans are exported until the server stops, pending spans are flushed then
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		log.Fatal("DB connection error: ", err)
+		log.Fatal("tracing error: ", err)
+	}
+	defer flushTracing(shutdownTracing)
+
+	serverMetrics := metrics.New()
+	var repo repository.SpecRepo
+	var apiKeys repository.APIKeyStore
+	var healthChecks []controllers.HealthCheck
+	switch cfg.Storage {
+	case config.StorageDatabase:
+		driver, err := cfg.Database.Driver()
+		if err != nil {
+			log.Fatal("DB connection error: ", err)
+		}
+
+		// Create a database connection
+		db, err := config.NewDatabase(cfg.Database)
+		if err != nil {
+			log.Fatal("DB connection error: ", err)
+		}
+		// The database is closed after in-flight requests have been drained
+		defer config.CloseDatabase(db)
+
+		// The server refuses to start with a schema which is behind the migrations, unless the schema check is warn
+		migrator, err := newMigrator(db, driver)
+		if err != nil {
+			log.Fatal("migrations error: ", err)
+		}
+		if err := checkSchema(context.Background(), migrator, cfg.SchemaCheck == "warn"); err != nil {
+			log.Fatal("schema error: ", err)
+		}
+
+		serverMetrics.RegisterDB(db, "friendmanagement")
+		dbRepo := repository.NewDriverRepo(db, driver)
+		if cfg.Tracing.SQLStatements {
+			dbRepo = dbRepo.WithExecutor(tracing.WrapExecutor(driver))
+		}
+		repo = dbRepo
+		apiKeys = dbRepo
+		healthChecks = []controllers.HealthCheck{
+			{Name: "database", Check: db.PingContext},
+			{Name: "migrations", Check: checkSchemaReady(migrator, cfg.SchemaCheck == "warn")},
+		}
+	case config.StorageMemory:
+		// Data is lost when the server stops, it is used for demos and local frontend work
+		memoryRepo := repository.NewMemoryRepo()
+		if err := seedDemoUsers(memoryRepo); err != nil {
+			log.Fatal("failed to seed users ", err)
+		}
+		repo = memoryRepo
+		apiKeys = memoryRepo
+	}
+
+	repo = metrics.NewRepo(tracing.NewRepo(repo), serverMetrics)
+
+	// /v1 routes are open without authentication, ex: for local development
+	var authenticator *auth.Authenticator
+	if cfg.Auth.Enabled {
+		authenticator, err = auth.NewAuthenticator(apiKeys, cfg.Auth)
+		if err != nil {
+			log.Fatal("auth error: ", err)
+		}
+		if cfg.Storage == config.StorageMemory && !authenticator.AcceptsJWT() {
+			log.Print("No API key can be created with the memory storage, configure a JWT key file or disable authentication")
+		} else if accepts, err := acceptsCredentials(context.Background(), apiKeys, authenticator); err != nil {
+			log.Print("failed to list API keys ", err)
+		} else if !accepts {
+			// Clients of a version without authentication are refused until they send a key, see Upgrading of the README
+			log.Print("WARNING: authentication is enabled without an active API key or a JWT key, every /v1 request is refused. ",
+				"Create a key with `friendctl create-api-key <name>` or set AUTH_ENABLED=false")
+		}
+	} else {
+		log.Print("Authentication is disabled, /v1 routes are open")
 	}
-	defer config.CloseDatabase(db)
 
 	//init routers
-	r := initRoutes(db)
+	healthController := controllers.NewHealthController(healthChecks...)
+	r := initRoutes(repo, authenticator, healthController, serverMetrics, cfg)
 
-	// Start server
-	fmt.Println("Server starting at: 8080")
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		fmt.Printf("Server error %v", err)
+	// Start server, it stops on SIGINT or SIGTERM and /readyz fails from then on
+	fmt.Println("Server starting at:", cfg.ListenAddr)
+	if err := serve(newServer(cfg, r), cfg.Server, healthController.SetShuttingDown); err != nil {
+		log.Print("Server error ", err)
+		return
 	}
+	log.Print("Server stopped")
 }
 
-func initRoutes(db *sql.DB) *chi.Mux {
+func flushTracing(shutdown func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		log.Print("failed to flush spans ", err)
+	}
+}
+
+// Report whether any request can be authenticated, it needs an active API key or a JWT key
+func acceptsCredentials(ctx context.Context, apiKeys repository.APIKeyStore, authenticator *auth.Authenticator) (bool, error) {
+	if authenticator.AcceptsJWT() {
+		return true, nil
+	}
+	keys, err := apiKeys.GetAPIKeys(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, key := range keys {
+		if !key.RevokedAt.Valid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Create the same dummy users as the first migration
+func seedDemoUsers(repo repository.SpecRepo) error {
+	for _, name := range []string{"john", "andy", "common", "lisa", "kate"} {
+		if _, err := repo.CreateUser(context.Background(), name, name+"@example.com"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func initRoutes(repo repository.SpecRepo, authenticator *auth.Authenticator, healthController *controllers.HealthController, serverMetrics *metrics.Metrics, cfg config.Config) *chi.Mux {
 	r := chi.NewRouter()
-	dbRepo := repository.NewDBRepo(db)
-	friendController := controllers.NewFriendController(dbRepo)
+	r.Use(controllers.RequestID)
+	r.Use(tracing.Middleware)
+	r.Use(serverMetrics.Middleware)
+	friendController := controllers.NewFriendController(repo, repository.NewEmailNormalizer(cfg.EmailLowercaseLocalPart))
 
+	// API keys are redacted from request logs like the Authorization header
 	logger := httplog.NewLogger("friend-management", httplog.Options{
-		LogLevel: "trace",
+		LogLevel:    cfg.LogLevel,
+		SkipHeaders: []string{auth.APIKeyHeader},
 	})
-	r.Use(httplog.RequestLogger(logger))
+
+	// Browsers of allowed origins can call the API, CORS is disabled without allowed origins
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		r.Use(cors.Handler(cors.Options{
+			AllowedOrigins: cfg.CORS.AllowedOrigins,
+			AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete, http.MethodOptions},
+			AllowedHeaders: []string{"Accept", "Content-Type", "Authorization", auth.APIKeyHeader, controllers.RequestIDHeader},
+			ExposedHeaders: []string{controllers.RequestIDHeader},
+			MaxAge:         300,
+		}))
+	}
+
+	// Probes and scrapes are not logged, they are called every few seconds
+	r.Get("/healthz", healthController.Liveness)
+	r.Get("/readyz", healthController.Readiness)
+	r.Method(http.MethodGet, "/metrics", serverMetrics.Handler())
+
+	// API keys are looked up within the timeout of the route, so a slow lookup cannot hold a request forever
+	authenticate := func(route chi.Router) {
+		if authenticator != nil {
+			route.Use(controllers.Authenticate(authenticator))
+		}
+	}
 
 	r.Route("/v1", func(route chi.Router) {
-		route.Get("/users", friendController.GetUsers)
-		route.Post("/friends", friendController.CreateFriend)
-		route.Get("/friends", friendController.GetFriends)
-		route.Get("/recipients", friendController.GetRecipientEmails)
-		route.Post("/subscription", friendController.CreateSubcription)
-		route.Post("/blocking", friendController.CreateUserBlock)
-		route.Get("/commonFriends", friendController.GetCommonFriends)
+		route.Use(httplog.RequestLogger(logger))
+		route.Group(func(route chi.Router) {
+			route.Use(controllers.Timeout(cfg.RequestTimeout))
+			authenticate(route)
+			route.Get("/users", friendController.GetUsers)
+			route.Post("/users", friendController.CreateUser)
+			route.Get("/users/{email}", friendController.GetUser)
+			route.Patch("/users/{email}", friendController.UpdateUser)
+			route.Delete("/users/{email}", friendController.DeleteUser)
+			route.Get("/users/{email}/friends", friendController.GetFriends)
+			route.Post("/friends", friendController.CreateFriend)
+			route.Delete("/friends", friendController.DeleteFriend)
+			route.Get("/friends", friendController.GetFriends)
+			route.Get("/friends/common", friendController.GetCommonFriends)
+			route.Get("/recipients", friendController.GetRecipientEmails)
+			route.Post("/subscription", friendController.CreateSubcription)
+			route.Delete("/subscription", friendController.DeleteSubscription)
+			route.Post("/blocking", friendController.CreateUserBlock)
+			route.Delete("/blocking", friendController.DeleteUserBlock)
+			route.Get("/commonFriends", friendController.GetCommonFriends)
+			route.Post("/friendRequests", friendController.CreateFriendRequest)
+			route.Get("/friendRequests/incoming", friendController.GetIncomingFriendRequests)
+			route.Get("/friendRequests/outgoing", friendController.GetOutgoingFriendRequests)
+			route.Get("/users/{email}/friendRequests/incoming", friendController.GetIncomingFriendRequests)
+			route.Get("/users/{email}/friendRequests/outgoing", friendController.GetOutgoingFriendRequests)
+			route.Post("/friendRequests/accept", friendController.AcceptFriendRequest)
+			route.Post("/friendRequests/reject", friendController.RejectFriendRequest)
+			route.Post("/friendRequests/cancel", friendController.CancelFriendRequest)
+		})
+		route.Group(func(route chi.Router) {
+			route.Use(controllers.Timeout(cfg.FriendPathTimeout))
+			authenticate(route)
+			route.Get("/suggestions", friendController.GetFriendSuggestions)
+			route.Get("/users/{email}/suggestions", friendController.GetFriendSuggestions)
+			route.Get("/friendPath", friendController.GetFriendPath)
+		})
 	})
 
 	return r
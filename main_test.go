@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/auth"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/controllers"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/metrics"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/models"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutes_CORS(t *testing.T) {
+	tcs := map[string]struct {
+		allowedOrigins []string
+		origin         string
+		expAllowOrigin string
+	}{
+		"success with an allowed origin": {
+			allowedOrigins: []string{"https://app.example.com"},
+			origin:         "https://app.example.com",
+			expAllowOrigin: "https://app.example.com",
+		},
+		"success with all origins by default": {
+			origin:         "https://other.example.com",
+			expAllowOrigin: "*",
+		},
+		"success with all origins": {
+			allowedOrigins: []string{"*"},
+			origin:         "https://other.example.com",
+			expAllowOrigin: "*",
+		},
+		"failed with a disallowed origin": {
+			allowedOrigins: []string{"https://app.example.com"},
+			origin:         "https://evil.example.com",
+		},
+		"failed without allowed origins": {
+			allowedOrigins: []string{},
+			origin:         "https://app.example.com",
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			cfg := config.Default()
+			if tc.allowedOrigins != nil {
+				cfg.CORS.AllowedOrigins = tc.allowedOrigins
+			}
+			r := initRoutes(repository.NewMemoryRepo(), nil, controllers.NewHealthController(), metrics.New(), cfg)
+
+			for _, path := range []string{"/healthz", "/v1/users"} {
+				req, err := http.NewRequest("GET", path, nil)
+				require.NoError(t, err)
+				req.Header.Set("Origin", tc.origin)
+				rr := httptest.NewRecorder()
+				r.ServeHTTP(rr, req)
+
+				require.Equal(t, http.StatusOK, rr.Code, path)
+				require.Equal(t, tc.expAllowOrigin, rr.Header().Get("Access-Control-Allow-Origin"), path)
+				require.Empty(t, rr.Header().Get("Access-Control-Allow-Methods"), path)
+			}
+		})
+	}
+}
+
+// slowAPIKeys will be a key store which does not answer a lookup until the request is done
+type slowAPIKeys struct {
+	repository.APIKeyStore
+}
+
+func (_self slowAPIKeys) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRoutes_AuthenticateWithinTimeout(t *testing.T) {
+	tcs := map[string]struct {
+		path string
+	}{
+		"success with the request timeout": {
+			path: "/v1/users",
+		},
+		"success with the friend path timeout": {
+			path: "/v1/friendPath",
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.RequestTimeout = 10 * time.Millisecond
+			cfg.FriendPathTimeout = 10 * time.Millisecond
+			authenticator, err := auth.NewAuthenticator(slowAPIKeys{}, cfg.Auth)
+			require.NoError(t, err)
+			r := initRoutes(repository.NewMemoryRepo(), authenticator, controllers.NewHealthController(), metrics.New(), cfg)
+
+			req, err := http.NewRequest("GET", tc.path, nil)
+			require.NoError(t, err)
+			req.Header.Set(auth.APIKeyHeader, "fm_valid")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusGatewayTimeout, rr.Code)
+			require.Contains(t, rr.Body.String(), `"code":"request_timeout"`)
+		})
+	}
+}
+
+func TestRoutes_AcceptsCredentials(t *testing.T) {
+	tcs := map[string]struct {
+		apiKeys   []string
+		revoked   []string
+		jwtSecret string
+		expAccept bool
+	}{
+		"success with an active API key": {
+			apiKeys:   []string{"ci", "old"},
+			revoked:   []string{"old"},
+			expAccept: true,
+		},
+		"success with a JWT key file": {
+			jwtSecret: "a-secret-of-jwts-which-has-32-bytes",
+			expAccept: true,
+		},
+		"failed without API keys": {
+			expAccept: false,
+		},
+		"failed with revoked API keys only": {
+			apiKeys:   []string{"old"},
+			revoked:   []string{"old"},
+			expAccept: false,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			ctx := context.Background()
+			repo := repository.NewMemoryRepo()
+			for _, name := range tc.apiKeys {
+				_, err := repo.CreateAPIKey(ctx, name, auth.HashAPIKey("fm_"+name))
+				require.NoError(t, err)
+			}
+			for _, name := range tc.revoked {
+				require.NoError(t, repo.RevokeAPIKey(ctx, name))
+			}
+			cfg := config.AuthConfig{}
+			if tc.jwtSecret != "" {
+				cfg.JWTKeyFile = filepath.Join(t.TempDir(), "jwt.key")
+				require.NoError(t, os.WriteFile(cfg.JWTKeyFile, []byte(tc.jwtSecret), 0600))
+			}
+			authenticator, err := auth.NewAuthenticator(repo, cfg)
+			require.NoError(t, err)
+
+			accepts, err := acceptsCredentials(ctx, repo, authenticator)
+			require.NoError(t, err)
+			require.Equal(t, tc.expAccept, accepts)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/db/migrations"
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/internal/migrate"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status | force version"
+
+// Create a migrator with the embedded migrations of the driver
+func newMigrator(db *sql.DB, driver string) (migrate.Migrator, error) {
+	if driver == config.DriverSQLite {
+		return migrate.New(db, driver, migrations.SQLite())
+	}
+	return migrate.New(db, driver, migrations.Postgres())
+}
+
+// Run a migrate subcommand against the database of the configuration.
+// down reverses the last migration unless the number of steps is given
+func runMigrate(dbConfig config.DatabaseConfig, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	driver, err := dbConfig.Driver()
+	if err != nil {
+		return err
+	}
+	db, err := config.NewDatabase(dbConfig)
+	if err != nil {
+		return err
+	}
+	defer config.CloseDatabase(db)
+	migrator, err := newMigrator(db, driver)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		applied, err := migrator.Up(ctx)
+		printMigrations("applied", applied)
+		return err
+	case args[0] == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number: %s", args[1])
+			}
+		}
+		reversed, err := migrator.Down(ctx, steps)
+		printMigrations("reversed", reversed)
+		return err
+	case args[0] == "status" && len(args) == 1:
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d, dirty: %t, latest: %d\n", status.Version, status.Dirty, status.Latest)
+		printMigrations("pending", status.Pending)
+		return nil
+	case args[0] == "force" && len(args) == 2:
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("version must be a number: %s", args[1])
+		}
+		return migrator.Force(ctx, version)
+	}
+	return errors.New(migrateUsage)
+}
+
+func printMigrations(action string, applied []migrate.Migration) {
+	for _, migration := range applied {
+		fmt.Printf("%s %d_%s\n", action, migration.Version, migration.Name)
+	}
+}
+
+// Verify the schema of the database before the server starts. A dirty database always stops the server,
+// pending migrations stop it unless warnOnly is set, then they are only logged
+func checkSchema(ctx context.Context, migrator migrate.Migrator, warnOnly bool) error {
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case status.Dirty:
+		return fmt.Errorf("database is dirty at version %d, fix it and run `migrate force %d`", status.Version, status.Version)
+	case len(status.Pending) > 0 && warnOnly:
+		log.Printf("warning: database is at version %d, %d migrations are pending up to version %d", status.Version, len(status.Pending), status.Latest)
+	case len(status.Pending) > 0:
+		return fmt.Errorf("database is at version %d, %d migrations are pending up to version %d, run `migrate up`", status.Version, len(status.Pending), status.Latest)
+	case status.IsAhead():
+		log.Printf("warning: database is at version %d which is newer than the latest migration %d of the server", status.Version, status.Latest)
+	}
+	return nil
+}
+
+// Check the schema for readiness like checkSchema without logging, the version may have been changed
+// by `migrate` since the server started
+func checkSchemaReady(migrator migrate.Migrator, warnOnly bool) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case status.Dirty:
+			return fmt.Errorf("database is dirty at version %d", status.Version)
+		case len(status.Pending) > 0 && !warnOnly:
+			return fmt.Errorf("database is at version %d, %d migrations are pending up to version %d", status.Version, len(status.Pending), status.Latest)
+		}
+		return nil
+	}
+}
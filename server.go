@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+)
+
+// Create the HTTP server of the handler with timeouts of connections
+func newServer(cfg config.Config, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           handler,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+}
+
+// Serve requests until SIGINT or SIGTERM is received. onShutdown is called first so readiness fails, requests are
+// still served during the shutdown delay, then the server stops accepting connections and drains in-flight requests
+// within the shutdown timeout. Requests which are still running after the deadline are cut off
+func serve(srv *http.Server, cfg config.ServerConfig, onShutdown func()) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	return serveUntilDone(ctx, srv, listener, cfg, onShutdown)
+}
+
+func serveUntilDone(ctx context.Context, srv *http.Server, listener net.Listener, cfg config.ServerConfig, onShutdown func()) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	onShutdown()
+	if cfg.ShutdownDelay > 0 {
+		log.Printf("Server shutting down, serving requests for %s", cfg.ShutdownDelay)
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	log.Printf("Server shutting down, draining requests within %s", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+		return err
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
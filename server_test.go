@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ToTranMinhNhut/S3_FriendManagementAPI_NhutTo/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_DrainRequestsOnShutdown(t *testing.T) {
+	tcs := map[string]struct {
+		handlerDelay    time.Duration
+		shutdownTimeout time.Duration
+		expError        error
+		expBody         string
+	}{
+		"success with draining an in-flight request": {
+			handlerDelay:    100 * time.Millisecond,
+			shutdownTimeout: time.Second,
+			expBody:         "done",
+		},
+		"failed with a request which is longer than the shutdown timeout": {
+			handlerDelay:    time.Second,
+			shutdownTimeout: 50 * time.Millisecond,
+			expError:        context.DeadlineExceeded,
+		},
+	}
+
+	for desc, tc := range tcs {
+		t.Run(desc, func(t *testing.T) {
+			started := make(chan struct{})
+			srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				time.Sleep(tc.handlerDelay)
+				w.Write([]byte("done"))
+			})}
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			served := make(chan error, 1)
+			shuttingDown := make(chan struct{})
+			go func() {
+				served <- serveUntilDone(ctx, srv, listener, config.ServerConfig{ShutdownTimeout: tc.shutdownTimeout}, func() {
+					close(shuttingDown)
+				})
+			}()
+
+			type response struct {
+				body string
+				err  error
+			}
+			responded := make(chan response, 1)
+			go func() {
+				resp, err := http.Get("http://" + listener.Addr().String())
+				if err != nil {
+					responded <- response{err: err}
+					return
+				}
+				defer resp.Body.Close()
+				body, err := ioutil.ReadAll(resp.Body)
+				responded <- response{body: string(body), err: err}
+			}()
+
+			// Shut down while the request is in flight
+			<-started
+			cancel()
+
+			require.Equal(t, tc.expError, <-served)
+			<-shuttingDown
+			result := <-responded
+			if tc.expError != nil {
+				require.Error(t, result.err)
+				return
+			}
+			require.NoError(t, result.err)
+			require.Equal(t, tc.expBody, result.body)
+
+			// New connections are refused after the shutdown
+			_, err = http.Get("http://" + listener.Addr().String())
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestServer_ServeRequestsDuringShutdownDelay(t *testing.T) {
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("done"))
+	})}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	shuttingDown := make(chan struct{})
+	served := make(chan error, 1)
+	go func() {
+		cfg := config.ServerConfig{ShutdownTimeout: time.Second, ShutdownDelay: 200 * time.Millisecond}
+		served <- serveUntilDone(ctx, srv, listener, cfg, func() {
+			close(shuttingDown)
+		})
+	}()
+	cancel()
+	<-shuttingDown
+
+	// New requests are served during the delay
+	resp, err := http.Get("http://" + listener.Addr().String())
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, "done", string(body))
+
+	require.NoError(t, <-served)
+	_, err = http.Get("http://" + listener.Addr().String())
+	require.Error(t, err)
+}